@@ -12,6 +12,12 @@ type (
 		HTTP `yaml:"http"`
 		Log  `yaml:"logger"`
 		PG   `yaml:"postgres"`
+
+		Storage    `yaml:"storage"`
+		Rates      `yaml:"rates"`
+		Prices     `yaml:"prices"`
+		Moderation `yaml:"moderation"`
+		Auth       `yaml:"auth"`
 	}
 
 	App struct {
@@ -42,6 +48,34 @@ type (
 		PoolMax int    `env-required:"true" yaml:"pool_max" env:"PG_POOL_MAX"`
 		URL     string `env-required:"true" yaml:"db_source" env:"DB_SOURCE"`
 	}
+
+	Storage struct {
+		Path           string `env-required:"true" yaml:"path" env:"STORAGE_PATH"`
+		URLPrefix      string `env-required:"true" yaml:"url-prefix" env:"STORAGE_URL_PREFIX"`
+		MaxImageSizeMB int64  `env-required:"true" yaml:"max-image-size-mb" env:"STORAGE_MAX_IMAGE_SIZE_MB"`
+	}
+
+	Rates struct {
+		ImportInterval time.Duration `env-required:"true" yaml:"import-interval" env:"RATES_IMPORT_INTERVAL"`
+		Feeds          []RateFeed    `yaml:"feeds"`
+	}
+
+	Prices struct {
+		RefreshInterval time.Duration `env-required:"true" yaml:"refresh-interval" env:"PRICES_REFRESH_INTERVAL"`
+	}
+
+	RateFeed struct {
+		Source string `yaml:"source"`
+		Path   string `yaml:"path"`
+	}
+
+	Moderation struct {
+		ClaimTimeout time.Duration `env-required:"true" yaml:"claim-timeout" env:"MODERATION_CLAIM_TIMEOUT"`
+	}
+
+	Auth struct {
+		AdminToken string `yaml:"admin-token" env:"AUTH_ADMIN_TOKEN"`
+	}
 )
 
 // New reads config either from config file either from environment
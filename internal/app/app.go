@@ -11,10 +11,21 @@ import (
 	"net/http"
 	"ngMarketplace/config"
 	"ngMarketplace/internal/category"
+	"ngMarketplace/internal/discount"
+	"ngMarketplace/internal/exchange_rate"
+	"ngMarketplace/internal/moderation"
+	"ngMarketplace/internal/price_history"
 	"ngMarketplace/internal/product"
+	"ngMarketplace/internal/product_image"
+	"ngMarketplace/internal/product_import"
+	"ngMarketplace/internal/product_translation"
+	"ngMarketplace/internal/product_variant"
+	"ngMarketplace/internal/suggestion"
 	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/async"
 	"ngMarketplace/pkg/logger"
 	"ngMarketplace/pkg/postgres"
+	"ngMarketplace/pkg/storage"
 	"os/signal"
 	"sync"
 	"syscall"
@@ -22,12 +33,16 @@ import (
 )
 
 type App struct {
-	cfg        *config.Config
-	wg         sync.WaitGroup
-	router     *gin.Engine
-	httpServer *http.Server
-	logger     logger.Logger
-	pg         *postgres.Postgres
+	cfg            *config.Config
+	wg             sync.WaitGroup
+	runner         *async.BackgroundRunner
+	router         *gin.Engine
+	httpServer     *http.Server
+	logger         logger.Logger
+	pg             *postgres.Postgres
+	rateImporter   *exchange_rate.Importer
+	importWorker   *product_import.Worker
+	priceRefresher *product.PriceRefresher
 }
 
 // New collects everything needed to start the app
@@ -43,30 +58,105 @@ func New(cfg *config.Config) (*App, error) {
 
 	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
 	if err != nil {
-		l.Fatal(fmt.Sprintf("app - Run - postgres.New: %v", err))
+		l.Fatal("app - Run - postgres.New: %v", err)
 	}
 	l.Debug("PostgreSQL initialized")
 
-	//runner := async.NewBackgroundRunner(&a.wg)
+	runner := async.NewBackgroundRunner(&a.wg)
+
+	files, err := storage.NewLocalStorage(cfg.Storage.Path, cfg.Storage.URLPrefix)
+	if err != nil {
+		l.Fatal("app - Run - storage.NewLocalStorage: %v", err)
+	}
+	l.Debug("Local file storage initialized")
+
+	maxImageSize := cfg.Storage.MaxImageSizeMB << 20
 
 	// category Composite
 	categoryRepo := category.NewRepository(pg)
 	categoryUseCase := category.NewUseCase(categoryRepo)
 	categoryHandler := category.NewHandler(categoryUseCase, l)
 
+	// product translation Composite
+	translationRepo := product_translation.NewRepository(pg)
+	translationUseCase := product_translation.NewUseCase(translationRepo)
+	translationHandler := product_translation.NewHandler(translationUseCase, l)
+
+	// product variant Composite
+	variantRepo := product_variant.NewRepository(pg)
+	variantUseCase := product_variant.NewUseCase(variantRepo)
+	variantHandler := product_variant.NewHandler(variantUseCase, l)
+
+	// product image Composite
+	imageRepo := product_image.NewRepository(pg)
+	imageUseCase := product_image.NewUseCase(imageRepo, files, maxImageSize, l)
+	imageHandler := product_image.NewHandler(imageUseCase, l, maxImageSize)
+
+	// discount Composite
+	discountRepo := discount.NewRepository(pg)
+	discountUseCase := discount.NewUseCase(discountRepo)
+	discountHandler := discount.NewHandler(discountUseCase, l)
+
+	// exchange rate Composite
+	rateRepo := exchange_rate.NewRepository(pg)
+	rateUseCase := exchange_rate.NewUseCase(rateRepo)
+	rateHandler := exchange_rate.NewHandler(rateUseCase, l)
+
+	rateFeeds := make([]exchange_rate.FeedFile, 0, len(cfg.Rates.Feeds))
+	for _, feed := range cfg.Rates.Feeds {
+		rateFeeds = append(rateFeeds, exchange_rate.FeedFile{Source: feed.Source, Path: feed.Path})
+	}
+	rateImporter := exchange_rate.NewImporter(rateUseCase, rateFeeds, cfg.Rates.ImportInterval, l)
+
+	// price history Composite
+	priceHistoryRepo := price_history.NewRepository(pg)
+	priceHistoryUseCase := price_history.NewUseCase(priceHistoryRepo)
+	priceHistoryHandler := price_history.NewHandler(priceHistoryUseCase, l)
+
 	// product Composite
-	productRepo := product.NewRepository(pg)
-	productUseCase := product.NewUseCase(productRepo)
+	productRepo := product.NewRepository(pg, translationRepo, priceHistoryRepo)
+	productUseCase := product.NewUseCase(productRepo, translationRepo, variantRepo, discountRepo, rateRepo, categoryRepo, imageUseCase)
 	productHandler := product.NewHandler(productUseCase, l)
+	priceRefresher := product.NewPriceRefresher(productRepo, cfg.Prices.RefreshInterval, l)
+
+	// product import Composite
+	importRepo := product_import.NewRepository(pg)
+	importUseCase := product_import.NewUseCase(importRepo, productUseCase)
+	importHandler := product_import.NewHandler(importUseCase, l)
+	importWorker := product_import.NewWorker(importUseCase, l)
+
+	// moderation Composite
+	moderationRepo := moderation.NewRepository(pg)
+	moderationUseCase := moderation.NewUseCase(moderationRepo, productRepo, cfg.Moderation.ClaimTimeout)
+	moderationHandler := moderation.NewHandler(moderationUseCase, l)
 
-	router := router.NewRouter()
+	// suggestion Composite
+	suggestionRepo := suggestion.NewRepository(pg)
+	suggestionUseCase := suggestion.NewUseCase(suggestionRepo)
+	suggestionHandler := suggestion.NewHandler(suggestionUseCase, l)
+
+	router := router.NewRouter(cfg.Auth.AdminToken)
+	router.Static(cfg.Storage.URLPrefix, cfg.Storage.Path)
 	categoryHandler.Register(router)
 	productHandler.Register(router)
+	importHandler.Register(router)
+	moderationHandler.Register(router)
+	translationHandler.Register(router)
+	variantHandler.Register(router)
+	imageHandler.Register(router)
+	priceHistoryHandler.Register(router)
+	discountHandler.Register(router)
+	rateHandler.Register(router)
+	suggestionHandler.Register(router)
 
 	a.cfg = cfg
+	a.runner = runner
 	a.router = router
 	a.logger = l
 	a.pg = pg
+	a.rateImporter = rateImporter
+	a.importWorker = importWorker
+	a.priceRefresher = priceRefresher
 
 	return a, nil
 }
@@ -78,6 +168,18 @@ func (a *App) Run(ctx context.Context) error {
 
 	grp, ctx := errgroup.WithContext(ctx)
 
+	a.runner.RunAsync(func() {
+		a.rateImporter.Run(ctx)
+	})
+
+	a.runner.RunAsync(func() {
+		a.importWorker.Run(ctx)
+	})
+
+	a.runner.RunAsync(func() {
+		a.priceRefresher.Run(ctx)
+	})
+
 	grp.Go(func() error {
 		return a.startHTTP(ctx)
 	})
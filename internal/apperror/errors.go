@@ -1,6 +1,7 @@
 package apperror
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"ngMarketplace/internal/transport/http/router"
@@ -8,12 +9,16 @@ import (
 
 // Codes for error response
 var (
-	badRequestCode         = "BAD_REQUEST"
-	conflictCode           = "CONFLICT"
-	serviceUnavailableCode = "SERVICE_UNAVAILABLE"
-	serverErrorCode        = "INTERNAL_SERVER_ERROR"
-	unacceptableCode       = "UNACCEPTABLE"
-	notFoundCode           = "NOT_FOUND"
+	badRequestCode           = "BAD_REQUEST"
+	conflictCode             = "CONFLICT"
+	serviceUnavailableCode   = "SERVICE_UNAVAILABLE"
+	serverErrorCode          = "INTERNAL_SERVER_ERROR"
+	unacceptableCode         = "UNACCEPTABLE"
+	notFoundCode             = "NOT_FOUND"
+	forbiddenCode            = "FORBIDDEN"
+	payloadTooLargeCode      = "PAYLOAD_TOO_LARGE"
+	preconditionFailedCode   = "PRECONDITION_FAILED"
+	preconditionRequiredCode = "PRECONDITION_REQUIRED"
 )
 
 // ErrorResponse represents the error response structure
@@ -71,6 +76,22 @@ func WriteNotAcceptableResponse(ctx *gin.Context, err error, details string) {
 	writeError(ctx, errResp)
 }
 
+// WriteForbiddenResponse - answers with forbidden status (403)
+func WriteForbiddenResponse(ctx *gin.Context, err error, details string) {
+	if details == "" {
+		details = "You are not allowed to do this"
+	}
+
+	errResp := &ErrorResponse{
+		Status:  http.StatusForbidden,
+		Code:    forbiddenCode,
+		Error:   err.Error(),
+		Details: details,
+	}
+
+	writeError(ctx, errResp)
+}
+
 // WriteConflictResponse - answers with conflict status (409)
 func WriteConflictResponse(ctx *gin.Context, err error, details string) {
 	if details == "" {
@@ -87,6 +108,65 @@ func WriteConflictResponse(ctx *gin.Context, err error, details string) {
 	writeError(ctx, errResp)
 }
 
+// WritePreconditionFailedResponse - answers with precondition failed status (412)
+func WritePreconditionFailedResponse(ctx *gin.Context, err error, details string) {
+	if details == "" {
+		details = "What you have sent was made for an outdated version"
+	}
+
+	errResp := &ErrorResponse{
+		Status:  http.StatusPreconditionFailed,
+		Code:    preconditionFailedCode,
+		Error:   err.Error(),
+		Details: details,
+	}
+
+	writeError(ctx, errResp)
+}
+
+// WritePreconditionRequiredResponse - answers with precondition required status (428)
+func WritePreconditionRequiredResponse(ctx *gin.Context, err error, details string) {
+	if details == "" {
+		details = "The request must be conditional"
+	}
+
+	errResp := &ErrorResponse{
+		Status:  http.StatusPreconditionRequired,
+		Code:    preconditionRequiredCode,
+		Error:   err.Error(),
+		Details: details,
+	}
+
+	writeError(ctx, errResp)
+}
+
+// WriteIfMatchErrResponse - answers to a write without a usable If-Match header, with precondition required
+// status (428) when it is missing and with precondition failed status (412) when it holds an unknown tag
+func WriteIfMatchErrResponse(ctx *gin.Context, err error) {
+	if errors.Is(err, router.ErrPreconditionRequired) {
+		WritePreconditionRequiredResponse(ctx, err, "Send If-Match with the ETag of the record you have read")
+		return
+	}
+
+	WritePreconditionFailedResponse(ctx, err, "")
+}
+
+// WritePayloadTooLargeResponse - answers with payload too large status (413)
+func WritePayloadTooLargeResponse(ctx *gin.Context, err error, details string) {
+	if details == "" {
+		details = "What you have sent is too large"
+	}
+
+	errResp := &ErrorResponse{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    payloadTooLargeCode,
+		Error:   err.Error(),
+		Details: details,
+	}
+
+	writeError(ctx, errResp)
+}
+
 // WriteInternalErrResponse - answers with internal server error status (500)
 func WriteInternalErrResponse(ctx *gin.Context, err error, details string) {
 	if details == "" {
@@ -3,6 +3,7 @@ package category
 import (
 	"encoding/json"
 	"ngMarketplace/internal/common"
+	"time"
 )
 
 // getCategoryRequest represents the param request for getting a category
@@ -26,10 +27,26 @@ type updateCategoryRequest struct {
 	AttributeSchema json.RawMessage `json:"attribute_schema"`
 }
 
-// getCategoriesRequest represents the request query for getting the list of categories
+// batchGetRequest represents the request body for getting several categories at once
+type batchGetRequest struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// getCategoryQuery represents the query for getting a category with fields and include
+type getCategoryQuery struct {
+	common.Expansion
+}
+
+// getCategoriesRequest represents the request query for getting the list of categories,
+// state=deleted|all is the admin view including soft deleted categories, created_after and created_before
+// are RFC 3339 times bounding the creation time
 type getCategoriesRequest struct {
-	CategoryName string `form:"category_name"`
-	Language     string `form:"language"`
+	CategoryName  string     `form:"category_name"`
+	Language      string     `form:"language"`
+	State         string     `form:"state"` // available to admins only
+	CreatedAfter  *time.Time `form:"created_after"`
+	CreatedBefore *time.Time `form:"created_before"`
+	common.Expansion
 	common.Filters
 }
 
@@ -15,13 +15,15 @@ const (
 	categoriesURL         = "/categories"
 	categoryURL           = "/categories/:id"
 	categoriesByParentURL = "/categories/parent/:parent_id"
+	batchGetURL           = "/categories/batch-get"
 )
 
 type UseCase interface {
 	Create(ctx context.Context, category *Category) error
-	GetCategory(ctx context.Context, categoryID int64) (*Category, error)
-	UpdateCategory(ctx context.Context, categoryID int64, category *updateCategoryRequest) (*Category, error)
-	DeleteCategory(ctx context.Context, categoryID int64) error
+	GetCategory(ctx context.Context, categoryID int64, expansion common.Expansion) (*Category, error)
+	BatchGetCategories(ctx context.Context, ids []int, expansion common.Expansion) ([]*Category, []int, error)
+	UpdateCategory(ctx context.Context, categoryID int64, category *updateCategoryRequest, version int) (*Category, error)
+	DeleteCategory(ctx context.Context, categoryID int64, version int) error
 	GetCategories(ctx context.Context, filters getCategoriesRequest) ([]*Category, common.Metadata, error)
 	GetCategoryByParentID(ctx context.Context, parentID int64) ([]*Category, error)
 }
@@ -45,6 +47,7 @@ func (h *Handler) Register(router *gin.Engine) {
 	router.DELETE(categoryURL, h.deleteCategoryHandler)
 	router.GET(categoriesURL, h.listCategoriesHandler)
 	router.GET(categoriesByParentURL, h.getByParentIDHandler)
+	router.POST(batchGetURL, h.batchGetCategoriesHandler)
 }
 
 // CreateCategoryHandler creates a new category in the marketplace
@@ -82,7 +85,7 @@ func (h *Handler) createCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"category": category}, nil); err != nil {
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"category": category}, router.ETagHeader(category.Version)); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
 		ctx.JSON(http.StatusCreated, gin.H{"category": category})
 		return
@@ -100,26 +103,98 @@ func (h *Handler) showCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
-	category, err := h.useCase.GetCategory(ctx, req.ID)
+	var query getCategoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "fields and include must be comma separated lists")
+		return
+	}
+
+	category, err := h.useCase.GetCategory(ctx, req.ID, query.Expansion)
 	if err != nil {
 		h.logger.Error("%s: h.useCase.GetCategory: %v", op, err)
 		switch {
 		case errors.Is(err, ErrCategoryNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Category you are seeking does not exist")
+		case errors.Is(err, common.ErrExpansionValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check fields and include parameters")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
 		return
 	}
 
-	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"category": category}, nil); err != nil {
+	projected, err := query.Project(category)
+	if err != nil {
+		h.logger.Error("%s: query.Project: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	etag, err := router.RepresentationETag(category.Version, projected)
+	if err != nil {
+		h.logger.Error("%s: router.RepresentationETag: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if router.NotModified(ctx, etag) {
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"category": projected}, http.Header{"ETag": []string{etag}}); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
-		ctx.JSON(http.StatusOK, gin.H{"category": category})
+		ctx.JSON(http.StatusOK, gin.H{"category": projected})
+		return
+	}
+}
+
+// batchGetCategoriesHandler returns categories by the list of ids in the order they were requested in,
+// ids of categories which do not exist are returned in not_found
+func (h *Handler) batchGetCategoriesHandler(ctx *gin.Context) {
+	const op = "batchGetCategoriesHandler"
+
+	var req batchGetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "ids must be sent as a list of category ids")
+		return
+	}
+
+	var query getCategoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "fields and include must be comma separated lists")
+		return
+	}
+
+	categories, notFound, err := h.useCase.BatchGetCategories(ctx, req.IDs, query.Expansion)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.BatchGetCategories: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrBatchValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check ids and parameters")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	projected, err := common.ProjectAll(query.Expansion, categories)
+	if err != nil {
+		h.logger.Error("%s: common.ProjectAll: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"categories": projected, "not_found": notFound}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"categories": projected, "not_found": notFound})
 		return
 	}
 }
 
-// updateCategoryHandler updates category by id
+// updateCategoryHandler updates category by id, If-Match must hold the ETag of the category
 func (h *Handler) updateCategoryHandler(ctx *gin.Context) {
 	const op = "updateCategoryHandler"
 
@@ -130,6 +205,13 @@ func (h *Handler) updateCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
+	version, err := router.IfMatchVersion(ctx)
+	if err != nil {
+		h.logger.Error("%s: router.IfMatchVersion: %v", op, err)
+		apperror.WriteIfMatchErrResponse(ctx, err)
+		return
+	}
+
 	var input updateCategoryRequest
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -138,12 +220,14 @@ func (h *Handler) updateCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
-	category, err := h.useCase.UpdateCategory(ctx, req.ID, &input)
+	category, err := h.useCase.UpdateCategory(ctx, req.ID, &input, version)
 	if err != nil {
 		h.logger.Error("%s: h.useCase.UpdateCategory: %v", op, err)
 		switch {
 		case errors.Is(err, ErrCategoryNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Category you are seeking to update does not exist")
+		case errors.Is(err, ErrVersionMismatch):
+			apperror.WritePreconditionFailedResponse(ctx, err, "Category was changed by someone else, read it again")
 		case errors.Is(err, ErrCategoryValidationFailed):
 			apperror.WriteBadRequestResponse(ctx, err, err.Error())
 		default:
@@ -152,14 +236,14 @@ func (h *Handler) updateCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"category": category}, nil); err != nil {
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"category": category}, router.ETagHeader(category.Version)); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
 		ctx.JSON(http.StatusOK, gin.H{"category": category})
 		return
 	}
 }
 
-// deleteCategoryHandler deletes category by id
+// deleteCategoryHandler deletes category by id, If-Match must hold the ETag of the category
 func (h *Handler) deleteCategoryHandler(ctx *gin.Context) {
 	const op = "deleteCategoryHandler"
 
@@ -170,11 +254,20 @@ func (h *Handler) deleteCategoryHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.useCase.DeleteCategory(ctx, req.ID); err != nil {
+	version, err := router.IfMatchVersion(ctx)
+	if err != nil {
+		h.logger.Error("%s: router.IfMatchVersion: %v", op, err)
+		apperror.WriteIfMatchErrResponse(ctx, err)
+		return
+	}
+
+	if err = h.useCase.DeleteCategory(ctx, req.ID, version); err != nil {
 		h.logger.Error("%s: h.useCase.DeleteCategory: %v", op, err)
 		switch {
 		case errors.Is(err, ErrCategoryNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Category you are seeking to delete does not exist")
+		case errors.Is(err, ErrVersionMismatch):
+			apperror.WritePreconditionFailedResponse(ctx, err, "Category was changed by someone else, read it again")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
@@ -200,6 +293,11 @@ func (h *Handler) listCategoriesHandler(ctx *gin.Context) {
 		return
 	}
 
+	if common.AdminOnly(req.State, 0) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state")
+		return
+	}
+
 	categories, metadata, err := h.useCase.GetCategories(ctx, req)
 	if err != nil {
 		switch {
@@ -211,9 +309,16 @@ func (h *Handler) listCategoriesHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"categories": categories, "metadata": metadata}, nil); err != nil {
+	projected, err := common.ProjectAll(req.Expansion, categories)
+	if err != nil {
+		h.logger.Error("%s: common.ProjectAll: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"categories": projected, "metadata": metadata}, nil); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
-		ctx.JSON(http.StatusOK, gin.H{"categories": categories, "metadata": metadata})
+		ctx.JSON(http.StatusOK, gin.H{"categories": projected, "metadata": metadata})
 		return
 	}
 }
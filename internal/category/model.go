@@ -16,12 +16,28 @@ type Category struct {
 	ParentID        *int            `json:"parent_id"`
 	Language        string          `json:"language"`
 	AttributeSchema json.RawMessage `json:"attribute_schema"`
-	CreatedAt       time.Time       `json:"-"`
+	CreatedAt       time.Time       `json:"created_at"`
 	Active          bool            `json:"-"`
-	UpdatedAt       time.Time       `json:"-"`
-	DeletedAt       *time.Time      `json:"-"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       *time.Time      `json:"deleted_at,omitempty"`
+	Version         int             `json:"-"`
+
+	Parent   *Category   `json:"parent,omitempty"`
+	Children []*Category `json:"children,omitempty"`
 }
 
+// Fields and relations of categories clients may ask for with fields and include
+var (
+	categoryFields    = []string{"category_id", "category_name", "parent_id", "language", "attribute_schema", "created_at", "updated_at", "deleted_at"}
+	categoryRelations = []string{relationParent, relationChildren}
+)
+
+// Relations of categories embedded on request, they are named after the keys they are embedded under
+const (
+	relationParent   = "parent"
+	relationChildren = "children"
+)
+
 func validateCategory(v *validator.Validator, category *Category) {
 	v.Check(len(category.CategoryName) <= 50, "category_name", "must not be more than 50 bytes long")
 	v.Check(validator.In(category.Language, "tj", "ru", "en"), "category_language", "must be tj, ru, or en")
@@ -66,6 +82,7 @@ var (
 	ErrInvalidParentID   = errors.New("parent category does not exist")
 	ErrConnectionFailed  = errors.New("database connection failed")
 	ErrCategoryNotFound  = errors.New("category not found")
+	ErrVersionMismatch   = errors.New("category was changed since it was read")
 )
 
 // Service Errors
@@ -27,7 +27,7 @@ func (r *Repository) Create(ctx context.Context, category *Category) error {
 		VALUES 
 		       ($1, $2, $3, $4)
 		RETURNING 
-			category_id, created_at, active`
+			category_id, created_at, updated_at, active, version`
 
 	args := []interface{}{
 		category.CategoryName,
@@ -43,7 +43,9 @@ func (r *Repository) Create(ctx context.Context, category *Category) error {
 	).Scan(
 		&category.CategoryID,
 		&category.CreatedAt,
+		&category.UpdatedAt,
 		&category.Active,
+		&category.Version,
 	); err != nil {
 		if postgres.IsPgErr(err) {
 			err = postgres.Conv2CustomErr(err)
@@ -74,7 +76,8 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Category, error) {
 
 	query := `
 		SELECT 
-		    category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at
+		    category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at,
+		    version
 		FROM 
 		    categories
 		WHERE 
@@ -99,6 +102,7 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Category, error) {
 		&category.Active,
 		&category.UpdatedAt,
 		&category.DeletedAt,
+		&category.Version,
 	); err != nil {
 		if errors.Is(err, postgres.ErrNoRows) {
 			return nil, ErrCategoryNotFound
@@ -109,7 +113,8 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Category, error) {
 	return &category, nil
 }
 
-// Update method updates category entirely
+// Update method updates category entirely if it is still of the version it was read in, the category
+// is read beforehand, so a missing row means it was changed or deleted since then
 func (r *Repository) Update(ctx context.Context, category *Category) error {
 	const op = "Update"
 
@@ -125,7 +130,9 @@ func (r *Repository) Update(ctx context.Context, category *Category) error {
 		    category_id = $5
 		AND 
 		    active = true
-		RETURNING updated_at`
+		AND
+		    version = $6
+		RETURNING updated_at, version`
 
 	args := []interface{}{
 		category.CategoryName,
@@ -133,16 +140,17 @@ func (r *Repository) Update(ctx context.Context, category *Category) error {
 		category.Language,
 		category.AttributeSchema,
 		category.CategoryID,
+		category.Version,
 	}
 
 	if err := r.client.Pool.QueryRow(
 		ctx,
 		query,
 		args...,
-	).Scan(&category.UpdatedAt); err != nil {
+	).Scan(&category.UpdatedAt, &category.Version); err != nil {
 		switch {
 		case errors.Is(err, postgres.ErrNoRows):
-			return ErrCategoryNotFound
+			return ErrVersionMismatch
 		default:
 			return postgres.ErrDoQuery(op, err)
 		}
@@ -151,67 +159,105 @@ func (r *Repository) Update(ctx context.Context, category *Category) error {
 	return nil
 }
 
-// SoftDelete method deletes category softly, meaning that it makes active false and that's it
-func (r *Repository) SoftDelete(ctx context.Context, id int64) error {
+// SoftDelete method deletes category softly, meaning that it makes active false and that's it. The category
+// must be of the version, 0 deletes any version
+func (r *Repository) SoftDelete(ctx context.Context, id int64, version int) error {
 	const op = "Delete"
 
-	query := `
-		UPDATE 
-		    categories
-		SET 
-		    deleted_at = now(), 
-		    active = false
-		WHERE 
-		    category_id = $1 
-		AND 
-		    active = true
-		RETURNING deleted_at`
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		query := `SELECT version FROM categories WHERE category_id = $1 AND active = true FOR UPDATE`
 
-	var deletedAt *time.Time
-	err := r.client.Pool.QueryRow(ctx, query, id).Scan(&deletedAt)
-	if err != nil {
-		switch {
-		case errors.Is(err, postgres.ErrNoRows):
-			return ErrCategoryNotFound
-		default:
-			return postgres.ErrDoQuery(op, err)
+		var current int
+		if err := tx.QueryRow(ctx, query, id).Scan(&current); err != nil {
+			switch {
+			case errors.Is(err, postgres.ErrNoRows):
+				return ErrCategoryNotFound
+			default:
+				return postgres.ErrDoQuery(op, err)
+			}
 		}
-	}
 
-	return nil
+		if version != 0 && version != current {
+			return ErrVersionMismatch
+		}
+
+		query = `
+			UPDATE 
+			    categories
+			SET 
+			    deleted_at = now(), 
+			    active = false
+			WHERE 
+			    category_id = $1`
+
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		return nil
+	})
 }
 
-// GetPaginated method returns the list of categories and other data for metadata
-func (r *Repository) GetPaginated(ctx context.Context, categoryName string, language string, filters common.Filters) ([]*Category, int, error) {
+// sortKeys maps sort columns to expressions categories are ordered by and their SQL types,
+// root categories go as having parent 0
+var sortKeys = map[string][2]string{
+	"category_id":   {"category_id", "integer"},
+	"category_name": {"category_name", "text"},
+	"parent_id":     {"coalesce(parent_id, 0)", "integer"},
+	"created_at":    {"created_at", "timestamptz"},
+	"updated_at":    {"updated_at", "timestamptz"},
+}
+
+// GetPaginated method returns the list of categories visible in the scope and metadata, categories are paginated
+// by the cursor of filters when it is given. createdAfter and createdBefore bound the creation time when they are not nil. categoryNames are the variants of one name (e.g. Cyrillic and Latin spelling),
+// a category matching any of them is returned
+func (r *Repository) GetPaginated(ctx context.Context, categoryNames []string, language string, createdAfter *time.Time, createdBefore *time.Time, visibility common.Visibility, filters common.Filters) ([]*Category, common.Metadata, error) {
 	const op = "GetPaginated"
 
+	key := sortKeys[filters.SortColumn()]
+	keyset := filters.Keyset(key[0], key[1], "category_id", 7)
+
 	query := fmt.Sprintf(`
 		SELECT 
-		    count(*) OVER(), category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at
+		    %[1]s, category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at,
+		    (%[2]s)::text
 		FROM 
 		    categories
 		WHERE 
-		    (to_tsvector('simple', category_name) @@ plainto_tsquery('simple', $1) OR $1 = '') 
+		    (to_tsvector('simple', category_name) @@ %[3]s OR coalesce(cardinality($1::text[]), 0) = 0) 
 		AND
 		    language = $2
+		AND
+		    (created_at > $5::timestamptz OR $5::timestamptz IS NULL)
+		AND
+		    (created_at < $6::timestamptz OR $6::timestamptz IS NULL)
+		AND
+		    %[6]s
+		AND
+		    %[4]s
 		ORDER BY
-		    %s %s, category_id ASC
+		    %[2]s %[5]s, category_id %[5]s
 		LIMIT $3 
-		OFFSET $4`, filters.SortColumn(), filters.SortDirection())
+		OFFSET $4`, keyset.Count, key[0], common.AnyTSQuery("plainto_tsquery", "simple", 1, len(categoryNames)), keyset.Condition, keyset.Direction, visibility.StateCondition("categories"))
 
-	args := []interface{}{categoryName, language, filters.Limit(), filters.Offset()}
+	args := []interface{}{categoryNames, language, filters.FetchLimit(), keyset.Offset, createdAfter, createdBefore}
+	args = append(args, keyset.Args...)
 
 	rows, err := r.client.Pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, postgres.ErrDoQuery(op, err)
+		return nil, common.Metadata{}, postgres.ErrDoQuery(op, err)
 	}
 	defer rows.Close()
 
 	totalRecords := 0
 	categories := []*Category{}
+	keys := []common.Key{}
 
 	for rows.Next() {
-		var category Category
+		var (
+			category Category
+			key      common.Key
+		)
 		err = rows.Scan(
 			&totalRecords,
 			&category.CategoryID,
@@ -223,19 +269,24 @@ func (r *Repository) GetPaginated(ctx context.Context, categoryName string, lang
 			&category.Active,
 			&category.UpdatedAt,
 			&category.DeletedAt,
+			&key.Value,
 		)
 		if err != nil {
-			return nil, 0, postgres.ErrScan(op, err)
+			return nil, common.Metadata{}, postgres.ErrScan(op, err)
 		}
 
+		key.ID = int64(category.CategoryID)
 		categories = append(categories, &category)
+		keys = append(keys, key)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, 0, postgres.ErrReadRows(op, err)
+		return nil, common.Metadata{}, postgres.ErrReadRows(op, err)
 	}
 
-	return categories, totalRecords, nil
+	categories, metadata := common.Paginate(filters, categories, keys, totalRecords)
+
+	return categories, metadata, nil
 }
 
 // GetByParentID gets categories by parent_id
@@ -286,6 +337,143 @@ func (r *Repository) GetByParentID(ctx context.Context, parentID int64) ([]*Cate
 	return categories, nil
 }
 
+// GetByIDs method gets active categories by ids at once
+func (r *Repository) GetByIDs(ctx context.Context, ids []int) ([]*Category, error) {
+	const op = "GetByIDs"
+
+	query := `
+		SELECT 
+		    category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at
+		FROM 
+		    categories
+		WHERE 
+		    active = true 
+		AND 
+			category_id = ANY($1::int[])`
+
+	categories := []*Category{}
+
+	rows, err := r.client.Pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category Category
+		err = rows.Scan(
+			&category.CategoryID,
+			&category.CategoryName,
+			&category.ParentID,
+			&category.Language,
+			&category.AttributeSchema,
+			&category.CreatedAt,
+			&category.Active,
+			&category.UpdatedAt,
+			&category.DeletedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		categories = append(categories, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return categories, nil
+}
+
+// GetByParentIDs method gets active children of several categories at once, ordered by parent
+func (r *Repository) GetByParentIDs(ctx context.Context, parentIDs []int) ([]*Category, error) {
+	const op = "GetByParentIDs"
+
+	query := `
+		SELECT 
+		    category_id, category_name, parent_id, language, attribute_schema, created_at, active, updated_at, deleted_at
+		FROM 
+		    categories
+		WHERE 
+		    active = true 
+		AND 
+			parent_id = ANY($1::int[])
+		ORDER BY
+		    parent_id, category_id`
+
+	categories := []*Category{}
+
+	rows, err := r.client.Pool.Query(ctx, query, parentIDs)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category Category
+		err = rows.Scan(
+			&category.CategoryID,
+			&category.CategoryName,
+			&category.ParentID,
+			&category.Language,
+			&category.AttributeSchema,
+			&category.CreatedAt,
+			&category.Active,
+			&category.UpdatedAt,
+			&category.DeletedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		categories = append(categories, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return categories, nil
+}
+
+// GetSubtreeIDs method returns the ids together with ids of all active descendants of their categories
+func (r *Repository) GetSubtreeIDs(ctx context.Context, ids []int) ([]int, error) {
+	const op = "GetSubtreeIDs"
+
+	query := `
+		WITH RECURSIVE subtree AS (
+		    SELECT unnest($1::int[]) AS category_id
+		    UNION
+		    SELECT c.category_id FROM categories c JOIN subtree s ON c.parent_id = s.category_id
+		    WHERE c.active = true
+		)
+		SELECT category_id FROM subtree ORDER BY category_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	subtree := []int{}
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		subtree = append(subtree, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return subtree, nil
+}
+
 // Restore restores some category by category_ID
 func (r *Repository) Restore(ctx context.Context, categoryID int64) error {
 	const op = "Restore"
@@ -4,16 +4,21 @@ import (
 	"context"
 	"fmt"
 	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/common/attribute_schema/translit"
 	"ngMarketplace/pkg/validator"
+	"strings"
+	"time"
 )
 
 type Storage interface {
 	Create(ctx context.Context, category *Category) error
 	GetByID(ctx context.Context, id int64) (*Category, error)
 	Update(ctx context.Context, category *Category) error
-	SoftDelete(ctx context.Context, id int64) error
-	GetPaginated(ctx context.Context, categoryName string, language string, filters common.Filters) ([]*Category, int, error)
+	SoftDelete(ctx context.Context, id int64, version int) error
+	GetPaginated(ctx context.Context, categoryNames []string, language string, createdAfter *time.Time, createdBefore *time.Time, visibility common.Visibility, filters common.Filters) ([]*Category, common.Metadata, error)
 	GetByParentID(ctx context.Context, parentID int64) ([]*Category, error)
+	GetByIDs(ctx context.Context, ids []int) ([]*Category, error)
+	GetByParentIDs(ctx context.Context, parentIDs []int) ([]*Category, error)
 	Restore(ctx context.Context, categoryID int64) error
 }
 
@@ -39,16 +44,118 @@ func (s *Service) Create(ctx context.Context, category *Category) error {
 	return nil
 }
 
-func (s *Service) GetCategory(ctx context.Context, categoryID int64) (*Category, error) {
-	return s.Repository.GetByID(ctx, categoryID)
+// GetCategory returns the category with the relations requested by include
+func (s *Service) GetCategory(ctx context.Context, categoryID int64, expansion common.Expansion) (*Category, error) {
+	v := validator.New()
+
+	if common.ValidateExpansion(v, expansion, categoryFields, categoryRelations); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", common.ErrExpansionValidationFailed, v.Errors)
+	}
+
+	category, err := s.Repository.GetByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.expand(ctx, []*Category{category}, expansion); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// BatchGetCategories returns categories in the order of ids together with ids of categories which were not found,
+// relations requested by include are embedded
+func (s *Service) BatchGetCategories(ctx context.Context, ids []int, expansion common.Expansion) ([]*Category, []int, error) {
+	v := validator.New()
+
+	common.ValidateBatch(v, ids)
+
+	if common.ValidateExpansion(v, expansion, categoryFields, categoryRelations); !v.Valid() {
+		return nil, nil, fmt.Errorf("%w: %w", common.ErrBatchValidationFailed, v.Errors)
+	}
+
+	categories, err := s.Repository.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	categories, notFound := common.OrderByIDs(ids, categories, func(category *Category) int { return category.CategoryID })
+
+	if err = s.expand(ctx, categories, expansion); err != nil {
+		return nil, nil, err
+	}
+
+	return categories, notFound, nil
 }
 
-func (s *Service) UpdateCategory(ctx context.Context, categoryID int64, newCategory *updateCategoryRequest) (*Category, error) {
+// expand embeds the relations requested by include into categories, every relation is loaded
+// for all categories with one query
+func (s *Service) expand(ctx context.Context, categories []*Category, expansion common.Expansion) error {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	if expansion.Includes(relationParent) {
+		parentIDs := []int{}
+		for _, category := range categories {
+			if category.ParentID != nil {
+				parentIDs = append(parentIDs, *category.ParentID)
+			}
+		}
+
+		parents, err := s.Repository.GetByIDs(ctx, parentIDs)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[int]*Category, len(parents))
+		for _, parent := range parents {
+			byID[parent.CategoryID] = parent
+		}
+
+		for _, category := range categories {
+			if category.ParentID != nil {
+				category.Parent = byID[*category.ParentID]
+			}
+		}
+	}
+
+	if expansion.Includes(relationChildren) {
+		ids := make([]int, 0, len(categories))
+		for _, category := range categories {
+			ids = append(ids, category.CategoryID)
+		}
+
+		children, err := s.Repository.GetByParentIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		byParent := make(map[int][]*Category, len(categories))
+		for _, child := range children {
+			byParent[*child.ParentID] = append(byParent[*child.ParentID], child)
+		}
+
+		for _, category := range categories {
+			category.Children = byParent[category.CategoryID]
+		}
+	}
+
+	return nil
+}
+
+// UpdateCategory changes the category of the version, 0 updates any version
+func (s *Service) UpdateCategory(ctx context.Context, categoryID int64, newCategory *updateCategoryRequest, version int) (*Category, error) {
 	category, err := s.Repository.GetByID(ctx, categoryID)
 	if err != nil {
 		return nil, err
 	}
 
+	if version != 0 && category.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
 	if newCategory.CategoryName != nil {
 		category.CategoryName = *newCategory.CategoryName
 	}
@@ -78,8 +185,9 @@ func (s *Service) UpdateCategory(ctx context.Context, categoryID int64, newCateg
 	return category, nil
 }
 
-func (s *Service) DeleteCategory(ctx context.Context, categoryID int64) error {
-	return s.Repository.SoftDelete(ctx, categoryID)
+// DeleteCategory deletes the category of the version softly, 0 deletes any version
+func (s *Service) DeleteCategory(ctx context.Context, categoryID int64, version int) error {
+	return s.Repository.SoftDelete(ctx, categoryID, version)
 }
 
 func (s *Service) GetCategories(ctx context.Context, filters getCategoriesRequest) ([]*Category, common.Metadata, error) {
@@ -99,22 +207,36 @@ func (s *Service) GetCategories(ctx context.Context, filters getCategoriesReques
 		filters.Language = "ru"
 	}
 
-	filters.SortSafeList = []string{"category_id", "category_name", "parent_id", "-category_id", "-category_name", "-parent_id"}
+	filters.SortSafeList = []string{
+		"category_id", "category_name", "parent_id", "created_at", "updated_at",
+		"-category_id", "-category_name", "-parent_id", "-created_at", "-updated_at",
+	}
 
 	v := validator.New()
 
 	v.Check(validator.In(filters.Language, "ru", "tj", "en"), "language", "language must be one of [tj ru en]")
+	common.ValidateState(v, filters.State)
+	common.ValidateCreated(v, filters.CreatedAfter, filters.CreatedBefore)
+	common.ValidateExpansion(v, filters.Expansion, categoryFields, categoryRelations)
+	common.ValidateCursor(v, filters.Filters, sortKeys[strings.TrimPrefix(filters.Sort, "-")][1])
 
 	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
 		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
 	}
 
-	categories, totalRecords, err := s.Repository.GetPaginated(ctx, filters.CategoryName, filters.Language, filters.Filters)
+	categoryNames := []string{}
+	if name := strings.TrimSpace(filters.CategoryName); name != "" {
+		categoryNames = translit.Variants(name, filters.Language)
+	}
+
+	categories, metadata, err := s.Repository.GetPaginated(ctx, categoryNames, filters.Language, filters.CreatedAfter, filters.CreatedBefore, common.NewVisibility(filters.State, 0), filters.Filters)
 	if err != nil {
 		return nil, common.Metadata{}, err
 	}
 
-	metadata := common.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	if err = s.expand(ctx, categories, filters.Expansion); err != nil {
+		return nil, common.Metadata{}, err
+	}
 
 	return categories, metadata, nil
 }
@@ -26,12 +26,12 @@ func Generate(path string, packageName string, information *parser.SchemaInforma
 			if err != nil {
 				return fmt.Errorf("failed to generate fields for oneOf[%d]: %w", i, err)
 			}
-			oneOfStructName := fmt.Sprintf("%sVariant%d", translit.TranslitFieldName(information.Title), i+1)
+			oneOfStructName := fmt.Sprintf("%sVariant%d", translit.Identifier(information.Title), i+1)
 			oneOfStructs = append(oneOfStructs, fmt.Sprintf(baseType, oneOfStructName, oneOfFields))
 		}
 	}
 
-	finalType := fmt.Sprintf(baseType, translit.TranslitFieldName(information.Title), fields)
+	finalType := fmt.Sprintf(baseType, translit.Identifier(information.Title), fields)
 
 	if len(oneOfStructs) > 0 {
 		finalType += "\n" + strings.Join(oneOfStructs, "\n")
@@ -55,8 +55,10 @@ func generateFields(fields parser.Fields) (string, error) {
 	var result strings.Builder
 
 	for _, val := range fields.Properties {
-		goFieldName := translit.TranslitFieldName(val.FieldName)
-		goFieldName = strings.ToUpper(string(goFieldName[0])) + goFieldName[1:]
+		goFieldName := translit.Identifier(val.FieldName)
+		if goFieldName == "" {
+			return "", fmt.Errorf("field name %q cannot be converted to go identifier", val.FieldName)
+		}
 
 		var goType string
 		switch val.FieldType {
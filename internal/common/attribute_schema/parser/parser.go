@@ -28,6 +28,7 @@ type FieldInfo struct {
 	Description string
 	Minimum     *float64
 	Maximum     float64
+	Filterable  bool
 }
 
 func ExtractInformation(data []byte) (*SchemaInformation, error) {
@@ -242,6 +243,14 @@ func extractProperties(schema map[string]interface{}) (*Fields, error) {
 				fieldInfo.Description = descriptionValStr
 			}
 
+			if filterableVal, ok := propMap["x-filterable"]; ok {
+				filterable, ok := filterableVal.(bool)
+				if !ok {
+					return nil, errors.New("property x-filterable must be a boolean")
+				}
+				fieldInfo.Filterable = filterable
+			}
+
 			if enumVal, ok := propMap["enum"]; ok {
 				enum, err := extractEnum(enumVal)
 				if err != nil {
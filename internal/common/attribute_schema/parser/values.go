@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Property looks the field up by name in the schema properties and in every oneOf branch
+func (s *SchemaInformation) Property(name string) (FieldInfo, bool) {
+	for _, prop := range s.Properties {
+		if prop.FieldName == name {
+			return prop, true
+		}
+	}
+
+	for _, oneOf := range s.OneOf {
+		for _, prop := range oneOf.Properties {
+			if prop.FieldName == name {
+				return prop, true
+			}
+		}
+	}
+
+	return FieldInfo{}, false
+}
+
+// FilterableProperties returns fields marked with x-filterable ordered by name, a field declared
+// in several oneOf branches is returned once
+func (s *SchemaInformation) FilterableProperties() []FieldInfo {
+	var filterable []FieldInfo
+	seen := make(map[string]bool)
+
+	for _, fields := range append([]Fields{s.Fields}, s.OneOf...) {
+		for _, prop := range fields.Properties {
+			if prop.Filterable && !seen[prop.FieldName] {
+				seen[prop.FieldName] = true
+				filterable = append(filterable, prop)
+			}
+		}
+	}
+
+	slices.SortFunc(filterable, func(a, b FieldInfo) int { return strings.Compare(a.FieldName, b.FieldName) })
+
+	return filterable
+}
+
+// CheckAttributes checks attributes decoded from a JSON object against the schema, required fields must be present,
+// fields must hold valid values and must be declared in the schema. Errors are keyed by field name and nil means
+// the attributes are valid. With oneOf the attributes must satisfy one of the branches, errors of the closest one are returned
+func (s *SchemaInformation) CheckAttributes(attributes map[string]interface{}) map[string]string {
+	branches := s.OneOf
+	if len(branches) == 0 {
+		branches = []Fields{s.Fields}
+	}
+
+	var closest map[string]string
+	for _, fields := range branches {
+		errs := fields.check(attributes)
+		if len(errs) == 0 {
+			return nil
+		}
+		if closest == nil || len(errs) < len(closest) {
+			closest = errs
+		}
+	}
+
+	return closest
+}
+
+func (f Fields) check(attributes map[string]interface{}) map[string]string {
+	errs := make(map[string]string)
+
+	for _, name := range f.RequiredFields {
+		if _, ok := attributes[name]; !ok {
+			errs[name] = "must be provided"
+		}
+	}
+
+	for name, value := range attributes {
+		i := slices.IndexFunc(f.Properties, func(prop FieldInfo) bool { return prop.FieldName == name })
+		if i < 0 {
+			errs[name] = "is not declared in the schema of the category"
+			continue
+		}
+
+		if err := f.Properties[i].CheckValue(value); err != nil {
+			errs[name] = err.Error()
+		}
+	}
+
+	return errs
+}
+
+// IsInteger reports whether the field holds whole numbers
+func (f FieldInfo) IsInteger() bool {
+	return f.FieldType == "int" || f.FieldType == "integer"
+}
+
+// IsNumeric reports whether the field holds any numbers
+func (f FieldInfo) IsNumeric() bool {
+	return f.IsInteger() || f.FieldType == "number" || f.FieldType == "double" || f.FieldType == "float"
+}
+
+// CheckValue checks a value decoded from JSON against the field type, enum and limits
+func (f FieldInfo) CheckValue(value interface{}) error {
+	switch {
+	case f.FieldType == "string":
+		str, ok := value.(string)
+		if !ok {
+			return errors.New("must be a string")
+		}
+		if len(f.Enum) > 0 && !inEnum(f.Enum, str) {
+			return fmt.Errorf("must be one of %v", f.Enum)
+		}
+		if f.MinLength != nil && utf8.RuneCountInString(str) < *f.MinLength {
+			return fmt.Errorf("must be at least %d characters long", *f.MinLength)
+		}
+		if f.MaxLength != 0 && utf8.RuneCountInString(str) > f.MaxLength {
+			return fmt.Errorf("must not be more than %d characters long", f.MaxLength)
+		}
+	case f.IsNumeric():
+		number, ok := value.(float64)
+		if !ok {
+			return errors.New("must be a number")
+		}
+		if f.IsInteger() && number != math.Trunc(number) {
+			return errors.New("must be an integer")
+		}
+		if f.Minimum != nil && number < *f.Minimum {
+			return fmt.Errorf("must be greater than or equal to %v", *f.Minimum)
+		}
+		if f.Maximum != 0 && number > f.Maximum {
+			return fmt.Errorf("must be less than or equal to %v", f.Maximum)
+		}
+	default:
+		return fmt.Errorf("unsupported field type: %s", f.FieldType)
+	}
+
+	return nil
+}
+
+func inEnum(enum []string, value string) bool {
+	for _, el := range enum {
+		if el == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// latinPair maps a Latin letter combination to its Cyrillic counterpart
+type latinPair struct {
+	latin    string
+	cyrillic string
+}
+
+// final reports whether the pair is a vowel with "й", such pairs match only at the end of a word
+// or before a consonant, before a vowel "y" starts a new syllable
+func (p latinPair) final() bool {
+	return len(p.latin) > 1 && strings.HasSuffix(p.cyrillic, "й")
+}
+
+// latinVowels are letters a final pair must not be followed by
+const latinVowels = "aeiouy"
+
+// ruReverse - обратная таблица для русского языка. Сочетания идут раньше одиночных букв,
+// чтобы "shch" не разбиралось как "s" + "h" + ... Сочетания с "й" разбираются только в конце слова
+// или перед согласной, перед гласной "y" читается с ней: "mayak" -> "маяк", а не "майак"
+var ruReverse = []latinPair{
+	{"shch", "щ"}, {"sch", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yo", "ё"}, {"jo", "ё"}, {"yu", "ю"}, {"ju", "ю"}, {"iu", "ю"},
+	{"ya", "я"}, {"ja", "я"}, {"ia", "я"}, {"ye", "е"},
+	{"ay", "ай"}, {"ey", "ей"}, {"iy", "ий"}, {"oy", "ой"}, {"uy", "уй"}, {"yy", "ый"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"}, {"g", "г"},
+	{"h", "х"}, {"i", "и"}, {"j", "й"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"},
+	{"o", "о"}, {"p", "п"}, {"q", "к"}, {"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"},
+	{"v", "в"}, {"w", "в"}, {"x", "кс"}, {"y", "ы"}, {"z", "з"},
+}
+
+// tjReverse - обратная таблица для таджикского языка
+var tjReverse = []latinPair{
+	{"shch", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"gh", "ғ"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yo", "ё"}, {"yu", "ю"}, {"ya", "я"}, {"ye", "е"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"}, {"g", "г"},
+	{"h", "ҳ"}, {"i", "и"}, {"ī", "ӣ"}, {"j", "ҷ"}, {"k", "к"}, {"l", "л"}, {"m", "м"},
+	{"n", "н"}, {"o", "о"}, {"p", "п"}, {"q", "қ"}, {"r", "р"}, {"s", "с"}, {"t", "т"},
+	{"u", "у"}, {"ū", "ӯ"}, {"v", "в"}, {"w", "в"}, {"x", "х"}, {"y", "й"}, {"z", "з"},
+}
+
+// ToCyrillic converts Latin text typed on a Latin keyboard back into Cyrillic, e.g.
+// "telefon samsung" -> "телефон самсунг". language is "ru" or "tj", any other value falls back to "ru".
+// The result is a best guess intended for search query expansion, it is not an inverse of Translit
+func ToCyrillic(s string, language string) string {
+	pairs := ruReverse
+	if language == "tj" {
+		pairs = tjReverse
+	}
+
+	var result strings.Builder
+	result.Grow(len(s) * 2)
+
+	for rest := s; rest != ""; {
+		pair, ok := matchLatin(rest, pairs)
+		if !ok {
+			r, size := utf8.DecodeRuneInString(rest)
+			result.WriteRune(r)
+			rest = rest[size:]
+			continue
+		}
+
+		first, size := utf8.DecodeRuneInString(rest)
+		if unicode.IsUpper(first) {
+			next, _ := utf8.DecodeRuneInString(rest[size:])
+			result.WriteString(upperCase(pair.cyrillic, unicode.IsUpper(next)))
+		} else {
+			result.WriteString(pair.cyrillic)
+		}
+
+		rest = rest[len(pair.latin):]
+	}
+
+	return result.String()
+}
+
+// HasCyrillic reports whether s contains at least one Cyrillic letter
+func HasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasLatin reports whether s contains at least one Latin letter
+func HasLatin(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchLatin(s string, pairs []latinPair) (latinPair, bool) {
+	for _, pair := range pairs {
+		if len(s) < len(pair.latin) || !strings.EqualFold(s[:len(pair.latin)], pair.latin) {
+			continue
+		}
+
+		if pair.final() {
+			next, _ := utf8.DecodeRuneInString(s[len(pair.latin):])
+			if strings.ContainsRune(latinVowels, unicode.ToLower(next)) {
+				continue
+			}
+		}
+
+		return pair, true
+	}
+
+	return latinPair{}, false
+}
+
+// Variants returns s followed by its spellings in the other script: Latin parts are converted into Cyrillic
+// and Cyrillic parts are transliterated into Latin, so "telefon samsung" and "телефон самсунг" find each other.
+// For Tajik the Russian reading of Latin text is added too, because users mix both alphabets. Duplicates are dropped
+func Variants(s string, language string) []string {
+	variants := []string{s}
+
+	add := func(variant string) {
+		for _, existing := range variants {
+			if existing == variant {
+				return
+			}
+		}
+		variants = append(variants, variant)
+	}
+
+	if HasLatin(s) {
+		add(ToCyrillic(s, language))
+		if language == "tj" {
+			add(ToCyrillic(s, "ru"))
+		}
+	}
+
+	if HasCyrillic(s) {
+		add(Translit(s, Simple))
+	}
+
+	return variants
+}
@@ -5,63 +5,137 @@ import (
 	"unicode"
 )
 
-// translitTable - таблица соответствия кириллических символов латинским.
-var translitTable = map[rune]string{
-	'А': "A", 'а': "a",
-	'Б': "B", 'б': "b",
-	'В': "V", 'в': "v",
-	'Г': "G", 'г': "g",
-	'Д': "D", 'д': "d",
-	'Е': "E", 'е': "e",
-	'Ё': "Yo", 'ё': "yo",
-	'Ж': "Zh", 'ж': "zh",
-	'З': "Z", 'з': "z",
-	'И': "I", 'и': "i",
-	'Й': "J", 'й': "j",
-	'К': "K", 'к': "k",
-	'Л': "L", 'л': "l",
-	'М': "M", 'м': "m",
-	'Н': "N", 'н': "n",
-	'О': "O", 'о': "o",
-	'П': "P", 'п': "p",
-	'Р': "R", 'р': "r",
-	'С': "S", 'с': "s",
-	'Т': "T", 'т': "t",
-	'У': "U", 'у': "u",
-	'Ф': "F", 'ф': "f",
-	'Х': "Kh", 'х': "kh",
-	'Ц': "Ts", 'ц': "ts",
-	'Ч': "Ch", 'ч': "ch",
-	'Ш': "Sh", 'ш': "sh",
-	'Щ': "Shch", 'щ': "shch",
-	'Ъ': "", 'ъ': "",
-	'Ы': "Y", 'ы': "y",
-	'Ь': "", 'ь': "",
-	'Э': "E", 'э': "e",
-	'Ю': "Yu", 'ю': "yu",
-	'Я': "Ya", 'я': "ya",
-	// Таджикские специфические символы (если используются)
-	'Ғ': "G", 'ғ': "g",
-	'Қ': "Q", 'қ': "q",
-	'Ҳ': "H", 'ҳ': "h",
-	'Ҷ': "J", 'ҷ': "j",
+// Scheme defines the romanization standard used to convert Cyrillic into Latin
+type Scheme int
+
+const (
+	// Simple is the practical scheme used for field names, type names and slugs. ASCII only
+	Simple Scheme = iota
+	// ISO9 is ISO 9:1995 (GOST 7.79-2000 system A): one Latin letter with diacritics per Cyrillic letter
+	ISO9
+	// GOST779 is GOST 7.79-2000 system B: ASCII only, with apostrophes and the "c"/"cz" rule for Ц
+	GOST779
+	// Passport is the ICAO Doc 9303 scheme used in Russian and Tajik international passports
+	Passport
+)
+
+// simpleTable - таблица соответствия кириллических символов латинским (практическая транслитерация).
+var simpleTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Таджикские буквы
+	'ғ': "g", 'ӣ': "i", 'қ': "q", 'ӯ': "u", 'ҳ': "h", 'ҷ': "j",
+}
+
+// iso9Table - ISO 9:1995, обратимая транслитерация с диакритикой.
+var iso9Table = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë",
+	'ж': "ž", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "c", 'ч': "č", 'ш': "š", 'щ': "ŝ", 'ъ': "ʺ",
+	'ы': "y", 'ь': "ʹ", 'э': "è", 'ю': "û", 'я': "â",
+	// Таджикские буквы
+	'ғ': "ġ", 'ӣ': "ī", 'қ': "ķ", 'ӯ': "ū", 'ҳ': "ḩ", 'ҷ': "ç",
+}
+
+// gost779Table - ГОСТ 7.79-2000 система Б. Буква Ц обрабатывается отдельно (см. gostTs).
+var gost779Table = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "``",
+	'ы': "y'", 'ь': "`", 'э': "e`", 'ю': "yu", 'я': "ya",
+	// Таджикские буквы
+	'ғ': "g`", 'ӣ': "i`", 'қ': "k`", 'ӯ': "u`", 'ҳ': "h`", 'ҷ': "j`",
+}
+
+// passportTable - ICAO Doc 9303, используется в загранпаспортах.
+var passportTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	// Таджикские буквы
+	'ғ': "gh", 'ӣ': "i", 'қ': "q", 'ӯ': "u", 'ҳ': "h", 'ҷ': "j",
+}
+
+var tables = map[Scheme]map[rune]string{
+	Simple:   simpleTable,
+	ISO9:     iso9Table,
+	GOST779:  gost779Table,
+	Passport: passportTable,
+}
+
+// Translit converts Cyrillic letters of s into Latin by the given scheme,
+// everything else is copied as is
+func Translit(s string, scheme Scheme) string {
+	table, ok := tables[scheme]
+	if !ok {
+		table = simpleTable
+	}
+
+	runes := []rune(s)
+
+	var result strings.Builder
+	result.Grow(len(s))
+
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+
+		latin, ok := table[lower]
+		if !ok {
+			result.WriteRune(r)
+			continue
+		}
+
+		if scheme == GOST779 && lower == 'ц' {
+			latin = gostTs(runes, i)
+		}
+
+		if unicode.IsUpper(r) {
+			latin = upperCase(latin, isAllCaps(runes, i))
+		}
+
+		result.WriteString(latin)
+	}
+
+	return result.String()
 }
 
+// fieldNameKept are Cyrillic letters field names were always generated with as is
+var fieldNameKept = map[rune]bool{'ӣ': true, 'ӯ': true}
+
+// TranslitFieldName transliterates attribute names by the Simple scheme the way names of existing schemas
+// were generated: every upper-case letter is capitalized on its own, so "ЩИ" becomes "ShchI",
+// and the letters of fieldNameKept are copied as is
 func TranslitFieldName(name string) string {
 	var result strings.Builder
 	hasCyrillic := false
 
 	for _, r := range name {
-		if unicode.Is(unicode.Cyrillic, r) {
-			hasCyrillic = true
-			if translit, ok := translitTable[r]; ok {
-				result.WriteString(translit)
-			} else {
-				result.WriteRune(r)
-			}
-		} else {
+		if !unicode.Is(unicode.Cyrillic, r) {
 			result.WriteRune(r)
+			continue
 		}
+		hasCyrillic = true
+
+		lower := unicode.ToLower(r)
+
+		latin, ok := simpleTable[lower]
+		if !ok || fieldNameKept[lower] {
+			result.WriteRune(r)
+			continue
+		}
+
+		if unicode.IsUpper(r) {
+			latin = upperCase(latin, false)
+		}
+
+		result.WriteString(latin)
 	}
 
 	if hasCyrillic {
@@ -69,3 +143,86 @@ func TranslitFieldName(name string) string {
 	}
 	return name
 }
+
+// Slugify returns a lowercase ASCII slug suitable for URLs, e.g. "Бытовая техника" -> "bytovaya-tekhnika"
+func Slugify(s string) string {
+	var result strings.Builder
+	separate := false
+
+	for _, r := range strings.ToLower(Translit(s, Simple)) {
+		if !isASCIIAlnum(r) {
+			separate = true
+			continue
+		}
+
+		if separate && result.Len() > 0 {
+			result.WriteByte('-')
+		}
+		separate = false
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
+
+// Identifier returns an exported Go identifier in CamelCase, e.g. "объём памяти" -> "ObyomPamyati".
+// Identifiers that would start with a digit are prefixed with "X"; empty string is returned when
+// s has no letters or digits at all
+func Identifier(s string) string {
+	words := strings.FieldsFunc(Translit(s, Simple), func(r rune) bool {
+		return !isASCIIAlnum(r)
+	})
+
+	var result strings.Builder
+	for _, word := range words {
+		result.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	identifier := result.String()
+	if identifier != "" && identifier[0] >= '0' && identifier[0] <= '9' {
+		identifier = "X" + identifier
+	}
+
+	return identifier
+}
+
+// gostTs applies the GOST 7.79 rule for Ц: "c" before е, и, ы, й and "cz" otherwise
+func gostTs(runes []rune, i int) string {
+	if i+1 < len(runes) {
+		switch unicode.ToLower(runes[i+1]) {
+		case 'е', 'и', 'ы', 'й':
+			return "c"
+		}
+	}
+
+	return "cz"
+}
+
+// isAllCaps reports whether the upper-case letter at i is a part of an all caps word,
+// so "ЩИ" becomes "SHCHI" while "Щи" becomes "Shchi"
+func isAllCaps(runes []rune, i int) bool {
+	if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+		return unicode.IsUpper(runes[i+1])
+	}
+
+	return i > 0 && unicode.IsLetter(runes[i-1]) && unicode.IsUpper(runes[i-1])
+}
+
+func upperCase(latin string, allCaps bool) string {
+	if latin == "" {
+		return latin
+	}
+
+	if allCaps {
+		return strings.ToUpper(latin)
+	}
+
+	runes := []rune(latin)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
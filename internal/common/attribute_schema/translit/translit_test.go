@@ -0,0 +1,147 @@
+package translit
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTranslit(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		scheme Scheme
+		want   string
+	}{
+		{"simple", "Бытовая техника", Simple, "Bytovaya tekhnika"},
+		{"simple title case", "Щи", Simple, "Shchi"},
+		{"simple all caps", "ЩИ", Simple, "SHCHI"},
+		{"simple signs", "объём", Simple, "obyom"},
+		{"simple tajik", "Ҳисор", Simple, "Hisor"},
+		{"simple keeps other runes", "iPhone 15, 128 ГБ", Simple, "iPhone 15, 128 GB"},
+		{"iso9", "Жёлтый щит", ISO9, "Žëltyj ŝit"},
+		{"gost ts before e", "Цирк", GOST779, "Cirk"},
+		{"gost ts otherwise", "Цапля", GOST779, "Czaplya"},
+		{"gost ts all caps", "ЦАПЛЯ", GOST779, "CZAPLYA"},
+		{"gost hard sign", "Съезд", GOST779, "S``ezd"},
+		{"passport", "Юрий Подъячев", Passport, "Iurii Podieiachev"},
+		{"unknown scheme falls back to simple", "Хлеб", Scheme(42), "Khleb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Translit(tt.s, tt.scheme); got != tt.want {
+				t.Errorf("Translit(%q, %d) = %q, want %q", tt.s, tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslitFieldName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"brand", "brand"},
+		{"Объём памяти", "Obyom pamyati"},
+		{"ЩИ", "ShchI"},
+		{"ЦВЕТ", "TsVET"},
+		{"Ҳаҷм", "Hajm"},
+		{"Ранги ӯ", "Rangi ӯ"},
+		{"Қимати ӣ", "Qimati ӣ"},
+	}
+
+	for _, tt := range tests {
+		if got := TranslitFieldName(tt.name); got != tt.want {
+			t.Errorf("TranslitFieldName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Бытовая техника", "bytovaya-tekhnika"},
+		{"  Объём -- памяти! ", "obyom-pamyati"},
+		{"iPhone 15 Pro", "iphone-15-pro"},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.s); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"объём памяти", "ObyomPamyati"},
+		{"4 ядра", "X4Yadra"},
+		{"screen_size", "ScreenSize"},
+		{"---", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Identifier(tt.s); got != tt.want {
+			t.Errorf("Identifier(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestToCyrillic(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		language string
+		want     string
+	}{
+		{"words", "telefon samsung", "ru", "телефон самсунг"},
+		{"y before a vowel starts a syllable", "mayak", "ru", "маяк"},
+		{"y at the end of a word", "moy", "ru", "мой"},
+		{"yy at the end of a word", "krasnyy", "ru", "красный"},
+		{"ye", "Yelena", "ru", "Елена"},
+		{"ts", "zayats", "ru", "заяц"},
+		{"y before a consonant", "Chaynik", "ru", "Чайник"},
+		{"all caps", "MAYAK", "ru", "МАЯК"},
+		{"shch before sh", "shchuka", "ru", "щука"},
+		{"unknown language falls back to ru", "kniga", "de", "книга"},
+		{"tajik letters", "Hisor qand", "tj", "Ҳисор қанд"},
+		{"tajik gh", "ghalla", "tj", "ғалла"},
+		{"keeps other runes", "iphone 15", "ru", "ипхоне 15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToCyrillic(tt.s, tt.language); got != tt.want {
+				t.Errorf("ToCyrillic(%q, %q) = %q, want %q", tt.s, tt.language, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		language string
+		want     []string
+	}{
+		{"latin", "telefon", "ru", []string{"telefon", "телефон"}},
+		{"cyrillic", "телефон", "ru", []string{"телефон", "telefon"}},
+		{"tajik adds russian reading", "hisor", "tj", []string{"hisor", "ҳисор", "хисор"}},
+		{"digits only", "15", "ru", []string{"15"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Variants(tt.s, tt.language); !slices.Equal(got, tt.want) {
+				t.Errorf("Variants(%q, %q) = %q, want %q", tt.s, tt.language, got, tt.want)
+			}
+		})
+	}
+}
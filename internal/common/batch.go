@@ -0,0 +1,52 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+	"slices"
+)
+
+var (
+	ErrBatchValidationFailed = errors.New("batch validation failed")
+)
+
+// MaxBatchSize limits the number of ids of batch get requests
+const MaxBatchSize = 100
+
+// ValidateBatch checks ids of a batch get request
+func ValidateBatch(v *validator.Validator, ids []int) {
+	v.Check(len(ids) > 0, "ids", "must be provided")
+	v.Check(len(ids) <= MaxBatchSize, "ids", fmt.Sprintf("must not be more than %d", MaxBatchSize))
+	for _, id := range ids {
+		v.Check(id > 0, "ids", "every id must be greater than zero")
+	}
+}
+
+// OrderByIDs returns records in the order their ids were requested in and the ids of records which
+// were not found, id gives the id of a record. A repeated id is answered once
+func OrderByIDs[T any](ids []int, records []T, id func(T) int) ([]T, []int) {
+	byID := make(map[int]T, len(records))
+	for _, record := range records {
+		byID[id(record)] = record
+	}
+
+	ordered := make([]T, 0, len(records))
+	notFound := []int{}
+	seen := make([]int, 0, len(ids))
+
+	for _, requested := range ids {
+		if slices.Contains(seen, requested) {
+			continue
+		}
+		seen = append(seen, requested)
+
+		if record, ok := byID[requested]; ok {
+			ordered = append(ordered, record)
+		} else {
+			notFound = append(notFound, requested)
+		}
+	}
+
+	return ordered, notFound
+}
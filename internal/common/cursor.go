@@ -0,0 +1,211 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Cursor points at a row of a listing sorted by Sort with the sort key of the row and its id, rows after it
+// (or before it when Backward is set) are found by comparing the keys instead of skipping OFFSET rows
+type Cursor struct {
+	Sort     string `json:"s"`
+	Key      string `json:"k"`
+	ID       int64  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Encode returns the opaque token of the cursor given to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses the token made by Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// ValidateCursor checks the cursor of Filters, it must be issued for the same sort and its key must be a value
+// of keyType, the SQL type the key is cast to in Keyset, so a tampered cursor is not sent to the database.
+// Listings supporting cursors call it together with ValidateFilters
+func ValidateCursor(v *validator.Validator, f Filters, keyType string) {
+	if f.Cursor == "" {
+		return
+	}
+
+	cursor, err := DecodeCursor(f.Cursor)
+	if err != nil {
+		v.AddError("cursor", "must be a cursor returned in metadata")
+		return
+	}
+
+	if cursor.Sort != f.Sort {
+		v.AddError("cursor", "was issued for another sort")
+		return
+	}
+
+	v.Check(validCursorKey(cursor.Key, keyType), "cursor", "must be a cursor returned in metadata")
+}
+
+// numericRx matches numeric values as PostgreSQL writes them as text
+var numericRx = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// timestamptzLayouts are the text forms of timestamptz values, the offset goes with minutes only when it has them
+var timestamptzLayouts = []string{"2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05.999999-07:00"}
+
+// validCursorKey reports whether the key saved in a cursor can be cast to keyType
+func validCursorKey(key string, keyType string) bool {
+	switch keyType {
+	case "integer":
+		_, err := strconv.ParseInt(key, 10, 32)
+		return err == nil
+	case "numeric":
+		return numericRx.MatchString(key)
+	case "real":
+		_, err := strconv.ParseFloat(key, 32)
+		return err == nil
+	case "timestamptz":
+		for _, layout := range timestamptzLayouts {
+			if _, err := time.Parse(layout, key); err == nil {
+				return true
+			}
+		}
+		return false
+	case "text":
+		return utf8.ValidString(key) && !strings.ContainsRune(key, 0)
+	default:
+		return false
+	}
+}
+
+// cursor returns the decoded cursor of Filters, nil in page-based mode
+func (f Filters) cursor() *Cursor {
+	if f.Cursor == "" {
+		return nil
+	}
+
+	cursor, err := DecodeCursor(f.Cursor)
+	if err != nil {
+		return nil
+	}
+
+	return cursor
+}
+
+// Keyset holds parts of a listing query for the pagination mode of Filters
+type Keyset struct {
+	// Condition selects rows after the cursor, it is "true" in page-based mode
+	Condition string
+	// Direction orders both the sort key and the id, it is reversed when the cursor goes backward
+	Direction string
+	// Count is the expression of total records, it is not calculated with a cursor
+	Count  string
+	Offset int
+	Args   []interface{}
+}
+
+// Keyset builds the keyset condition on the sort key and id expressions numbering its arguments from param,
+// keyType is the SQL type the key saved in the cursor as text is cast back to. Rows must be ordered by
+// the key and then by the id in Direction and fetched with FetchLimit
+func (f Filters) Keyset(key string, keyType string, id string, param int) Keyset {
+	cursor := f.cursor()
+	if cursor == nil {
+		return Keyset{
+			Condition: "true",
+			Direction: f.SortDirection(),
+			Count:     "count(*) OVER()",
+			Offset:    f.Offset(),
+		}
+	}
+
+	forward := f.SortDirection() == "ASC"
+	if cursor.Backward {
+		forward = !forward
+	}
+
+	direction, comparison := "ASC", ">"
+	if !forward {
+		direction, comparison = "DESC", "<"
+	}
+
+	return Keyset{
+		Condition: fmt.Sprintf("(%s, %s) %s ($%d::text::%s, $%d)", key, id, comparison, param, keyType, param+1),
+		Direction: direction,
+		Count:     "0",
+		Args:      []interface{}{cursor.Key, cursor.ID},
+	}
+}
+
+// FetchLimit returns the number of rows to fetch, one more than a page to learn whether there is the next one
+func (f Filters) FetchLimit() int {
+	return f.PageSize + 1
+}
+
+// Key is the sort key of a fetched row as text and its id
+type Key struct {
+	Value string
+	ID    int64
+}
+
+// Paginate trims the extra row fetched with FetchLimit, restores the order of rows fetched backward and
+// returns metadata with cursors to the neighbouring pages, keys are the keys of rows
+func Paginate[T any](f Filters, rows []T, keys []Key, totalRecords int) ([]T, Metadata) {
+	hasMore := len(rows) > f.Limit()
+	if hasMore {
+		rows, keys = rows[:f.Limit()], keys[:f.Limit()]
+	}
+
+	cursor := f.cursor()
+
+	var metadata Metadata
+	if cursor == nil {
+		metadata = CalculateMetadata(totalRecords, f.Page, f.PageSize)
+	} else {
+		metadata = Metadata{PageSize: f.PageSize}
+	}
+
+	if len(rows) == 0 {
+		return rows, metadata
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil || f.Page > 1
+	if cursor != nil && cursor.Backward {
+		slices.Reverse(rows)
+		slices.Reverse(keys)
+		hasNext, hasPrev = true, hasMore
+	}
+
+	if hasNext {
+		last := keys[len(keys)-1]
+		metadata.NextCursor = Cursor{Sort: f.Sort, Key: last.Value, ID: last.ID}.Encode()
+	}
+	if hasPrev {
+		first := keys[0]
+		metadata.PrevCursor = Cursor{Sort: f.Sort, Key: first.Value, ID: first.ID, Backward: true}.Encode()
+	}
+
+	return rows, metadata
+}
@@ -0,0 +1,308 @@
+package common
+
+import (
+	"cmp"
+	"ngMarketplace/pkg/validator"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	tests := []Cursor{
+		{Sort: "price", Key: "10.50", ID: 7},
+		{Sort: "-created_at", Key: "2026-01-02 03:04:05+00", ID: 1, Backward: true},
+		{Sort: "product_id", Key: "", ID: 0},
+	}
+
+	for _, cursor := range tests {
+		decoded, err := DecodeCursor(cursor.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor(%+v.Encode()) error = %v", cursor, err)
+		}
+		if *decoded != cursor {
+			t.Errorf("DecodeCursor(%+v.Encode()) = %+v", cursor, *decoded)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []string{
+		"not base64!",
+		"bm90IGpzb24",                    // "not json"
+		Cursor{Key: "1", ID: 1}.Encode(), // no sort
+	}
+
+	for _, token := range tests {
+		if _, err := DecodeCursor(token); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want %v", token, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestValidateCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filters
+		keyType string
+		valid   bool
+	}{
+		{"no cursor", Filters{Sort: "price"}, "numeric", true},
+		{"same sort", Filters{Sort: "price", Cursor: Cursor{Sort: "price", Key: "1", ID: 1}.Encode()}, "numeric", true},
+		{"another sort", Filters{Sort: "-price", Cursor: Cursor{Sort: "price", Key: "1", ID: 1}.Encode()}, "numeric", false},
+		{"garbage", Filters{Sort: "price", Cursor: "garbage"}, "numeric", false},
+		{"tampered key", Filters{Sort: "price", Cursor: Cursor{Sort: "price", Key: "1; DROP", ID: 1}.Encode()}, "numeric", false},
+		{"stale key type", Filters{Sort: "created_at", Cursor: Cursor{Sort: "created_at", Key: "12.50", ID: 1}.Encode()}, "timestamptz", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateCursor(v, tt.filter, tt.keyType)
+			if v.Valid() != tt.valid {
+				t.Errorf("ValidateCursor() valid = %v, want %v, errors: %v", v.Valid(), tt.valid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestValidCursorKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		keyType string
+		valid   bool
+	}{
+		{"42", "integer", true},
+		{"4294967296", "integer", false},
+		{"4.2", "integer", false},
+		{"12.3400000000", "numeric", true},
+		{"-0.5", "numeric", true},
+		{"NaN", "numeric", false},
+		{"1e5", "numeric", false},
+		{"0.0607927", "real", true},
+		{"1e-20", "real", true},
+		{"high", "real", false},
+		{"2026-01-02 03:04:05+00", "timestamptz", true},
+		{"2026-01-02 03:04:05.123456+05:30", "timestamptz", true},
+		{"2026-01-02", "timestamptz", false},
+		{"Phones", "text", true},
+		{"bad\x00name", "text", false},
+		{"\xff", "text", false},
+		{"1", "uuid", false},
+	}
+
+	for _, tt := range tests {
+		if got := validCursorKey(tt.key, tt.keyType); got != tt.valid {
+			t.Errorf("validCursorKey(%q, %q) = %v, want %v", tt.key, tt.keyType, got, tt.valid)
+		}
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name          string
+		filters       Filters
+		wantCondition string
+		wantDirection string
+		wantCount     string
+		wantOffset    int
+	}{
+		{
+			name:          "page ascending",
+			filters:       Filters{Page: 3, PageSize: 10, Sort: "price"},
+			wantCondition: "true",
+			wantDirection: "ASC",
+			wantCount:     "count(*) OVER()",
+			wantOffset:    20,
+		},
+		{
+			name:          "page descending",
+			filters:       Filters{Page: 1, PageSize: 10, Sort: "-price"},
+			wantCondition: "true",
+			wantDirection: "DESC",
+			wantCount:     "count(*) OVER()",
+		},
+		{
+			name:          "forward ascending",
+			filters:       Filters{PageSize: 10, Sort: "price", Cursor: Cursor{Sort: "price", Key: "5", ID: 2}.Encode()},
+			wantCondition: "(price, id) > ($4::text::numeric, $5)",
+			wantDirection: "ASC",
+			wantCount:     "0",
+		},
+		{
+			name:          "forward descending",
+			filters:       Filters{PageSize: 10, Sort: "-price", Cursor: Cursor{Sort: "-price", Key: "5", ID: 2}.Encode()},
+			wantCondition: "(price, id) < ($4::text::numeric, $5)",
+			wantDirection: "DESC",
+			wantCount:     "0",
+		},
+		{
+			name:          "backward ascending",
+			filters:       Filters{PageSize: 10, Sort: "price", Cursor: Cursor{Sort: "price", Key: "5", ID: 2, Backward: true}.Encode()},
+			wantCondition: "(price, id) < ($4::text::numeric, $5)",
+			wantDirection: "DESC",
+			wantCount:     "0",
+		},
+		{
+			name:          "backward descending",
+			filters:       Filters{PageSize: 10, Sort: "-price", Cursor: Cursor{Sort: "-price", Key: "5", ID: 2, Backward: true}.Encode()},
+			wantCondition: "(price, id) > ($4::text::numeric, $5)",
+			wantDirection: "ASC",
+			wantCount:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyset := tt.filters.Keyset("price", "numeric", "id", 4)
+			if keyset.Condition != tt.wantCondition {
+				t.Errorf("Condition = %q, want %q", keyset.Condition, tt.wantCondition)
+			}
+			if keyset.Direction != tt.wantDirection {
+				t.Errorf("Direction = %q, want %q", keyset.Direction, tt.wantDirection)
+			}
+			if keyset.Count != tt.wantCount {
+				t.Errorf("Count = %q, want %q", keyset.Count, tt.wantCount)
+			}
+			if keyset.Offset != tt.wantOffset {
+				t.Errorf("Offset = %d, want %d", keyset.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+// keyedRow is a row of a listing sorted by key and then by id
+type keyedRow struct {
+	key int
+	id  int64
+}
+
+// fetch runs the keyset of the filters over rows the way the database would: rows are ordered by the key
+// and the id in the direction of the keyset, the ones past the cursor are kept and FetchLimit of them returned
+func fetch(t *testing.T, f Filters, rows []keyedRow) ([]keyedRow, []Key, int) {
+	t.Helper()
+
+	keyset := f.Keyset("key", "integer", "id", 1)
+
+	sorted := slices.Clone(rows)
+	slices.SortFunc(sorted, func(a, b keyedRow) int {
+		return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.id, b.id))
+	})
+	if keyset.Direction == "DESC" {
+		slices.Reverse(sorted)
+	}
+
+	if len(keyset.Args) > 0 {
+		key, err := strconv.Atoi(keyset.Args[0].(string))
+		if err != nil {
+			t.Fatalf("cursor key %v is not an integer", keyset.Args[0])
+		}
+		id := keyset.Args[1].(int64)
+		greater := strings.Contains(keyset.Condition, ">")
+
+		sorted = slices.DeleteFunc(sorted, func(row keyedRow) bool {
+			c := cmp.Or(cmp.Compare(row.key, key), cmp.Compare(row.id, id))
+			return greater && c <= 0 || !greater && c >= 0
+		})
+	}
+
+	total := len(sorted)
+	sorted = sorted[min(keyset.Offset, len(sorted)):]
+	sorted = sorted[:min(f.FetchLimit(), len(sorted))]
+
+	keys := make([]Key, 0, len(sorted))
+	for _, row := range sorted {
+		keys = append(keys, Key{Value: strconv.Itoa(row.key), ID: row.id})
+	}
+
+	return sorted, keys, total
+}
+
+func ids(rows []keyedRow) []int64 {
+	result := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row.id)
+	}
+	return result
+}
+
+func TestPaginateRoundTrip(t *testing.T) {
+	// keys repeat, so rows with equal keys are told apart by their ids
+	rows := []keyedRow{
+		{key: 30, id: 1}, {key: 10, id: 2}, {key: 20, id: 3}, {key: 10, id: 4}, {key: 20, id: 5},
+		{key: 50, id: 6}, {key: 40, id: 7}, {key: 10, id: 8}, {key: 30, id: 9}, {key: 20, id: 10},
+	}
+
+	tests := []struct {
+		sort string
+		want []int64
+	}{
+		{"key", []int64{2, 4, 8, 3, 5, 10, 1, 9, 7, 6}},
+		{"-key", []int64{6, 7, 9, 1, 10, 5, 3, 8, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			f := Filters{Page: 1, PageSize: 3, Sort: tt.sort, SortSafeList: []string{"key", "-key"}}
+
+			var (
+				forward []int64
+				pages   []Metadata
+			)
+
+			for range len(rows) {
+				fetched, keys, total := fetch(t, f, rows)
+				page, metadata := Paginate(f, fetched, keys, total)
+				forward = append(forward, ids(page)...)
+				pages = append(pages, metadata)
+
+				if metadata.NextCursor == "" {
+					break
+				}
+				f.Cursor = metadata.NextCursor
+			}
+
+			if !slices.Equal(forward, tt.want) {
+				t.Fatalf("forward = %v, want %v", forward, tt.want)
+			}
+			if pages[0].TotalRecords != len(rows) || pages[0].PrevCursor != "" {
+				t.Errorf("first page metadata = %+v, want total %d and no previous cursor", pages[0], len(rows))
+			}
+
+			var backward []int64
+
+			f.Cursor = pages[len(pages)-1].PrevCursor
+			for range len(rows) {
+				fetched, keys, total := fetch(t, f, rows)
+				page, metadata := Paginate(f, fetched, keys, total)
+				backward = append(ids(page), backward...)
+
+				if metadata.NextCursor == "" {
+					t.Fatalf("page before the last one has no next cursor: %+v", metadata)
+				}
+				if metadata.PrevCursor == "" {
+					break
+				}
+				f.Cursor = metadata.PrevCursor
+			}
+
+			lastPageSize := len(rows) % f.PageSize
+			if lastPageSize == 0 {
+				lastPageSize = f.PageSize
+			}
+			if want := tt.want[:len(tt.want)-lastPageSize]; !slices.Equal(backward, want) {
+				t.Errorf("backward = %v, want %v", backward, want)
+			}
+		})
+	}
+}
+
+func TestPaginateEmpty(t *testing.T) {
+	f := Filters{Page: 1, PageSize: 3, Sort: "key", SortSafeList: []string{"key"}}
+
+	page, metadata := Paginate(f, []keyedRow{}, []Key{}, 0)
+	if len(page) != 0 || metadata != (Metadata{}) {
+		t.Errorf("Paginate() of no rows = %v, %+v", page, metadata)
+	}
+}
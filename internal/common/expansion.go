@@ -0,0 +1,122 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrExpansionValidationFailed = errors.New("fields or include validation failed")
+)
+
+// Expansion holds the fields and include query parameters shaping responses: fields is a comma separated list
+// of top-level fields to return, all of them when it is empty, and include is a list of related resources
+// to embed. Relations are embedded under their own names and are returned even when they are not in fields
+type Expansion struct {
+	Fields  string `form:"fields"`
+	Include string `form:"include"`
+}
+
+// FieldList returns the requested fields without blanks and duplicates
+func (e Expansion) FieldList() []string {
+	return splitList(e.Fields)
+}
+
+// IncludeList returns the requested relations without blanks and duplicates
+func (e Expansion) IncludeList() []string {
+	return splitList(e.Include)
+}
+
+// Includes reports whether the relation was requested
+func (e Expansion) Includes(relation string) bool {
+	return slices.Contains(e.IncludeList(), relation)
+}
+
+// ValidateExpansion checks the requested fields and relations against those the resource has
+func ValidateExpansion(v *validator.Validator, e Expansion, fields []string, relations []string) {
+	for _, field := range e.FieldList() {
+		v.Check(validator.In(field, fields...), "fields", fmt.Sprintf("unknown field %s, must be one of %s", field, strings.Join(fields, ", ")))
+	}
+
+	for _, relation := range e.IncludeList() {
+		v.Check(validator.In(relation, relations...), "include", fmt.Sprintf("unknown relation %s, must be one of %s", relation, strings.Join(relations, ", ")))
+	}
+}
+
+// Project returns the JSON object of value reduced to the requested fields and relations,
+// value is returned as is when no fields were requested
+func (e Expansion) Project(value any) (any, error) {
+	names := e.names()
+	if names == nil {
+		return value, nil
+	}
+
+	return project(value, names)
+}
+
+// ProjectAll projects every value of the list as Project does
+func ProjectAll[T any](e Expansion, values []T) (any, error) {
+	names := e.names()
+	if names == nil {
+		return values, nil
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, len(values))
+	for _, value := range values {
+		object, err := project(value, names)
+		if err != nil {
+			return nil, err
+		}
+
+		projected = append(projected, object)
+	}
+
+	return projected, nil
+}
+
+// names returns the keys kept by projection, nil when every key is kept
+func (e Expansion) names() []string {
+	fields := e.FieldList()
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return append(fields, e.IncludeList()...)
+}
+
+func project(value any, names []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value to project: %w", err)
+	}
+
+	var object map[string]json.RawMessage
+	if err = json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to project value: %w", err)
+	}
+
+	projected := make(map[string]json.RawMessage, len(names))
+	for _, name := range names {
+		if raw, ok := object[name]; ok {
+			projected[name] = raw
+		}
+	}
+
+	return projected, nil
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -5,27 +5,32 @@ import (
 	"math"
 	"ngMarketplace/pkg/validator"
 	"strings"
+	"time"
 )
 
 var (
 	ErrFilterValidationFailed = errors.New("filters validation failed")
 )
 
-// Filters holds information for pagination
+// Filters holds information for pagination, listings supporting keyset pagination go after
+// Cursor instead of Page when it is given
 type Filters struct {
 	Page         int    `form:"page"`
 	PageSize     int    `form:"page_size"`
 	Sort         string `form:"sort"`
+	Cursor       string `form:"cursor"`
 	SortSafeList []string
 }
 
 // Metadata holds information about current pagination
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 }
 
 // CalculateMetadata calculates metadata for response
@@ -52,6 +57,13 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(validator.In(f.Sort, f.SortSafeList...), "sort", "invalid sort value")
 }
 
+// ValidateCreated checks the created_after and created_before bounds of listings, both are optional
+func ValidateCreated(v *validator.Validator, createdAfter *time.Time, createdBefore *time.Time) {
+	if createdAfter != nil && createdBefore != nil {
+		v.Check(createdAfter.Before(*createdBefore), "created_after", "must be earlier than created_before")
+	}
+}
+
 // SortColumn checks that the client-provided Sort field matches one of the entries in our safeList
 // and if it does, extract the column name from the Sort field by stripping the leading
 // hyphen character (if one exists)
@@ -0,0 +1,23 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnyTSQuery builds a tsquery expression that matches any of count variants of a search query passed
+// as a single text[] parameter, e.g. AnyTSQuery("plainto_tsquery", "simple", 1, 2) returns
+// (plainto_tsquery('simple', ($1::text[])[1]) || plainto_tsquery('simple', ($1::text[])[2])).
+// function and config are inlined into SQL, so they must never come from the client
+func AnyTSQuery(function string, config string, param int, count int) string {
+	if count == 0 {
+		return "NULL::tsquery"
+	}
+
+	queries := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		queries = append(queries, fmt.Sprintf("%s('%s', ($%d::text[])[%d])", function, config, param, i))
+	}
+
+	return "(" + strings.Join(queries, " || ") + ")"
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"errors"
+	"ngMarketplace/pkg/validator"
+)
+
+var (
+	ErrAdminOnly = errors.New("state and viewer_id are available to admins only")
+)
+
+// Visibility scopes of reads: the public sees active records, owners also see their own records in any status
+// and admins see records in the requested state, including soft deleted ones
+const (
+	VisibilityPublic = "public"
+	VisibilityOwner  = "owner"
+	VisibilityAdmin  = "admin"
+)
+
+// States of records admins can read, StateActive is the default one
+const (
+	StateActive  = "active"
+	StateDeleted = "deleted"
+	StateAll     = "all"
+)
+
+// Visibility describes which records a reader may see, OwnerID is set for the owner scope
+type Visibility struct {
+	Scope   string
+	OwnerID int
+	State   string
+}
+
+// NewVisibility returns the scope of a reader, state asks for the admin view and viewerID
+// for the view of the owner, the public scope is returned otherwise
+func NewVisibility(state string, viewerID int) Visibility {
+	switch {
+	case state != "":
+		return Visibility{Scope: VisibilityAdmin, State: state}
+	case viewerID > 0:
+		return Visibility{Scope: VisibilityOwner, OwnerID: viewerID, State: StateActive}
+	default:
+		return Visibility{Scope: VisibilityPublic, State: StateActive}
+	}
+}
+
+// AdminOnly reports whether the reader asks for a scope other than the public one. Until sellers have accounts
+// the owner scope cannot be checked either, so both scopes are available to admins only
+func AdminOnly(state string, viewerID int) bool {
+	return state != "" || viewerID > 0
+}
+
+// ValidateState checks the state requested for the admin view
+func ValidateState(v *validator.Validator, state string) {
+	v.Check(state == "" || validator.In(state, StateActive, StateDeleted, StateAll), "state", "state must be one of active, deleted, all")
+}
+
+// StateCondition returns the SQL condition on the state of records aliased as alias, records are soft deleted
+// by setting deleted_at and clearing active
+func (v Visibility) StateCondition(alias string) string {
+	switch v.State {
+	case StateDeleted:
+		return alias + ".deleted_at IS NOT NULL"
+	case StateAll:
+		return "true"
+	default:
+		return alias + ".active = true"
+	}
+}
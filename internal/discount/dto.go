@@ -1 +1,57 @@
 package discount
+
+import (
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/money"
+	"time"
+)
+
+// getDiscountRequest represents the param request for getting a discount
+type getDiscountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// createDiscountRequest represents a request body for creating a discount
+type createDiscountRequest struct {
+	Name      string      `json:"name" binding:"required"`
+	Kind      string      `json:"kind" binding:"required,oneof=percent fixed"`
+	Value     money.Money `json:"value"`
+	Currency  *string     `json:"currency"`
+	Scope     string      `json:"scope" binding:"required,oneof=product variant category seller"`
+	TargetID  int         `json:"target_id" binding:"required,min=1"`
+	Priority  int         `json:"priority"`
+	Stackable bool        `json:"stackable"`
+	StartsAt  *time.Time  `json:"starts_at"`
+	EndsAt    *time.Time  `json:"ends_at"`
+	UserID    int         `json:"user_id" binding:"required,min=1"` // todo user_id should be got from token
+}
+
+// updateDiscountRequest represents a request body for updating a discount, scope and target cannot be changed.
+// user_id is the seller changing the discount, admins may omit it
+type updateDiscountRequest struct {
+	UserID    int          `json:"user_id" binding:"omitempty,min=1"`
+	Name      *string      `json:"name"`
+	Kind      *string      `json:"kind"`
+	Value     *money.Money `json:"value"`
+	Currency  *string      `json:"currency"`
+	Priority  *int         `json:"priority"`
+	Stackable *bool        `json:"stackable"`
+	StartsAt  *time.Time   `json:"starts_at"`
+	EndsAt    *time.Time   `json:"ends_at"`
+}
+
+// deleteDiscountRequest represents a query for deleting a discount, user_id is the seller deleting it
+// and admins may omit it
+type deleteDiscountRequest struct {
+	UserID int `form:"user_id" binding:"omitempty,min=1"`
+}
+
+// getDiscountsRequest represents a query for getting discounts by filters,
+// with current=true only discounts running right now are returned
+type getDiscountsRequest struct {
+	Scope    string `form:"scope"`
+	TargetID int    `form:"target_id"`
+	UserID   int    `form:"user_id"`
+	Current  bool   `form:"current"`
+	common.Filters
+}
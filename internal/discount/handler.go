@@ -1 +1,249 @@
 package discount
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	discountsURL = "/discounts"
+	discountURL  = "/discounts/:id"
+)
+
+type UseCase interface {
+	CreateDiscount(ctx context.Context, discount *Discount, editor Editor) error
+	GetDiscount(ctx context.Context, id int64) (*Discount, error)
+	UpdateDiscount(ctx context.Context, id int64, request *updateDiscountRequest, editor Editor) (*Discount, error)
+	DeleteDiscount(ctx context.Context, id int64, editor Editor) error
+	GetDiscounts(ctx context.Context, filters getDiscountsRequest) ([]*Discount, common.Metadata, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(discountsURL, h.createDiscountHandler)
+	router.GET(discountURL, h.showDiscountHandler)
+	router.PATCH(discountURL, h.updateDiscountHandler)
+	router.DELETE(discountURL, h.deleteDiscountHandler)
+	router.GET(discountsURL, h.listDiscountsHandler)
+}
+
+// createDiscountHandler creates a new discount for a product, variant, category subtree or seller
+func (h *Handler) createDiscountHandler(ctx *gin.Context) {
+	const op = "createDiscountHandler"
+
+	var req createDiscountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	discount := &Discount{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Value:     req.Value,
+		Currency:  req.Currency,
+		Scope:     req.Scope,
+		TargetID:  req.TargetID,
+		Priority:  req.Priority,
+		Stackable: req.Stackable,
+		EndsAt:    req.EndsAt,
+		UserID:    req.UserID,
+	}
+
+	if req.StartsAt != nil {
+		discount.StartsAt = *req.StartsAt
+	}
+
+	editor := Editor{UserID: req.UserID, Admin: router.IsAdmin(ctx)}
+
+	if err := h.useCase.CreateDiscount(ctx, discount, editor); err != nil {
+		h.logger.Error("%s: h.useCase.CreateDiscount: %v", op, err)
+		switch {
+		case errors.Is(err, ErrDiscountValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrTargetNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product, variant or category of the discount does not exist")
+		case errors.Is(err, ErrAdminOnly):
+			apperror.WriteForbiddenResponse(ctx, err, "Send the admin token to manage category discounts")
+		case errors.Is(err, ErrNotTargetOwner):
+			apperror.WriteForbiddenResponse(ctx, err, "Only the seller of the discount target can do this")
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"discount": discount}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"discount": discount})
+		return
+	}
+}
+
+// showDiscountHandler gets a discount by discount_id
+func (h *Handler) showDiscountHandler(ctx *gin.Context) {
+	const op = "showDiscountHandler"
+
+	var req getDiscountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct discount id")
+		return
+	}
+
+	discount, err := h.useCase.GetDiscount(ctx, req.ID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetDiscount: %v", op, err)
+		switch {
+		case errors.Is(err, ErrDiscountNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Discount you are seeking does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"discount": discount}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"discount": discount})
+		return
+	}
+}
+
+// updateDiscountHandler updates value, time window, priority or stacking of the discount
+func (h *Handler) updateDiscountHandler(ctx *gin.Context) {
+	const op = "updateDiscountHandler"
+
+	var req getDiscountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct discount id")
+		return
+	}
+
+	var input updateDiscountRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	editor := Editor{UserID: input.UserID, Admin: router.IsAdmin(ctx)}
+
+	discount, err := h.useCase.UpdateDiscount(ctx, req.ID, &input, editor)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.UpdateDiscount: %v", op, err)
+		switch {
+		case errors.Is(err, ErrDiscountNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Discount you are seeking to update does not exist")
+		case errors.Is(err, ErrAdminOnly):
+			apperror.WriteForbiddenResponse(ctx, err, "Send the admin token to manage category discounts")
+		case errors.Is(err, ErrNotTargetOwner):
+			apperror.WriteForbiddenResponse(ctx, err, "Only the seller of the discount target can do this")
+		case errors.Is(err, ErrDiscountValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"discount": discount}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"discount": discount})
+		return
+	}
+}
+
+// deleteDiscountHandler deletes the discount softly
+func (h *Handler) deleteDiscountHandler(ctx *gin.Context) {
+	const op = "deleteDiscountHandler"
+
+	var req getDiscountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct discount id")
+		return
+	}
+
+	var input deleteDiscountRequest
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "user_id must be a positive integer")
+		return
+	}
+
+	editor := Editor{UserID: input.UserID, Admin: router.IsAdmin(ctx)}
+
+	if err := h.useCase.DeleteDiscount(ctx, req.ID, editor); err != nil {
+		h.logger.Error("%s: h.useCase.DeleteDiscount: %v", op, err)
+		switch {
+		case errors.Is(err, ErrDiscountNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Discount you are seeking to delete does not exist")
+		case errors.Is(err, ErrAdminOnly):
+			apperror.WriteForbiddenResponse(ctx, err, "Send the admin token to manage category discounts")
+		case errors.Is(err, ErrNotTargetOwner):
+			apperror.WriteForbiddenResponse(ctx, err, "Only the seller of the discount target can do this")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"message": "discount was successfully deleted"}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"message": "discount was successfully deleted"})
+		return
+	}
+}
+
+// listDiscountsHandler returns a list of discounts by filters
+func (h *Handler) listDiscountsHandler(ctx *gin.Context) {
+	const op = "listDiscountsHandler"
+
+	var req getDiscountsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some filter was sent with incorrect type")
+		return
+	}
+
+	discounts, metadata, err := h.useCase.GetDiscounts(ctx, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetDiscounts: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrFilterValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check filter parameters")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"discounts": discounts, "metadata": metadata}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"discounts": discounts, "metadata": metadata})
+		return
+	}
+}
@@ -1 +1,110 @@
 package discount
+
+import (
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+// Kinds of discount
+const (
+	KindPercent = "percent"
+	KindFixed   = "fixed"
+)
+
+// Scopes of discount, TargetID refers to the product, the variant, the category (with its subtree) or the seller
+const (
+	ScopeProduct  = "product"
+	ScopeVariant  = "variant"
+	ScopeCategory = "category"
+	ScopeSeller   = "seller"
+)
+
+// Discount represents a percentage or fixed amount taken off prices within the scope during [StartsAt, EndsAt).
+// Discounts are applied in order of Priority: a non-stackable discount with the highest priority is applied alone,
+// otherwise all stackable discounts are applied together. Value of a fixed discount is an amount in Currency,
+// value of a percent discount is a percentage
+type Discount struct {
+	DiscountID int         `json:"discount_id"`
+	Name       string      `json:"name"`
+	Kind       string      `json:"kind"`
+	Value      money.Money `json:"value"`
+	Currency   *string     `json:"currency,omitempty"`
+	Scope      string      `json:"scope"`
+	TargetID   int         `json:"target_id"`
+	Priority   int         `json:"priority"`
+	Stackable  bool        `json:"stackable"`
+	StartsAt   time.Time   `json:"starts_at"`
+	EndsAt     *time.Time  `json:"ends_at"`
+	UserID     int         `json:"user_id"`
+	Active     bool        `json:"-"`
+	CreatedAt  time.Time   `json:"-"`
+	UpdatedAt  time.Time   `json:"-"`
+	DeletedAt  *time.Time  `json:"-"`
+}
+
+// Editor represents who creates or changes a discount: admins manage any discount, sellers manage discounts
+// of their own products, variants and of themselves, category discounts are managed by admins only
+type Editor struct {
+	UserID int
+	Admin  bool
+}
+
+// percentDecimals is the number of decimal places of percentages, the same as of the value column
+const percentDecimals = 2
+
+// setCurrency brings the value of a fixed discount to its currency, percentages have none
+func (d *Discount) setCurrency() {
+	if d.Currency != nil {
+		d.Value = d.Value.RoundTo(*d.Currency)
+		return
+	}
+	d.Value = d.Value.RoundTo("")
+}
+
+func validateDiscount(v *validator.Validator, discount *Discount) {
+	v.Check(discount.Name != "", "name", "must be provided")
+	v.Check(len(discount.Name) <= 255, "name", "must not be more than 255 bytes long")
+	v.Check(validator.In(discount.Kind, KindPercent, KindFixed), "kind", "must be percent or fixed")
+	v.Check(validator.In(discount.Scope, ScopeProduct, ScopeVariant, ScopeCategory, ScopeSeller), "scope", "must be one of product, variant, category, seller")
+	v.Check(discount.TargetID > 0, "target_id", "must be greater than zero")
+	v.Check(!discount.Value.IsNegative() && !discount.Value.IsZero(), "value", "must be greater than zero")
+
+	switch discount.Kind {
+	case KindPercent:
+		v.Check(discount.Value.Cmp(money.FromMajor(100, "")) < 0, "value", "percentage must be less than 100")
+		v.Check(discount.Value.Decimals() <= percentDecimals, "value", fmt.Sprintf("must not have more than %d decimal places", percentDecimals))
+		v.Check(discount.Currency == nil, "currency", "must not be provided for percent discounts")
+	case KindFixed:
+		v.Check(discount.Currency != nil && validator.In(*discount.Currency, "TJS", "RUB", "USD"), "currency", "must be TJS, RUB, or USD")
+		if discount.Currency != nil {
+			v.Check(discount.Value.FitsIn(*discount.Currency), "value", fmt.Sprintf("must not have more than %d decimal places", money.Exponent(*discount.Currency)))
+		}
+	}
+
+	if discount.EndsAt != nil {
+		v.Check(discount.EndsAt.After(discount.StartsAt), "ends_at", "must be after starts_at")
+	}
+}
+
+// Repository Errors
+var (
+	ErrTargetNotFound   = errors.New("target of the discount does not exist")
+	ErrDiscountNotFound = errors.New("discount not found")
+	ErrConnectionFailed = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrDiscountValidationFailed = errors.New("discount validation failed")
+	ErrAdminOnly                = errors.New("category discounts are available to admins only")
+	ErrNotTargetOwner           = errors.New("target of the discount belongs to another seller")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON  = errors.New("failed binding json")
+	ErrInvalidID = errors.New("invalid discount id was sent")
+)
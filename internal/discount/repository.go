@@ -1 +1,389 @@
 package discount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// targetExists is the condition checking that the target ($scope, $target) of a discount exists,
+// sellers are not stored in this service, so they are not checked
+const targetExists = `
+	CASE %[1]s
+	    WHEN 'product' THEN EXISTS (SELECT 1 FROM products WHERE product_id = %[2]s AND active = true)
+	    WHEN 'variant' THEN EXISTS (SELECT 1 FROM product_variants WHERE variant_id = %[2]s AND deleted_at IS NULL)
+	    WHEN 'category' THEN EXISTS (SELECT 1 FROM categories WHERE category_id = %[2]s AND deleted_at IS NULL)
+	    ELSE true
+	END`
+
+// Create method creates a new discount if its target exists
+func (r *Repository) Create(ctx context.Context, discount *Discount) error {
+	const op = "Create"
+
+	query := fmt.Sprintf(`
+		INSERT INTO
+		    discounts (name, kind, value, currency, scope, target_id, priority, stackable, starts_at, ends_at, user_id)
+		SELECT
+		    $1::varchar, $2::varchar, $3::numeric, $4::varchar, $5::varchar, $6::integer, $7::integer, $8::boolean,
+		    $9::timestamptz, $10::timestamptz, $11::integer
+		WHERE
+		    %s
+		RETURNING discount_id, created_at, active, updated_at`, fmt.Sprintf(targetExists, "$5::varchar", "$6::integer"))
+
+	args := []interface{}{
+		discount.Name,
+		discount.Kind,
+		discount.Value,
+		discount.Currency,
+		discount.Scope,
+		discount.TargetID,
+		discount.Priority,
+		discount.Stackable,
+		discount.StartsAt,
+		discount.EndsAt,
+		discount.UserID,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&discount.DiscountID,
+		&discount.CreatedAt,
+		&discount.Active,
+		&discount.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return ErrTargetNotFound
+		}
+
+		if postgres.IsPgErr(err) {
+			err = postgres.Conv2CustomErr(err)
+		}
+
+		var pgErr *postgres.PostgresErr
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "08000", "08001", "08003", "08006":
+				return postgres.ErrDoQuery(op, ErrConnectionFailed)
+			default:
+				return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+			}
+		}
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	return nil
+}
+
+// TargetOwner method returns the seller of the product or the variant the discount targets,
+// ErrTargetNotFound is returned when the target does not exist
+func (r *Repository) TargetOwner(ctx context.Context, scope string, targetID int) (int, error) {
+	const op = "TargetOwner"
+
+	query := `
+		SELECT
+		    CASE $1::varchar
+		        WHEN 'product' THEN (SELECT user_id FROM products WHERE product_id = $2 AND active = true)
+		        WHEN 'variant' THEN (
+		            SELECT
+		                p.user_id
+		            FROM
+		                product_variants v
+		            JOIN
+		                products p ON p.product_id = v.product_id AND p.active = true
+		            WHERE
+		                v.variant_id = $2
+		            AND
+		                v.deleted_at IS NULL)
+		    END`
+
+	var owner *int
+	if err := r.client.Pool.QueryRow(ctx, query, scope, targetID).Scan(&owner); err != nil {
+		return 0, postgres.ErrDoQuery(op, err)
+	}
+
+	if owner == nil {
+		return 0, ErrTargetNotFound
+	}
+
+	return *owner, nil
+}
+
+// GetByID method gets an active discount by ID
+func (r *Repository) GetByID(ctx context.Context, id int64) (*Discount, error) {
+	const op = "GetByID"
+
+	query := `
+		SELECT
+		    discount_id, name, kind, value, currency, scope, target_id, priority, stackable, starts_at, ends_at,
+		    user_id, created_at, active, updated_at, deleted_at
+		FROM
+		    discounts
+		WHERE
+		    active = true
+		AND
+		    discount_id = $1
+		LIMIT 1`
+
+	discount, err := scanDiscount(r.client.Pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrDiscountNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	return discount, nil
+}
+
+// Update method updates the discount
+func (r *Repository) Update(ctx context.Context, discount *Discount) error {
+	const op = "Update"
+
+	query := `
+		UPDATE
+		    discounts
+		SET
+		    name = $1,
+		    kind = $2,
+		    value = $3,
+		    currency = $4,
+		    priority = $5,
+		    stackable = $6,
+		    starts_at = $7,
+		    ends_at = $8
+		WHERE
+		    discount_id = $9
+		AND
+		    active = true
+		RETURNING updated_at`
+
+	args := []interface{}{
+		discount.Name,
+		discount.Kind,
+		discount.Value,
+		discount.Currency,
+		discount.Priority,
+		discount.Stackable,
+		discount.StartsAt,
+		discount.EndsAt,
+		discount.DiscountID,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(&discount.UpdatedAt); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrDiscountNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	return nil
+}
+
+// SoftDelete method deletes the discount softly, so it stops being applied
+func (r *Repository) SoftDelete(ctx context.Context, id int64) error {
+	const op = "Delete"
+
+	query := `
+		UPDATE
+		    discounts
+		SET
+		    deleted_at = now(),
+		    active = false
+		WHERE
+		    discount_id = $1
+		AND
+		    active = true
+		RETURNING deleted_at`
+
+	var deletedAt *time.Time
+	if err := r.client.Pool.QueryRow(ctx, query, id).Scan(&deletedAt); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrDiscountNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	return nil
+}
+
+// GetPaginated method returns the list of active discounts and total data for metadata
+func (r *Repository) GetPaginated(
+	ctx context.Context,
+	scope string,
+	targetID int,
+	userID int,
+	current bool,
+	filters common.Filters,
+) (
+	[]*Discount,
+	int,
+	error,
+) {
+	const op = "GetPaginated"
+
+	query := fmt.Sprintf(`
+		SELECT
+		    count(*) OVER(), discount_id, name, kind, value, currency, scope, target_id, priority, stackable,
+		    starts_at, ends_at, user_id, created_at, active, updated_at, deleted_at
+		FROM
+		    discounts
+		WHERE
+		    active = true
+		AND
+		    (scope = $1 OR $1 = '')
+		AND
+		    (target_id = $2 OR $2 = 0)
+		AND
+		    (user_id = $3 OR $3 = 0)
+		AND
+		    (NOT $4 OR starts_at <= now() AND (ends_at IS NULL OR ends_at > now()))
+		ORDER BY
+		    %s %s, discount_id ASC
+		LIMIT $5
+		OFFSET $6`, filters.SortColumn(), filters.SortDirection())
+
+	args := []interface{}{
+		scope,
+		targetID,
+		userID,
+		current,
+		filters.Limit(),
+		filters.Offset(),
+	}
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	discounts := []*Discount{}
+
+	for rows.Next() {
+		var discount Discount
+		err = rows.Scan(
+			&totalRecords,
+			&discount.DiscountID,
+			&discount.Name,
+			&discount.Kind,
+			&discount.Value,
+			&discount.Currency,
+			&discount.Scope,
+			&discount.TargetID,
+			&discount.Priority,
+			&discount.Stackable,
+			&discount.StartsAt,
+			&discount.EndsAt,
+			&discount.UserID,
+			&discount.CreatedAt,
+			&discount.Active,
+			&discount.UpdatedAt,
+			&discount.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, postgres.ErrScan(op, err)
+		}
+
+		discounts = append(discounts, &discount)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, postgres.ErrReadRows(op, err)
+	}
+
+	return discounts, totalRecords, nil
+}
+
+// GetApplied method returns discounts applied to the product right now in order of application,
+// variantID is nil for the price of the product itself
+func (r *Repository) GetApplied(ctx context.Context, productID int64, variantID *int64) ([]*Discount, error) {
+	const op = "GetApplied"
+
+	query := `
+		SELECT
+		    discount_id, name, kind, value, currency, scope, target_id, priority, stackable, starts_at, ends_at,
+		    user_id, created_at, active, updated_at, deleted_at
+		FROM
+		    applied_discounts($1, $2)`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID, variantID)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	discounts := []*Discount{}
+
+	for rows.Next() {
+		discount, err := scanDiscount(rows)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		discounts = append(discounts, discount)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return discounts, nil
+}
+
+// row is satisfied by both pgx.Row and pgx.Rows
+type row interface {
+	Scan(dest ...any) error
+}
+
+func scanDiscount(row row) (*Discount, error) {
+	var discount Discount
+
+	err := row.Scan(
+		&discount.DiscountID,
+		&discount.Name,
+		&discount.Kind,
+		&discount.Value,
+		&discount.Currency,
+		&discount.Scope,
+		&discount.TargetID,
+		&discount.Priority,
+		&discount.Stackable,
+		&discount.StartsAt,
+		&discount.EndsAt,
+		&discount.UserID,
+		&discount.CreatedAt,
+		&discount.Active,
+		&discount.UpdatedAt,
+		&discount.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	discount.setCurrency()
+
+	return &discount, nil
+}
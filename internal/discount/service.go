@@ -1 +1,195 @@
 package discount
+
+import (
+	"context"
+	"fmt"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+type Storage interface {
+	Create(ctx context.Context, discount *Discount) error
+	GetByID(ctx context.Context, id int64) (*Discount, error)
+	Update(ctx context.Context, discount *Discount) error
+	SoftDelete(ctx context.Context, id int64) error
+	GetPaginated(ctx context.Context, scope string, targetID int, userID int, current bool, filters common.Filters) ([]*Discount, int, error)
+	TargetOwner(ctx context.Context, scope string, targetID int) (int, error)
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+func (s *Service) CreateDiscount(ctx context.Context, discount *Discount, editor Editor) error {
+	if discount.StartsAt.IsZero() {
+		discount.StartsAt = time.Now()
+	}
+
+	v := validator.New()
+
+	if validateDiscount(v, discount); !v.Valid() {
+		return fmt.Errorf("%w: %w", ErrDiscountValidationFailed, v.Errors)
+	}
+
+	if err := s.authorize(ctx, discount, editor); err != nil {
+		return err
+	}
+
+	discount.setCurrency()
+
+	if err := s.Repository.Create(ctx, discount); err != nil {
+		return fmt.Errorf("failed to create discount: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetDiscount(ctx context.Context, id int64) (*Discount, error) {
+	return s.Repository.GetByID(ctx, id)
+}
+
+func (s *Service) UpdateDiscount(ctx context.Context, id int64, request *updateDiscountRequest, editor Editor) (*Discount, error) {
+	discount, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.authorize(ctx, discount, editor); err != nil {
+		return nil, err
+	}
+
+	if request.Name != nil {
+		discount.Name = *request.Name
+	}
+
+	if request.Kind != nil {
+		discount.Kind = *request.Kind
+		// percent discounts have no currency, so switching to them drops it
+		if discount.Kind == KindPercent {
+			discount.Currency = nil
+		}
+	}
+
+	if request.Value != nil {
+		discount.Value = *request.Value
+	}
+
+	if request.Currency != nil {
+		discount.Currency = request.Currency
+	}
+
+	if request.Priority != nil {
+		discount.Priority = *request.Priority
+	}
+
+	if request.Stackable != nil {
+		discount.Stackable = *request.Stackable
+	}
+
+	if request.StartsAt != nil {
+		discount.StartsAt = *request.StartsAt
+	}
+
+	if request.EndsAt != nil {
+		discount.EndsAt = request.EndsAt
+	}
+
+	v := validator.New()
+
+	if validateDiscount(v, discount); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrDiscountValidationFailed, v.Errors)
+	}
+
+	discount.setCurrency()
+
+	if err = s.Repository.Update(ctx, discount); err != nil {
+		return nil, err
+	}
+
+	return discount, nil
+}
+
+func (s *Service) DeleteDiscount(ctx context.Context, id int64, editor Editor) error {
+	discount, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.authorize(ctx, discount, editor); err != nil {
+		return err
+	}
+
+	return s.Repository.SoftDelete(ctx, id)
+}
+
+// authorize checks that the editor may manage discounts of the scope and target of the discount, see Editor
+func (s *Service) authorize(ctx context.Context, discount *Discount, editor Editor) error {
+	if editor.Admin {
+		return nil
+	}
+
+	owner := discount.TargetID
+
+	switch discount.Scope {
+	case ScopeCategory:
+		return ErrAdminOnly
+	case ScopeProduct, ScopeVariant:
+		var err error
+		if owner, err = s.Repository.TargetOwner(ctx, discount.Scope, discount.TargetID); err != nil {
+			return err
+		}
+	}
+
+	if editor.UserID == 0 || owner != editor.UserID {
+		return ErrNotTargetOwner
+	}
+
+	return nil
+}
+
+func (s *Service) GetDiscounts(ctx context.Context, filters getDiscountsRequest) ([]*Discount, common.Metadata, error) {
+	if filters.Page == 0 {
+		filters.Page = 1
+	}
+
+	if filters.PageSize == 0 {
+		filters.PageSize = 20
+	}
+
+	if filters.Sort == "" {
+		filters.Sort = "-priority"
+	}
+
+	filters.SortSafeList = []string{"discount_id", "priority", "starts_at", "ends_at", "-discount_id", "-priority", "-starts_at", "-ends_at"}
+
+	v := validator.New()
+
+	v.Check(filters.Scope == "" || validator.In(filters.Scope, ScopeProduct, ScopeVariant, ScopeCategory, ScopeSeller), "scope", "scope must be one of product, variant, category, seller")
+	v.Check(filters.TargetID >= 0, "target_id", "target_id cannot be negative")
+	v.Check(filters.UserID >= 0, "user_id", "user_id cannot be negative")
+
+	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
+		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
+	}
+
+	discounts, totalRecords, err := s.Repository.GetPaginated(
+		ctx,
+		filters.Scope,
+		filters.TargetID,
+		filters.UserID,
+		filters.Current,
+		filters.Filters,
+	)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	metadata := common.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return discounts, metadata, nil
+}
@@ -0,0 +1,34 @@
+package exchange_rate
+
+import (
+	"ngMarketplace/pkg/money"
+	"time"
+)
+
+// loadRatesRequest represents a request body for loading exchange rates
+type loadRatesRequest struct {
+	Rates []rateRequest `json:"rates" binding:"required,min=1,dive"`
+}
+
+// rateRequest represents a single rate, effective_at defaults to the moment of loading
+type rateRequest struct {
+	Currency    string     `json:"currency" binding:"required"`
+	Rate        money.Rate `json:"rate"`
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+// currencyURIRequest represents the param request for the rate history of a currency
+type currencyURIRequest struct {
+	Currency string `uri:"currency" binding:"required,oneof=RUB USD"`
+}
+
+// sourceURIRequest represents the param request for importing a feed of the central bank
+type sourceURIRequest struct {
+	Source string `uri:"source" binding:"required,oneof=nbt cbr"`
+}
+
+// historyQuery represents the period of the rate history, both days are inclusive
+type historyQuery struct {
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+}
@@ -0,0 +1,143 @@
+package exchange_rate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/text/encoding/htmlindex"
+	"io"
+	"math/big"
+	"ngMarketplace/pkg/money"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of rate feeds, both central banks publish rates in the same ValCurs XML layout
+const (
+	// SourceNBT - National Bank of Tajikistan, values are prices of foreign currencies in TJS
+	SourceNBT = "nbt"
+	// SourceCBR - Central Bank of Russia, values are prices of foreign currencies (TJS included) in RUB
+	SourceCBR = "cbr"
+)
+
+// feedDateLayouts - CBR writes dates as 02.01.2006, NBT as 2006-01-02
+var feedDateLayouts = []string{"02.01.2006", "2006-01-02"}
+
+type valCurs struct {
+	XMLName xml.Name `xml:"ValCurs"`
+	Date    string   `xml:"Date,attr"`
+	Valutes []valute `xml:"Valute"`
+}
+
+type valute struct {
+	CharCode string `xml:"CharCode"`
+	Nominal  string `xml:"Nominal"`
+	Value    string `xml:"Value"`
+}
+
+// price returns the exact price of one unit of the currency, CBR uses comma as the decimal separator
+func (v valute) price() (*big.Rat, error) {
+	nominal, err := strconv.ParseInt(strings.TrimSpace(v.Nominal), 10, 64)
+	if err != nil || nominal < 1 {
+		return nil, fmt.Errorf("invalid nominal %q", v.Nominal)
+	}
+
+	value, err := money.ParseRate(strings.ReplaceAll(strings.TrimSpace(v.Value), ",", "."))
+	if err != nil || !value.IsPositive() {
+		return nil, fmt.Errorf("invalid value %q", v.Value)
+	}
+
+	return value.Rat().Quo(value.Rat(), big.NewRat(nominal, 1)), nil
+}
+
+// parseFeed reads rates of quoted currencies to the base currency from a ValCurs document. A document that cannot
+// be read as a whole is an error, while a malformed entry of a single currency is only reported in skipped,
+// so the last good rate of that currency stays in effect
+func parseFeed(source string, r io.Reader) (rates []*Rate, skipped []string, err error) {
+	if source != SourceNBT && source != SourceCBR {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownSource, source)
+	}
+
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		encoding, err := htmlindex.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		return encoding.NewDecoder().Reader(input), nil
+	}
+
+	var doc valCurs
+	if err = decoder.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrMalformedFeed, err)
+	}
+
+	effectiveAt, err := parseFeedDate(doc.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prices := make(map[string]*big.Rat, len(doc.Valutes))
+	for _, v := range doc.Valutes {
+		code := strings.ToUpper(strings.TrimSpace(v.CharCode))
+		if code != BaseCurrency && !slices.Contains(QuotedCurrencies, code) {
+			continue
+		}
+
+		price, err := v.price()
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", code, err))
+			continue
+		}
+		prices[code] = price
+	}
+
+	// converts a price from the feed currency to the base one, the division is exact until the rate is rounded
+	toBase := func(price *big.Rat) *big.Rat { return price }
+
+	if source == SourceCBR {
+		baseInRUB, ok := prices[BaseCurrency]
+		if !ok {
+			return nil, skipped, fmt.Errorf("%w: no %s rate to convert rubles", ErrMalformedFeed, BaseCurrency)
+		}
+		prices["RUB"] = big.NewRat(1, 1)
+		toBase = func(price *big.Rat) *big.Rat { return new(big.Rat).Quo(price, baseInRUB) }
+	}
+
+	for _, currency := range QuotedCurrencies {
+		price, ok := prices[currency]
+		if !ok {
+			continue
+		}
+
+		rate, err := money.RateFromRat(toBase(price))
+		if err != nil || !rate.IsPositive() {
+			skipped = append(skipped, fmt.Sprintf("%s: rate rounds to zero or is out of range", currency))
+			continue
+		}
+
+		rates = append(rates, &Rate{
+			Currency:    currency,
+			Rate:        rate,
+			EffectiveAt: effectiveAt,
+			Source:      source,
+		})
+	}
+
+	if len(rates) == 0 {
+		return nil, skipped, fmt.Errorf("%w: no rates of %v", ErrMalformedFeed, QuotedCurrencies)
+	}
+
+	return rates, skipped, nil
+}
+
+func parseFeedDate(date string) (time.Time, error) {
+	for _, layout := range feedDateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(date), time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: invalid date %q", ErrMalformedFeed, date)
+}
@@ -0,0 +1,128 @@
+package exchange_rate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const nbtFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<ValCurs Date="2026-10-19" name="Official exchange rates">
+    <Valute ID="840"><CharCode>USD</CharCode><Nominal>1</Nominal><Name>US Dollar</Name><Value>10.9253</Value></Valute>
+    <Valute ID="978"><CharCode>EUR</CharCode><Nominal>1</Nominal><Name>Euro</Name><Value>11.8871</Value></Valute>
+    <Valute ID="643"><CharCode>RUB</CharCode><Nominal>10</Nominal><Name>Russian Ruble</Name><Value>1.4330</Value></Valute>
+</ValCurs>`
+
+const cbrFeed = `<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="19.10.2026" name="Foreign Currency Market">
+    <Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal><Name>US Dollar</Name><Value>78,5000</Value></Valute>
+    <Valute ID="R01670"><NumCode>972</NumCode><CharCode>TJS</CharCode><Nominal>10</Nominal><Name>Somoni</Name><Value>69,6742</Value></Valute>
+</ValCurs>`
+
+func TestParseFeed(t *testing.T) {
+	type want struct {
+		currency string
+		units    int64
+	}
+
+	tests := []struct {
+		name        string
+		source      string
+		feed        string
+		want        []want
+		wantSkipped []string
+		wantDate    time.Time
+	}{
+		{
+			name:     "nbt prices are in the base currency",
+			source:   SourceNBT,
+			feed:     nbtFeed,
+			want:     []want{{"RUB", 14330000}, {"USD", 1092530000}},
+			wantDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "cbr prices are converted through rubles",
+			source:   SourceCBR,
+			feed:     cbrFeed,
+			want:     []want{{"RUB", 14352515}, {"USD", 1126672427}},
+			wantDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:   "malformed entry is skipped",
+			source: SourceNBT,
+			feed: `<ValCurs Date="2026-10-19">
+			    <Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>abc</Value></Valute>
+			    <Valute><CharCode>RUB</CharCode><Nominal>0</Nominal><Value>1.4330</Value></Valute>
+			    <Valute><CharCode>rub</CharCode><Nominal>1</Nominal><Value>0.1433</Value></Valute>
+			</ValCurs>`,
+			want:        []want{{"RUB", 14330000}},
+			wantSkipped: []string{`USD: invalid value "abc"`, `RUB: invalid nominal "0"`},
+			wantDate:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:   "rate rounding to zero is skipped",
+			source: SourceNBT,
+			feed: `<ValCurs Date="2026-10-19">
+			    <Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>10.9253</Value></Valute>
+			    <Valute><CharCode>RUB</CharCode><Nominal>1000</Nominal><Value>0.000001</Value></Valute>
+			</ValCurs>`,
+			want:        []want{{"USD", 1092530000}},
+			wantSkipped: []string{"RUB: rate rounds to zero or is out of range"},
+			wantDate:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, skipped, err := parseFeed(tt.source, strings.NewReader(tt.feed))
+			if err != nil {
+				t.Fatalf("parseFeed() error = %v", err)
+			}
+
+			if len(rates) != len(tt.want) {
+				t.Fatalf("parseFeed() returned %d rates, want %d", len(rates), len(tt.want))
+			}
+			for i, rate := range rates {
+				if rate.Currency != tt.want[i].currency || rate.Rate.Units != tt.want[i].units {
+					t.Errorf("rate %d = %s %s, want %s %d", i, rate.Currency, rate.Rate, tt.want[i].currency, tt.want[i].units)
+				}
+				if rate.Source != tt.source || !rate.EffectiveAt.Equal(tt.wantDate) {
+					t.Errorf("rate %d source %s effective at %v, want %s at %v", i, rate.Source, rate.EffectiveAt, tt.source, tt.wantDate)
+				}
+			}
+
+			if strings.Join(skipped, "\n") != strings.Join(tt.wantSkipped, "\n") {
+				t.Errorf("parseFeed() skipped = %q, want %q", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestParseFeedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		feed    string
+		wantErr error
+	}{
+		{"unknown source", "ecb", nbtFeed, ErrUnknownSource},
+		{"not xml", SourceNBT, "rates", ErrMalformedFeed},
+		{"another document", SourceNBT, `<Rates Date="2026-10-19"></Rates>`, ErrMalformedFeed},
+		{"invalid date", SourceNBT, `<ValCurs Date="19/10/2026"></ValCurs>`, ErrMalformedFeed},
+		{"no quoted currencies", SourceNBT, `<ValCurs Date="2026-10-19">
+		    <Valute><CharCode>EUR</CharCode><Nominal>1</Nominal><Value>11.8871</Value></Valute>
+		</ValCurs>`, ErrMalformedFeed},
+		{"cbr without the base currency", SourceCBR, `<ValCurs Date="19.10.2026">
+		    <Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>78,5000</Value></Valute>
+		</ValCurs>`, ErrMalformedFeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parseFeed(tt.source, strings.NewReader(tt.feed)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseFeed() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package exchange_rate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	ratesURL       = "/exchange-rates"
+	rateHistoryURL = "/exchange-rates/:currency"
+	importURL      = "/exchange-rates/import/:source"
+)
+
+// maxFeedSize limits the body of an uploaded feed, daily feeds are a few kilobytes
+const maxFeedSize = 1 << 20
+
+type UseCase interface {
+	LoadRates(ctx context.Context, rates []*Rate) error
+	GetRates(ctx context.Context) ([]*Rate, error)
+	GetRateHistory(ctx context.Context, currency string, query historyQuery) ([]*Rate, error)
+	ImportFeed(ctx context.Context, source string, feed io.Reader) (*ImportResult, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(ratesURL, h.loadRatesHandler)
+	router.GET(ratesURL, h.listRatesHandler)
+	router.GET(rateHistoryURL, h.showRateHistoryHandler)
+	router.POST(importURL, h.importFeedHandler)
+}
+
+// loadRatesHandler loads exchange rates to the base currency, it is available to admins only
+func (h *Handler) loadRatesHandler(ctx *gin.Context) {
+	const op = "loadRatesHandler"
+
+	if !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, ErrAdminOnly, "Send the admin token to load exchange rates")
+		return
+	}
+
+	var req loadRatesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	rates := make([]*Rate, 0, len(req.Rates))
+	for _, rate := range req.Rates {
+		loaded := &Rate{
+			Currency: rate.Currency,
+			Rate:     rate.Rate,
+		}
+
+		if rate.EffectiveAt != nil {
+			loaded.EffectiveAt = *rate.EffectiveAt
+		}
+
+		rates = append(rates, loaded)
+	}
+
+	if err := h.useCase.LoadRates(ctx, rates); err != nil {
+		h.logger.Error("%s: h.useCase.LoadRates: %v", op, err)
+		switch {
+		case errors.Is(err, ErrRateValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"rates": rates}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"rates": rates})
+		return
+	}
+}
+
+// listRatesHandler returns current exchange rates of all currencies
+func (h *Handler) listRatesHandler(ctx *gin.Context) {
+	const op = "listRatesHandler"
+
+	rates, err := h.useCase.GetRates(ctx)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetRates: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"base_currency": BaseCurrency, "rates": rates}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"base_currency": BaseCurrency, "rates": rates})
+		return
+	}
+}
+
+// showRateHistoryHandler returns rates of the currency within the period
+func (h *Handler) showRateHistoryHandler(ctx *gin.Context) {
+	const op = "showRateHistoryHandler"
+
+	var uri currencyURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct currency (RUB, USD)")
+		return
+	}
+
+	var query historyQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide from and to as dates in YYYY-MM-DD format")
+		return
+	}
+
+	rates, err := h.useCase.GetRateHistory(ctx, uri.Currency, query)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetRateHistory: %v", op, err)
+		switch {
+		case errors.Is(err, ErrRateValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"rates": rates}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"rates": rates})
+		return
+	}
+}
+
+// importFeedHandler imports rates from the XML feed of the central bank sent as the request body,
+// it is available to admins only
+func (h *Handler) importFeedHandler(ctx *gin.Context) {
+	const op = "importFeedHandler"
+
+	if !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, ErrAdminOnly, "Send the admin token to import rate feeds")
+		return
+	}
+
+	var uri sourceURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct feed source (nbt, cbr)")
+		return
+	}
+
+	feed, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFeedSize))
+	if err != nil {
+		h.logger.Error("%s: io.ReadAll: %v", op, err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apperror.WritePayloadTooLargeResponse(ctx, ErrFeedTooLarge, "Feed is larger than allowed")
+			return
+		}
+		apperror.WriteBadRequestResponse(ctx, ErrMalformedFeed, "Feed could not be read")
+		return
+	}
+
+	result, err := h.useCase.ImportFeed(ctx, uri.Source, bytes.NewReader(feed))
+	if err != nil {
+		h.logger.Error("%s: h.useCase.ImportFeed: %v", op, err)
+		switch {
+		case errors.Is(err, ErrMalformedFeed), errors.Is(err, ErrRateValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusCreated, gin.H{"import": result}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"import": result})
+		return
+	}
+}
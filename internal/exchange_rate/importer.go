@@ -0,0 +1,66 @@
+package exchange_rate
+
+import (
+	"context"
+	"ngMarketplace/pkg/logger"
+	"time"
+)
+
+// FeedFile is a rate file of the source, that is delivered to path and re-imported on schedule
+type FeedFile struct {
+	Source string
+	Path   string
+}
+
+// FileImporter imports feed files from disk
+type FileImporter interface {
+	ImportFile(ctx context.Context, source string, path string) (*ImportResult, error)
+}
+
+// Importer imports feed files right away and then every interval until ctx is done,
+// it is meant to be started with async.BackgroundRunner
+type Importer struct {
+	useCase  FileImporter
+	feeds    []FeedFile
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewImporter(useCase FileImporter, feeds []FeedFile, interval time.Duration, logger logger.Logger) *Importer {
+	return &Importer{
+		useCase:  useCase,
+		feeds:    feeds,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run imports feeds until ctx is done, a failed feed is only logged and retried on the next tick
+func (i *Importer) Run(ctx context.Context) {
+	const op = "Importer.Run"
+
+	if len(i.feeds) == 0 || i.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, feed := range i.feeds {
+			result, err := i.useCase.ImportFile(ctx, feed.Source, feed.Path)
+			if err != nil {
+				i.logger.Error("%s: import %s from %s: %v", op, feed.Source, feed.Path, err)
+				continue
+			}
+
+			i.logger.Info("%s: imported %d rates from %s, skipped: %v", op, len(result.Imported), feed.Source, result.Skipped)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
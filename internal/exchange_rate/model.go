@@ -0,0 +1,60 @@
+package exchange_rate
+
+import (
+	"errors"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+// BaseCurrency is the currency rates are quoted in, prices are compared across currencies after converting to it
+const BaseCurrency = "TJS"
+
+// QuotedCurrencies are currencies that have rates to the base currency
+var QuotedCurrencies = []string{"RUB", "USD"}
+
+// Rate represents the price of one unit of Currency in BaseCurrency, effective from EffectiveAt until the next rate
+type Rate struct {
+	RateID      int        `json:"rate_id"`
+	Currency    string     `json:"currency"`
+	Rate        money.Rate `json:"rate"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	Source      string     `json:"source"`
+	CreatedAt   time.Time  `json:"-"`
+	UpdatedAt   time.Time  `json:"-"`
+}
+
+// ImportResult represents rates imported from a feed and currencies skipped because their entries were malformed
+type ImportResult struct {
+	Source   string   `json:"source"`
+	Imported []*Rate  `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+func validateRate(v *validator.Validator, rate *Rate) {
+	v.Check(validator.In(rate.Currency, QuotedCurrencies...), "currency", "must be RUB or USD, rates are quoted in "+BaseCurrency)
+	v.Check(rate.Rate.IsPositive(), "rate", "must be greater than zero")
+	v.Check(!rate.EffectiveAt.IsZero(), "effective_at", "must be provided")
+	v.Check(rate.Source != "", "source", "must be provided")
+	v.Check(len(rate.Source) <= 20, "source", "must not be more than 20 bytes long")
+}
+
+// Repository Errors
+var (
+	ErrConnectionFailed = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrRateValidationFailed = errors.New("exchange rate validation failed")
+	ErrMalformedFeed        = errors.New("malformed rate feed")
+	ErrUnknownSource        = errors.New("unknown rate feed source")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON      = errors.New("failed binding json")
+	ErrInvalidParams = errors.New("invalid currency or period was sent")
+	ErrFeedTooLarge  = errors.New("rate feed is too large")
+	ErrAdminOnly     = errors.New("loading exchange rates is available to admins only")
+)
@@ -0,0 +1,177 @@
+package exchange_rate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// Upsert method saves rates in one transaction, a rate of the currency with the same effective_at is replaced
+func (r *Repository) Upsert(ctx context.Context, rates []*Rate) error {
+	const op = "Upsert"
+
+	query := `
+		INSERT INTO
+		    exchange_rates (currency, rate, effective_at, source)
+		VALUES
+		    ($1, $2, $3, $4)
+		ON CONFLICT (currency, effective_at) DO UPDATE SET
+		    rate = excluded.rate,
+		    source = excluded.source
+		RETURNING rate_id, created_at, updated_at`
+
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		for _, rate := range rates {
+			args := []interface{}{
+				rate.Currency,
+				rate.Rate,
+				rate.EffectiveAt,
+				rate.Source,
+			}
+
+			if err := tx.QueryRow(
+				ctx,
+				query,
+				args...,
+			).Scan(
+				&rate.RateID,
+				&rate.CreatedAt,
+				&rate.UpdatedAt,
+			); err != nil {
+				if postgres.IsPgErr(err) {
+					err = postgres.Conv2CustomErr(err)
+				}
+
+				var pgErr *postgres.PostgresErr
+				if errors.As(err, &pgErr) {
+					switch pgErr.Code {
+					case "08000", "08001", "08003", "08006":
+						return postgres.ErrDoQuery(op, ErrConnectionFailed)
+					default:
+						return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+					}
+				}
+				return postgres.ErrDoQuery(op, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetCurrent method gets the rate in effect right now for every currency
+func (r *Repository) GetCurrent(ctx context.Context) ([]*Rate, error) {
+	const op = "GetCurrent"
+
+	query := `
+		SELECT DISTINCT ON (currency)
+		    rate_id, currency, rate, effective_at, source, created_at, updated_at
+		FROM
+		    exchange_rates
+		WHERE
+		    effective_at <= now()
+		ORDER BY
+		    currency, effective_at DESC`
+
+	return r.query(ctx, op, query)
+}
+
+// GetHistory method gets rates of the currency that became effective in [from, to)
+func (r *Repository) GetHistory(ctx context.Context, currency string, from, to time.Time) ([]*Rate, error) {
+	const op = "GetHistory"
+
+	query := `
+		SELECT
+		    rate_id, currency, rate, effective_at, source, created_at, updated_at
+		FROM
+		    exchange_rates
+		WHERE
+		    currency = $1
+		AND
+		    effective_at >= $2
+		AND
+		    effective_at < $3
+		ORDER BY
+		    effective_at`
+
+	return r.query(ctx, op, query, currency, from, to)
+}
+
+// Convert method converts the amount to the currency with the current rates and rounds it to the decimal places
+// of that currency, nil is returned when there is no rate for one of the currencies
+func (r *Repository) Convert(ctx context.Context, amount money.Money, to string) (*money.Money, error) {
+	const op = "Convert"
+
+	query := `SELECT convert_price($1::numeric, $2::varchar, $3::varchar)`
+
+	var converted *money.Money
+	if err := r.client.Pool.QueryRow(ctx, query, amount, amount.Currency, to).Scan(&converted); err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	if converted != nil {
+		*converted = converted.RoundTo(to)
+	}
+
+	return converted, nil
+}
+
+// HasRate method reports whether prices can be converted to the currency, that is whether it is the base currency
+// or a rate of it is in effect
+func (r *Repository) HasRate(ctx context.Context, currency string) (bool, error) {
+	const op = "HasRate"
+
+	query := `SELECT exchange_rate($1::varchar) IS NOT NULL`
+
+	var ok bool
+	if err := r.client.Pool.QueryRow(ctx, query, currency).Scan(&ok); err != nil {
+		return false, postgres.ErrDoQuery(op, err)
+	}
+
+	return ok, nil
+}
+
+func (r *Repository) query(ctx context.Context, op string, query string, args ...interface{}) ([]*Rate, error) {
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	rates := []*Rate{}
+
+	for rows.Next() {
+		var rate Rate
+		err = rows.Scan(
+			&rate.RateID,
+			&rate.Currency,
+			&rate.Rate,
+			&rate.EffectiveAt,
+			&rate.Source,
+			&rate.CreatedAt,
+			&rate.UpdatedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return rates, nil
+}
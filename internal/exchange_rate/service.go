@@ -0,0 +1,116 @@
+package exchange_rate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"ngMarketplace/pkg/validator"
+	"os"
+	"time"
+)
+
+type Storage interface {
+	Upsert(ctx context.Context, rates []*Rate) error
+	GetCurrent(ctx context.Context) ([]*Rate, error)
+	GetHistory(ctx context.Context, currency string, from, to time.Time) ([]*Rate, error)
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+// sourceManual marks rates loaded by admins through the API
+const sourceManual = "manual"
+
+// defaultHistoryDays is the period of the rate history returned when from is not provided
+const defaultHistoryDays = 30
+
+// LoadRates validates and saves rates, nothing is saved when any of them is invalid
+func (s *Service) LoadRates(ctx context.Context, rates []*Rate) error {
+	v := validator.New()
+
+	for i, rate := range rates {
+		if rate.EffectiveAt.IsZero() {
+			rate.EffectiveAt = time.Now()
+		}
+
+		if rate.Source == "" {
+			rate.Source = sourceManual
+		}
+
+		rv := validator.New()
+		if validateRate(rv, rate); !rv.Valid() {
+			v.AddError(fmt.Sprintf("rates[%d]", i), rv.Errors.Error())
+		}
+	}
+
+	if !v.Valid() {
+		return fmt.Errorf("%w: %w", ErrRateValidationFailed, v.Errors)
+	}
+
+	if err := s.Repository.Upsert(ctx, rates); err != nil {
+		return fmt.Errorf("failed to load rates: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetRates(ctx context.Context) ([]*Rate, error) {
+	return s.Repository.GetCurrent(ctx)
+}
+
+// GetRateHistory returns rates of the currency between from and to days inclusive, the last 30 days by default
+func (s *Service) GetRateHistory(ctx context.Context, currency string, query historyQuery) ([]*Rate, error) {
+	if query.To.IsZero() {
+		now := time.Now()
+		query.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	if query.From.IsZero() {
+		query.From = query.To.AddDate(0, 0, -defaultHistoryDays+1)
+	}
+
+	v := validator.New()
+
+	v.Check(!query.From.After(query.To), "from", "must not be after to")
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrRateValidationFailed, v.Errors)
+	}
+
+	return s.Repository.GetHistory(ctx, currency, query.From, query.To.AddDate(0, 0, 1))
+}
+
+// ImportFeed parses the central bank feed and saves its rates. Nothing is saved when the feed is malformed,
+// so the last good rates stay in effect
+func (s *Service) ImportFeed(ctx context.Context, source string, feed io.Reader) (*ImportResult, error) {
+	rates, skipped, err := parseFeed(source, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.LoadRates(ctx, rates); err != nil {
+		return nil, err
+	}
+
+	if skipped == nil {
+		skipped = []string{}
+	}
+
+	return &ImportResult{Source: source, Imported: rates, Skipped: skipped}, nil
+}
+
+// ImportFile imports the feed file of the source from disk
+func (s *Service) ImportFile(ctx context.Context, source string, path string) (*ImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rate feed: %w", err)
+	}
+	defer file.Close()
+
+	return s.ImportFeed(ctx, source, file)
+}
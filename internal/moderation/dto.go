@@ -0,0 +1,33 @@
+package moderation
+
+import "ngMarketplace/internal/common"
+
+// productURIRequest represents the param request for a product in the moderation queue
+type productURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getQueueRequest represents a query for the moderation queue, with unclaimed=true
+// only products nobody reviews right now are returned
+type getQueueRequest struct {
+	Unclaimed bool `form:"unclaimed"`
+	common.Filters
+}
+
+// claimRequest represents a request body for claiming a product for review
+type claimRequest struct {
+	ModeratorID int `json:"moderator_id" binding:"required,min=1"` // todo moderator_id should be got from token
+}
+
+// decisionRequest represents a request body for the decision about a claimed product
+type decisionRequest struct {
+	ModeratorID int     `json:"moderator_id" binding:"required,min=1"` // todo moderator_id should be got from token
+	Decision    string  `json:"decision" binding:"required,oneof=publish reject"`
+	ReasonCode  *string `json:"reason_code"`
+	Comment     *string `json:"comment"`
+}
+
+// getReasonsRequest represents a query for rejection reasons in the language
+type getReasonsRequest struct {
+	Language string `form:"language" binding:"omitempty,oneof=tj ru en"`
+}
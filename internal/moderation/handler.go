@@ -0,0 +1,214 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/product"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	queueURL    = "/moderation/queue"
+	reasonsURL  = "/moderation/reasons"
+	claimURL    = "/moderation/:id/claim"
+	decisionURL = "/moderation/:id/decision"
+)
+
+type UseCase interface {
+	GetQueue(ctx context.Context, filters getQueueRequest) ([]*QueueItem, common.Metadata, error)
+	ClaimProduct(ctx context.Context, id int64, moderatorID int) (*Claim, error)
+	Decide(ctx context.Context, id int64, decision *Decision) (*product.Product, error)
+	GetReasons(ctx context.Context, language string) ([]*Reason, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// Register adds moderation routes, all of them are available to admins only
+func (h *Handler) Register(router *gin.Engine) {
+	moderation := router.Group("", h.adminOnly)
+	moderation.GET(queueURL, h.queueHandler)
+	moderation.GET(reasonsURL, h.reasonsHandler)
+	moderation.POST(claimURL, h.claimHandler)
+	moderation.POST(decisionURL, h.decisionHandler)
+}
+
+// adminOnly stops requests without the admin token before they reach moderation handlers
+func (h *Handler) adminOnly(ctx *gin.Context) {
+	if !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, ErrAdminOnly, "Send the admin token to moderate products")
+		ctx.Abort()
+		return
+	}
+
+	ctx.Next()
+}
+
+// queueHandler returns products awaiting review ordered by submission time
+func (h *Handler) queueHandler(ctx *gin.Context) {
+	const op = "queueHandler"
+
+	var req getQueueRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some filter was sent with incorrect type")
+		return
+	}
+
+	items, metadata, err := h.useCase.GetQueue(ctx, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetQueue: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrFilterValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check filter parameters")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"queue": items, "metadata": metadata}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"queue": items, "metadata": metadata})
+		return
+	}
+}
+
+// claimHandler takes a product for review by the moderator
+func (h *Handler) claimHandler(ctx *gin.Context) {
+	const op = "claimHandler"
+
+	var req productURIRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	var input claimRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	claim, err := h.useCase.ClaimProduct(ctx, req.ID, input.ModeratorID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.ClaimProduct: %v", op, err)
+		switch {
+		case errors.Is(err, product.ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking to claim does not exist")
+		case errors.Is(err, ErrNotAwaitingReview):
+			apperror.WriteConflictResponse(ctx, err, "Product is not awaiting review")
+		case errors.Is(err, ErrAlreadyClaimed):
+			apperror.WriteConflictResponse(ctx, err, "Product is already being reviewed by another moderator")
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"claim": claim}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"claim": claim})
+		return
+	}
+}
+
+// decisionHandler publishes or rejects a product claimed by the moderator
+func (h *Handler) decisionHandler(ctx *gin.Context) {
+	const op = "decisionHandler"
+
+	var req productURIRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	var input decisionRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	decision := &Decision{
+		ModeratorID: input.ModeratorID,
+		Decision:    input.Decision,
+		ReasonCode:  input.ReasonCode,
+		Comment:     input.Comment,
+	}
+
+	p, err := h.useCase.Decide(ctx, req.ID, decision)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.Decide: %v", op, err)
+		switch {
+		case errors.Is(err, ErrDecisionValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrReasonNotFound):
+			apperror.WriteBadRequestResponse(ctx, err, "Rejection reason does not exist")
+		case errors.Is(err, product.ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking to review does not exist")
+		case errors.Is(err, ErrClaimNotFound):
+			apperror.WriteConflictResponse(ctx, err, "Claim the product before deciding about it")
+		case errors.Is(err, ErrNotClaimOwner), errors.Is(err, product.ErrClaimedByOther):
+			apperror.WriteForbiddenResponse(ctx, err, "Product is being reviewed by another moderator")
+		case errors.Is(err, product.ErrInvalidTransition):
+			apperror.WriteConflictResponse(ctx, err, err.Error())
+		case errors.Is(err, product.ErrStatusChanged):
+			apperror.WriteConflictResponse(ctx, err, "Product status was changed meanwhile, try again")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": p}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"product": p})
+		return
+	}
+}
+
+// reasonsHandler returns predefined rejection reasons in the requested language
+func (h *Handler) reasonsHandler(ctx *gin.Context) {
+	const op = "reasonsHandler"
+
+	var req getReasonsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "language must be one of tj, ru, en")
+		return
+	}
+
+	reasons, err := h.useCase.GetReasons(ctx, req.Language)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetReasons: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"reasons": reasons}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"reasons": reasons})
+		return
+	}
+}
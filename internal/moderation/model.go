@@ -0,0 +1,90 @@
+package moderation
+
+import (
+	"errors"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+// Decisions of a moderator about a product awaiting review
+const (
+	DecisionPublish = "publish"
+	DecisionReject  = "reject"
+)
+
+// QueueItem represents a product awaiting review, Claim is nil while no moderator reviews it
+type QueueItem struct {
+	ProductID   int         `json:"product_id"`
+	Price       money.Money `json:"price"`
+	Currency    string      `json:"currency"`
+	CategoryID  int         `json:"category_id"`
+	UserID      int         `json:"user_id"`
+	SubmittedAt time.Time   `json:"submitted_at"`
+	Claim       *Claim      `json:"claim"`
+}
+
+// Claim represents a product taken for review by a moderator, other moderators cannot take it until ExpiresAt
+type Claim struct {
+	ProductID   int       `json:"product_id"`
+	ModeratorID int       `json:"moderator_id"`
+	ClaimedAt   time.Time `json:"claimed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Reason represents a predefined reason of rejection with its text in the requested language
+type Reason struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// Decision represents a verdict of the moderator holding the claim, ReasonCode is required to reject
+// and Comment can explain the decision further
+type Decision struct {
+	ModeratorID int
+	Decision    string
+	ReasonCode  *string
+	Comment     *string
+}
+
+// maxCommentLength limits the text moderators add to their decisions
+const maxCommentLength = 1000
+
+func validateDecision(v *validator.Validator, decision *Decision) {
+	v.Check(decision.ModeratorID > 0, "moderator_id", "must be greater than zero")
+	v.Check(validator.In(decision.Decision, DecisionPublish, DecisionReject), "decision", "must be publish or reject")
+
+	switch decision.Decision {
+	case DecisionReject:
+		v.Check(decision.ReasonCode != nil && *decision.ReasonCode != "", "reason_code", "must be provided when rejecting a product")
+	case DecisionPublish:
+		v.Check(decision.ReasonCode == nil, "reason_code", "must only be provided when rejecting a product")
+	}
+
+	if decision.Comment != nil {
+		v.Check(len(*decision.Comment) <= maxCommentLength, "comment", "must not be more than 1000 bytes long")
+	}
+}
+
+// Repository Errors
+var (
+	ErrAlreadyClaimed   = errors.New("product is claimed by another moderator")
+	ErrClaimNotFound    = errors.New("product is not claimed")
+	ErrReasonNotFound   = errors.New("rejection reason not found")
+	ErrConnectionFailed = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrDecisionValidationFailed = errors.New("decision validation failed")
+	ErrNotAwaitingReview        = errors.New("product is not awaiting review")
+	ErrNotClaimOwner            = errors.New("claim of the product belongs to another moderator")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON  = errors.New("failed binding json")
+	ErrInvalidID = errors.New("invalid product id was sent")
+	ErrAdminOnly = errors.New("moderation is available to admins only")
+)
@@ -0,0 +1,262 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// GetQueue method returns active products awaiting review with their current claims and total data for metadata,
+// a product is submitted at its latest move to pending_review
+func (r *Repository) GetQueue(ctx context.Context, unclaimed bool, filters common.Filters) ([]*QueueItem, int, error) {
+	const op = "GetQueue"
+
+	query := fmt.Sprintf(`
+		SELECT
+		    count(*) OVER(), p.product_id, p.price, p.currency, p.category_id, p.user_id, s.submitted_at,
+		    c.moderator_id, c.claimed_at, c.expires_at
+		FROM
+		    products p
+		CROSS JOIN LATERAL
+		    (SELECT
+		        coalesce(max(h.changed_at), p.updated_at) AS submitted_at
+		    FROM
+		        product_status_history h
+		    WHERE
+		        h.product_id = p.product_id
+		    AND
+		        h.to_status = 'pending_review') s
+		LEFT JOIN
+		    moderation_claims c ON c.product_id = p.product_id AND c.expires_at > now()
+		WHERE
+		    p.active = true
+		AND
+		    p.status = 'pending_review'
+		AND
+		    (NOT $1 OR c.product_id IS NULL)
+		ORDER BY
+		    %s %s, p.product_id ASC
+		LIMIT $2
+		OFFSET $3`, filters.SortColumn(), filters.SortDirection())
+
+	args := []interface{}{
+		unclaimed,
+		filters.Limit(),
+		filters.Offset(),
+	}
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	items := []*QueueItem{}
+
+	for rows.Next() {
+		var (
+			item        QueueItem
+			moderatorID *int
+			claimedAt   *time.Time
+			expiresAt   *time.Time
+		)
+		err = rows.Scan(
+			&totalRecords,
+			&item.ProductID,
+			&item.Price,
+			&item.Currency,
+			&item.CategoryID,
+			&item.UserID,
+			&item.SubmittedAt,
+			&moderatorID,
+			&claimedAt,
+			&expiresAt,
+		)
+		if err != nil {
+			return nil, 0, postgres.ErrScan(op, err)
+		}
+
+		item.Price = item.Price.RoundTo(item.Currency)
+		if moderatorID != nil {
+			item.Claim = &Claim{
+				ProductID:   item.ProductID,
+				ModeratorID: *moderatorID,
+				ClaimedAt:   *claimedAt,
+				ExpiresAt:   *expiresAt,
+			}
+		}
+
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, postgres.ErrReadRows(op, err)
+	}
+
+	return items, totalRecords, nil
+}
+
+// Claim method claims the product for the moderator until the timeout passes. A moderator can renew
+// own claim, while an unexpired claim of another moderator makes ErrAlreadyClaimed
+func (r *Repository) Claim(ctx context.Context, productID int64, moderatorID int, timeout time.Duration) (*Claim, error) {
+	const op = "Claim"
+
+	query := `
+		INSERT INTO
+		    moderation_claims (product_id, moderator_id, claimed_at, expires_at)
+		VALUES
+		    ($1, $2, now(), now() + make_interval(secs => $3))
+		ON CONFLICT (product_id) DO UPDATE
+		SET
+		    moderator_id = EXCLUDED.moderator_id,
+		    claimed_at = EXCLUDED.claimed_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE
+		    moderation_claims.expires_at <= now()
+		OR
+		    moderation_claims.moderator_id = EXCLUDED.moderator_id
+		RETURNING product_id, moderator_id, claimed_at, expires_at`
+
+	args := []interface{}{
+		productID,
+		moderatorID,
+		timeout.Seconds(),
+	}
+
+	var claim Claim
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&claim.ProductID,
+		&claim.ModeratorID,
+		&claim.ClaimedAt,
+		&claim.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrAlreadyClaimed
+		}
+		return nil, convWriteErr(op, err)
+	}
+
+	return &claim, nil
+}
+
+// GetClaim method returns the unexpired claim of the product
+func (r *Repository) GetClaim(ctx context.Context, productID int64) (*Claim, error) {
+	const op = "GetClaim"
+
+	query := `
+		SELECT
+		    product_id, moderator_id, claimed_at, expires_at
+		FROM
+		    moderation_claims
+		WHERE
+		    product_id = $1
+		AND
+		    expires_at > now()`
+
+	var claim Claim
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		productID,
+	).Scan(
+		&claim.ProductID,
+		&claim.ModeratorID,
+		&claim.ClaimedAt,
+		&claim.ExpiresAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrClaimNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	return &claim, nil
+}
+
+// ReasonExists method checks that the rejection reason with the code exists and is active
+func (r *Repository) ReasonExists(ctx context.Context, code string) (bool, error) {
+	const op = "ReasonExists"
+
+	query := `SELECT EXISTS (SELECT 1 FROM rejection_reasons WHERE code = $1 AND active = true)`
+
+	var exists bool
+	if err := r.client.Pool.QueryRow(ctx, query, code).Scan(&exists); err != nil {
+		return false, postgres.ErrDoQuery(op, err)
+	}
+
+	return exists, nil
+}
+
+// GetReasons method returns active rejection reasons with their texts in the language
+func (r *Repository) GetReasons(ctx context.Context, language string) ([]*Reason, error) {
+	const op = "GetReasons"
+
+	query := `
+		SELECT
+		    code, CASE $1 WHEN 'tj' THEN text_tj WHEN 'en' THEN text_en ELSE text_ru END
+		FROM
+		    rejection_reasons
+		WHERE
+		    active = true
+		ORDER BY
+		    code`
+
+	rows, err := r.client.Pool.Query(ctx, query, language)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	reasons := []*Reason{}
+
+	for rows.Next() {
+		reason := Reason{Language: language}
+		if err = rows.Scan(&reason.Code, &reason.Text); err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		reasons = append(reasons, &reason)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return reasons, nil
+}
+
+// convWriteErr converts errors of insert queries into repository errors
+func convWriteErr(op string, err error) error {
+	if postgres.IsPgErr(err) {
+		err = postgres.Conv2CustomErr(err)
+	}
+
+	var pgErr *postgres.PostgresErr
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "08000", "08001", "08003", "08006":
+			return postgres.ErrDoQuery(op, ErrConnectionFailed)
+		default:
+			return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+		}
+	}
+	return postgres.ErrDoQuery(op, err)
+}
@@ -0,0 +1,153 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/product"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+type Storage interface {
+	GetQueue(ctx context.Context, unclaimed bool, filters common.Filters) ([]*QueueItem, int, error)
+	Claim(ctx context.Context, productID int64, moderatorID int, timeout time.Duration) (*Claim, error)
+	GetClaim(ctx context.Context, productID int64) (*Claim, error)
+	ReasonExists(ctx context.Context, code string) (bool, error)
+	GetReasons(ctx context.Context, language string) ([]*Reason, error)
+}
+
+// ProductStorage gives access to products and moves them through their lifecycle
+type ProductStorage interface {
+	GetByID(ctx context.Context, id int64) (*product.Product, error)
+	ChangeStatus(ctx context.Context, product *product.Product, change *product.StatusChange) error
+}
+
+type Service struct {
+	Repository   Storage
+	Products     ProductStorage
+	claimTimeout time.Duration
+}
+
+func NewUseCase(repository Storage, products ProductStorage, claimTimeout time.Duration) *Service {
+	return &Service{
+		Repository:   repository,
+		Products:     products,
+		claimTimeout: claimTimeout,
+	}
+}
+
+// GetQueue returns products awaiting review, the earliest submitted go first by default
+func (s *Service) GetQueue(ctx context.Context, filters getQueueRequest) ([]*QueueItem, common.Metadata, error) {
+	if filters.Page == 0 {
+		filters.Page = 1
+	}
+
+	if filters.PageSize == 0 {
+		filters.PageSize = 20
+	}
+
+	if filters.Sort == "" {
+		filters.Sort = "submitted_at"
+	}
+
+	filters.SortSafeList = []string{"submitted_at", "-submitted_at"}
+
+	v := validator.New()
+
+	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
+		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
+	}
+
+	items, totalRecords, err := s.Repository.GetQueue(ctx, filters.Unclaimed, filters.Filters)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	metadata := common.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return items, metadata, nil
+}
+
+// ClaimProduct takes the product awaiting review for the moderator, so nobody else reviews it until the claim expires
+func (s *Service) ClaimProduct(ctx context.Context, id int64, moderatorID int) (*Claim, error) {
+	p, err := s.Products.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Status != product.StatusPendingReview {
+		return nil, ErrNotAwaitingReview
+	}
+
+	return s.Repository.Claim(ctx, id, moderatorID, s.claimTimeout)
+}
+
+// Decide publishes or rejects the product claimed by the moderator, the claim is released
+// in the transaction of the status change
+func (s *Service) Decide(ctx context.Context, id int64, decision *Decision) (*product.Product, error) {
+	v := validator.New()
+
+	if validateDecision(v, decision); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrDecisionValidationFailed, v.Errors)
+	}
+
+	claim, err := s.Repository.GetClaim(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if claim.ModeratorID != decision.ModeratorID {
+		return nil, ErrNotClaimOwner
+	}
+
+	if decision.ReasonCode != nil {
+		exists, err := s.Repository.ReasonExists(ctx, *decision.ReasonCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, ErrReasonNotFound
+		}
+	}
+
+	p, err := s.Products.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	action := product.ActionPublish
+	if decision.Decision == DecisionReject {
+		action = product.ActionReject
+	}
+
+	status, err := product.NextStatus(action, p.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	from := p.Status
+	change := &product.StatusChange{
+		ProductID:  p.ProductID,
+		FromStatus: &from,
+		ToStatus:   status,
+		ReasonCode: decision.ReasonCode,
+		Reason:     decision.Comment,
+		UserID:     decision.ModeratorID,
+	}
+
+	if err = s.Products.ChangeStatus(ctx, p, change); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *Service) GetReasons(ctx context.Context, language string) ([]*Reason, error) {
+	if language == "" {
+		language = "ru"
+	}
+
+	return s.Repository.GetReasons(ctx, language)
+}
@@ -1 +1,14 @@
 package price_history
+
+import "time"
+
+// productURIRequest represents the param request for the price history of a product
+type productURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// historyQuery represents the period of the price history, both days are inclusive
+type historyQuery struct {
+	From time.Time `form:"from" time_format:"2006-01-02"`
+	To   time.Time `form:"to" time_format:"2006-01-02"`
+}
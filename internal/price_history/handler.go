@@ -1 +1,74 @@
 package price_history
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	priceHistoryURL = "/products/:id/price-history"
+)
+
+type UseCase interface {
+	GetPriceHistory(ctx context.Context, productID int64, query historyQuery) (*History, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.GET(priceHistoryURL, h.showPriceHistoryHandler)
+}
+
+// showPriceHistoryHandler returns price changes of the product and their daily points for a chart
+func (h *Handler) showPriceHistoryHandler(ctx *gin.Context) {
+	const op = "showPriceHistoryHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id")
+		return
+	}
+
+	var query historyQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide from and to as dates in YYYY-MM-DD format")
+		return
+	}
+
+	history, err := h.useCase.GetPriceHistory(ctx, uri.ProductID, query)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetPriceHistory: %v", op, err)
+		switch {
+		case errors.Is(err, ErrHistoryValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"price_history": history}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"price_history": history})
+		return
+	}
+}
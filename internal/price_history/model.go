@@ -1 +1,71 @@
 package price_history
+
+import (
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+// Entry represents a price and currency of a product, effective from ChangedAt until the next entry
+type Entry struct {
+	HistoryID int         `json:"history_id"`
+	ProductID int         `json:"product_id"`
+	Price     money.Money `json:"price"`
+	Currency  string      `json:"currency"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// DailyPoint represents the price of a product during one day, suitable for drawing a chart.
+// Open is the price at the start of the day (nil on the day the product got its first price),
+// Close is the price at the end of the day, Min and Max only take prices in the Close currency into account
+type DailyPoint struct {
+	Date     string       `json:"date"`
+	Currency string       `json:"currency"`
+	Open     *money.Money `json:"open"`
+	Close    money.Money  `json:"close"`
+	Min      money.Money  `json:"min"`
+	Max      money.Money  `json:"max"`
+}
+
+// setCurrency brings prices scanned from the database to the currency of the point
+func (p *DailyPoint) setCurrency() {
+	if p.Open != nil {
+		open := p.Open.RoundTo(p.Currency)
+		p.Open = &open
+	}
+	p.Close = p.Close.RoundTo(p.Currency)
+	p.Min = p.Min.RoundTo(p.Currency)
+	p.Max = p.Max.RoundTo(p.Currency)
+}
+
+// History represents price changes of a product within a period together with their daily aggregation
+type History struct {
+	Entries []*Entry      `json:"entries"`
+	Daily   []*DailyPoint `json:"daily"`
+}
+
+// maxHistoryDays limits the period that can be requested at once
+const maxHistoryDays = 366
+
+func validatePeriod(v *validator.Validator, from, to time.Time) {
+	v.Check(!from.After(to), "from", "must not be after to")
+	v.Check(to.Sub(from) < maxHistoryDays*24*time.Hour, "to", fmt.Sprintf("period must not be longer than %d days", maxHistoryDays))
+}
+
+// Repository Errors
+var (
+	ErrProductNotFound  = errors.New("product not found")
+	ErrConnectionFailed = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrHistoryValidationFailed = errors.New("price history validation failed")
+)
+
+// Handler Errors
+var (
+	ErrInvalidParams = errors.New("invalid product id or period was sent")
+)
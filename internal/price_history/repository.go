@@ -1 +1,196 @@
 package price_history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// RecordTx method saves a new price of the product within tx, so it is recorded together with the product change
+func (r *Repository) RecordTx(ctx context.Context, tx postgres.Tx, entry *Entry) error {
+	const op = "RecordTx"
+
+	query := `
+		INSERT INTO
+		    price_history (product_id, price, currency)
+		VALUES
+		    ($1, $2, $3)
+		RETURNING history_id, changed_at`
+
+	args := []interface{}{
+		entry.ProductID,
+		entry.Price,
+		entry.Currency,
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&entry.HistoryID,
+		&entry.ChangedAt,
+	); err != nil {
+		if postgres.IsPgErr(err) {
+			err = postgres.Conv2CustomErr(err)
+		}
+
+		var pgErr *postgres.PostgresErr
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23503":
+				return postgres.ErrDoQuery(op, ErrProductNotFound)
+			case "08000", "08001", "08003", "08006":
+				return postgres.ErrDoQuery(op, ErrConnectionFailed)
+			default:
+				return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+			}
+		}
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	return nil
+}
+
+// ProductExists method checks that the product exists and is active
+func (r *Repository) ProductExists(ctx context.Context, productID int64) (bool, error) {
+	const op = "ProductExists"
+
+	query := `SELECT EXISTS (SELECT 1 FROM products WHERE product_id = $1 AND active = true)`
+
+	var exists bool
+	if err := r.client.Pool.QueryRow(ctx, query, productID).Scan(&exists); err != nil {
+		return false, postgres.ErrDoQuery(op, err)
+	}
+
+	return exists, nil
+}
+
+// GetByProductID method gets price changes of the product made in [from, to)
+func (r *Repository) GetByProductID(ctx context.Context, productID int64, from, to time.Time) ([]*Entry, error) {
+	const op = "GetByProductID"
+
+	query := `
+		SELECT
+		    history_id, product_id, price, currency, changed_at
+		FROM
+		    price_history
+		WHERE
+		    product_id = $1
+		AND
+		    changed_at >= $2
+		AND
+		    changed_at < $3
+		ORDER BY
+		    changed_at, history_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID, from, to)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+
+	for rows.Next() {
+		var entry Entry
+		err = rows.Scan(
+			&entry.HistoryID,
+			&entry.ProductID,
+			&entry.Price,
+			&entry.Currency,
+			&entry.ChangedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		entry.Price = entry.Price.RoundTo(entry.Currency)
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return entries, nil
+}
+
+// GetDaily method aggregates prices of the product by days in [from, to). Days without changes carry
+// the last known price, days before the product got its first price are skipped
+func (r *Repository) GetDaily(ctx context.Context, productID int64, from, to time.Time) ([]*DailyPoint, error) {
+	const op = "GetDaily"
+
+	query := `
+		SELECT
+		    d.day, close_price.currency, open_price.price, close_price.price,
+		    least(CASE WHEN open_price.currency = close_price.currency THEN open_price.price END, stats.min_price, close_price.price),
+		    greatest(CASE WHEN open_price.currency = close_price.currency THEN open_price.price END, stats.max_price, close_price.price)
+		FROM
+		    generate_series($2::timestamptz, $3::timestamptz - interval '1 day', interval '1 day') AS d(day)
+		LEFT JOIN LATERAL (
+		    SELECT price, currency FROM price_history
+		    WHERE product_id = $1 AND changed_at < d.day
+		    ORDER BY changed_at DESC, history_id DESC
+		    LIMIT 1
+		) open_price ON true
+		JOIN LATERAL (
+		    SELECT price, currency FROM price_history
+		    WHERE product_id = $1 AND changed_at < d.day + interval '1 day'
+		    ORDER BY changed_at DESC, history_id DESC
+		    LIMIT 1
+		) close_price ON true
+		LEFT JOIN LATERAL (
+		    SELECT min(price) AS min_price, max(price) AS max_price FROM price_history
+		    WHERE product_id = $1 AND changed_at >= d.day AND changed_at < d.day + interval '1 day'
+		    AND currency = close_price.currency
+		) stats ON true
+		ORDER BY
+		    d.day`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID, from, to)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	points := []*DailyPoint{}
+
+	for rows.Next() {
+		var (
+			point DailyPoint
+			day   time.Time
+		)
+		err = rows.Scan(
+			&day,
+			&point.Currency,
+			&point.Open,
+			&point.Close,
+			&point.Min,
+			&point.Max,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		point.setCurrency()
+		point.Date = day.Format(time.DateOnly)
+		points = append(points, &point)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return points, nil
+}
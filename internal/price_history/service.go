@@ -1 +1,68 @@
 package price_history
+
+import (
+	"context"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+	"time"
+)
+
+type Storage interface {
+	ProductExists(ctx context.Context, productID int64) (bool, error)
+	GetByProductID(ctx context.Context, productID int64, from, to time.Time) ([]*Entry, error)
+	GetDaily(ctx context.Context, productID int64, from, to time.Time) ([]*DailyPoint, error)
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+// defaultHistoryDays is the period returned when from is not provided
+const defaultHistoryDays = 30
+
+// GetPriceHistory returns price changes of the product between from and to days inclusive,
+// by default the last 30 days up to today are returned
+func (s *Service) GetPriceHistory(ctx context.Context, productID int64, query historyQuery) (*History, error) {
+	if query.To.IsZero() {
+		now := time.Now()
+		query.To = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	if query.From.IsZero() {
+		query.From = query.To.AddDate(0, 0, -defaultHistoryDays+1)
+	}
+
+	v := validator.New()
+
+	if validatePeriod(v, query.From, query.To); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrHistoryValidationFailed, v.Errors)
+	}
+
+	exists, err := s.Repository.ProductExists(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	// the to day is inclusive, so the bound passed to the repository is the start of the next day
+	to := query.To.AddDate(0, 0, 1)
+
+	entries, err := s.Repository.GetByProductID(ctx, productID, query.From, to)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := s.Repository.GetDaily(ctx, productID, query.From, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &History{Entries: entries, Daily: daily}, nil
+}
@@ -0,0 +1,214 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"ngMarketplace/internal/common/attribute_schema/parser"
+	"ngMarketplace/pkg/validator"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// attributeParamPrefix starts query parameters filtering by category attributes, e.g. attr.brand=Samsung,Apple
+// or attr.ram_min=8, values of one parameter are alternatives and different parameters must all match
+const attributeParamPrefix = "attr."
+
+// Suffixes of attribute parameters bounding numeric attributes
+const (
+	attributeMinSuffix = "_min"
+	attributeMaxSuffix = "_max"
+)
+
+// attributeFilter represents a condition on one attribute of the category schema: the attribute equals
+// one of Values and lies within [Min, Max] when they are set
+type attributeFilter struct {
+	Name   string
+	Values []interface{}
+	Min    *float64
+	Max    *float64
+}
+
+// attributeParams picks attribute parameters from the query, their names are returned without the prefix
+func attributeParams(query url.Values) map[string][]string {
+	params := make(map[string][]string)
+	for key, values := range query {
+		if name, ok := strings.CutPrefix(key, attributeParamPrefix); ok {
+			params[name] = values
+		}
+	}
+
+	return params
+}
+
+// parseAttributeFilters checks attribute parameters against the category schema: only declared attributes
+// are accepted, values must have their types and bounds are only allowed for numeric attributes
+func parseAttributeFilters(v *validator.Validator, params map[string][]string, schema json.RawMessage) []attributeFilter {
+	info, err := parser.ExtractInformation(schema)
+	if err != nil {
+		v.AddError("attr", "category has no valid attribute_schema to filter by")
+		return nil
+	}
+
+	byName := make(map[string]*attributeFilter, len(params))
+	for _, param := range sortedParams(params) {
+		key := attributeParamPrefix + param
+
+		name, bound := param, ""
+		field, ok := info.Property(name)
+		if !ok {
+			for _, suffix := range []string{attributeMinSuffix, attributeMaxSuffix} {
+				if trimmed, found := strings.CutSuffix(param, suffix); found {
+					name, bound = trimmed, suffix
+					field, ok = info.Property(name)
+					break
+				}
+			}
+		}
+
+		if !ok {
+			v.AddError(key, "is not declared in the category attribute_schema")
+			continue
+		}
+
+		filter, exists := byName[name]
+		if !exists {
+			filter = &attributeFilter{Name: name}
+			byName[name] = filter
+		}
+
+		if bound != "" {
+			if !field.IsNumeric() {
+				v.AddError(key, "bounds are only allowed for numeric attributes")
+				continue
+			}
+
+			number, err := strconv.ParseFloat(params[param][len(params[param])-1], 64)
+			if err != nil {
+				v.AddError(key, "must be a number")
+				continue
+			}
+
+			if bound == attributeMinSuffix {
+				filter.Min = &number
+			} else {
+				filter.Max = &number
+			}
+			continue
+		}
+
+		for _, raw := range splitValues(params[param]) {
+			value, err := attributeValue(field, raw)
+			if err == nil {
+				err = field.CheckValue(value)
+			}
+			if err != nil {
+				v.AddError(key, fmt.Sprintf("%q %s", raw, err.Error()))
+				continue
+			}
+
+			filter.Values = append(filter.Values, value)
+		}
+	}
+
+	filters := make([]attributeFilter, 0, len(byName))
+	for _, filter := range byName {
+		if filter.Min != nil && filter.Max != nil {
+			v.Check(*filter.Min <= *filter.Max, attributeParamPrefix+filter.Name, "min must not be greater than max")
+		}
+		filters = append(filters, *filter)
+	}
+	slices.SortFunc(filters, func(a, b attributeFilter) int { return strings.Compare(a.Name, b.Name) })
+
+	return filters
+}
+
+// attributeValue converts a query value to the JSON type of the field
+func attributeValue(field parser.FieldInfo, raw string) (interface{}, error) {
+	if !field.IsNumeric() {
+		return raw, nil
+	}
+
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("must be a number")
+	}
+
+	return number, nil
+}
+
+// attributeConditions builds a condition on translations of products aliased as alias for the filters,
+// numbering its arguments from firstArg. Values are matched by containment, which is served by the jsonb_path_ops
+// GIN index on attributes. Bounds are matched by jsonpath comparisons the index cannot serve, they are checked
+// on translations of products already narrowed down by the other conditions, a numeric attribute filtered by
+// range on large categories would need an expression index of its own
+func attributeConditions(filters []attributeFilter, alias string, firstArg int) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", firstArg+len(args)-1)
+	}
+
+	for _, filter := range filters {
+		name, _ := json.Marshal(filter.Name)
+
+		if len(filter.Values) > 0 {
+			alternatives := make([]string, 0, len(filter.Values))
+			for _, value := range filter.Values {
+				document, _ := json.Marshal(map[string]interface{}{filter.Name: value})
+				alternatives = append(alternatives, fmt.Sprintf("t.attributes @> %s::jsonb", arg(string(document))))
+			}
+			conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+		}
+
+		var bounds []string
+		if filter.Min != nil {
+			bounds = append(bounds, fmt.Sprintf("$.%s >= %s", name, strconv.FormatFloat(*filter.Min, 'f', -1, 64)))
+		}
+		if filter.Max != nil {
+			bounds = append(bounds, fmt.Sprintf("$.%s <= %s", name, strconv.FormatFloat(*filter.Max, 'f', -1, 64)))
+		}
+		if len(bounds) > 0 {
+			conditions = append(conditions, fmt.Sprintf("t.attributes @@ %s::jsonpath", arg(strings.Join(bounds, " && "))))
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "true", nil
+	}
+
+	// attributes are kept per translation, a product matches when one of its translations matches all conditions
+	return fmt.Sprintf(`EXISTS (
+		    SELECT 1 FROM product_translations t
+		    WHERE t.product_id = %s.product_id AND t.deleted_at IS NULL AND %s)`,
+		alias, strings.Join(conditions, " AND ")), args
+}
+
+// splitValues splits comma separated alternatives, the parameter may also be repeated
+func splitValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+
+	return result
+}
+
+func sortedParams(params map[string][]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,188 @@
+package product
+
+import (
+	"encoding/json"
+	"net/url"
+	"ngMarketplace/pkg/validator"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testAttributeSchema = `{
+	"type": "object",
+	"title": "Phones",
+	"properties": {
+		"brand": {"type": "string", "enum": ["Samsung", "Apple"]},
+		"ram": {"type": "integer", "minimum": 1, "maximum": 64}
+	}
+}`
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func TestAttributeParams(t *testing.T) {
+	query := url.Values{
+		"attr.brand":   {"Samsung"},
+		"attr.ram_min": {"8"},
+		"sort":         {"price"},
+	}
+
+	want := map[string][]string{"brand": {"Samsung"}, "ram_min": {"8"}}
+	if got := attributeParams(query); !reflect.DeepEqual(got, want) {
+		t.Errorf("attributeParams() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitValues(t *testing.T) {
+	got := splitValues([]string{"Samsung, Apple", "", "Xiaomi,,"})
+	want := []string{"Samsung", "Apple", "Xiaomi"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitValues() = %v, want %v", got, want)
+	}
+}
+
+func TestParseAttributeFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string][]string
+		want   []attributeFilter
+		errors []string
+	}{
+		{
+			name:   "values and bounds",
+			params: map[string][]string{"brand": {"Samsung,Apple"}, "ram_min": {"8"}, "ram_max": {"16"}},
+			want: []attributeFilter{
+				{Name: "brand", Values: []interface{}{"Samsung", "Apple"}},
+				{Name: "ram", Min: floatPtr(8), Max: floatPtr(16)},
+			},
+		},
+		{
+			name:   "numeric values",
+			params: map[string][]string{"ram": {"8", "12"}},
+			want:   []attributeFilter{{Name: "ram", Values: []interface{}{8.0, 12.0}}},
+		},
+		{
+			name:   "undeclared attribute",
+			params: map[string][]string{"color": {"red"}},
+			want:   []attributeFilter{},
+			errors: []string{"attr.color"},
+		},
+		{
+			name:   "value outside the enum",
+			params: map[string][]string{"brand": {"Nokia"}},
+			want:   []attributeFilter{{Name: "brand"}},
+			errors: []string{"attr.brand"},
+		},
+		{
+			name:   "bound of a string attribute",
+			params: map[string][]string{"brand_min": {"1"}},
+			want:   []attributeFilter{{Name: "brand"}},
+			errors: []string{"attr.brand_min"},
+		},
+		{
+			name:   "bound is not a number",
+			params: map[string][]string{"ram_max": {"lots"}},
+			want:   []attributeFilter{{Name: "ram"}},
+			errors: []string{"attr.ram_max"},
+		},
+		{
+			name:   "min greater than max",
+			params: map[string][]string{"ram_min": {"16"}, "ram_max": {"8"}},
+			want:   []attributeFilter{{Name: "ram", Min: floatPtr(16), Max: floatPtr(8)}},
+			errors: []string{"attr.ram"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			got := parseAttributeFilters(v, tt.params, json.RawMessage(testAttributeSchema))
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAttributeFilters() = %+v, want %+v", got, tt.want)
+			}
+			if len(v.Errors) != len(tt.errors) {
+				t.Errorf("parseAttributeFilters() errors = %v, want keys %v", v.Errors, tt.errors)
+			}
+			for _, key := range tt.errors {
+				if _, ok := v.Errors[key]; !ok {
+					t.Errorf("parseAttributeFilters() errors = %v, want key %q", v.Errors, key)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAttributeFiltersInvalidSchema(t *testing.T) {
+	v := validator.New()
+	if got := parseAttributeFilters(v, map[string][]string{"brand": {"Samsung"}}, nil); got != nil {
+		t.Errorf("parseAttributeFilters() = %+v, want nil", got)
+	}
+	if _, ok := v.Errors["attr"]; !ok {
+		t.Errorf("parseAttributeFilters() errors = %v, want key %q", v.Errors, "attr")
+	}
+}
+
+func TestAttributeConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		filters   []attributeFilter
+		firstArg  int
+		condition string
+		args      []interface{}
+	}{
+		{
+			name:      "no filters",
+			firstArg:  1,
+			condition: "true",
+		},
+		{
+			name:      "values",
+			filters:   []attributeFilter{{Name: "brand", Values: []interface{}{"Samsung", "Apple"}}},
+			firstArg:  3,
+			condition: `(t.attributes @> $3::jsonb OR t.attributes @> $4::jsonb)`,
+			args:      []interface{}{`{"brand":"Samsung"}`, `{"brand":"Apple"}`},
+		},
+		{
+			name:      "bounds",
+			filters:   []attributeFilter{{Name: "ram", Min: floatPtr(8), Max: floatPtr(16.5)}},
+			firstArg:  1,
+			condition: `t.attributes @@ $1::jsonpath`,
+			args:      []interface{}{`$."ram" >= 8 && $."ram" <= 16.5`},
+		},
+		{
+			name: "several attributes",
+			filters: []attributeFilter{
+				{Name: "brand", Values: []interface{}{"Apple"}},
+				{Name: "ram", Values: []interface{}{8.0}, Max: floatPtr(16)},
+			},
+			firstArg:  2,
+			condition: `(t.attributes @> $2::jsonb) AND (t.attributes @> $3::jsonb) AND t.attributes @@ $4::jsonpath`,
+			args:      []interface{}{`{"brand":"Apple"}`, `{"ram":8}`, `$."ram" <= 16`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args := attributeConditions(tt.filters, "p", tt.firstArg)
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("attributeConditions() args = %v, want %v", args, tt.args)
+			}
+			if tt.condition == "true" {
+				if condition != "true" {
+					t.Errorf("attributeConditions() = %q, want %q", condition, "true")
+				}
+				return
+			}
+			if !strings.HasPrefix(condition, "EXISTS (") || !strings.Contains(condition, "t.product_id = p.product_id") {
+				t.Errorf("attributeConditions() = %q, want EXISTS over translations of p", condition)
+			}
+			if !strings.HasSuffix(condition, "t.deleted_at IS NULL AND "+tt.condition+")") {
+				t.Errorf("attributeConditions() = %q, want to end with %q", condition, tt.condition)
+			}
+		})
+	}
+}
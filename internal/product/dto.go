@@ -1,13 +1,28 @@
 package product
 
-import "ngMarketplace/internal/common"
+import (
+	"encoding/json"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/money"
+	"time"
+)
 
 // createProductRequest represents a request body for creating a product
 type createProductRequest struct {
-	Price      float64 `json:"price" binding:"required,min=1"`
-	Currency   string  `json:"currency" binding:"required"`
-	CategoryID int     `json:"category_id" binding:"required,min=1"`
-	UserID     int     `json:"user_id" binding:"required,min=1"` // todo user_id should be got from token
+	Price      money.Money `json:"price"`
+	Currency   string      `json:"currency" binding:"required"`
+	CategoryID int         `json:"category_id" binding:"required,min=1"`
+	UserID     int         `json:"user_id" binding:"required,min=1"` // todo user_id should be got from token
+
+	Translations []translationRequest `json:"translations" binding:"omitempty,dive"`
+}
+
+// translationRequest represents an initial translation sent together with a new product
+type translationRequest struct {
+	Language           string          `json:"language" binding:"required,oneof=tj ru en"`
+	ProductName        string          `json:"product_name" binding:"required"`
+	ProductDescription *string         `json:"product_description"`
+	Attributes         json.RawMessage `json:"attributes"`
 }
 
 // getProductRequest represents the param request for getting a product
@@ -15,19 +30,75 @@ type getProductRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+// getProductQuery represents the query for getting a product together with its translation
+// and prices converted to display_currency, state and viewer_id select the scope as in getProductsRequest.
+// fields and include shape the response as in listings
+type getProductQuery struct {
+	Language        string `form:"language" binding:"omitempty,oneof=tj ru en"`
+	DisplayCurrency string `form:"display_currency" binding:"omitempty,oneof=TJS RUB USD"`
+	State           string `form:"state" binding:"omitempty,oneof=active deleted all"` // available to admins only
+	ViewerID        int    `form:"viewer_id" binding:"omitempty,min=1"`                // available to admins only until sellers have accounts
+	common.Expansion
+}
+
+// batchGetRequest represents a request body for getting several products at once
+type batchGetRequest struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// batchGetQuery represents the query of getting several products at once, it shapes products
+// the same way as getProductsRequest
+type batchGetQuery struct {
+	DisplayCurrency string `form:"display_currency"`
+	State           string `form:"state"`     // available to admins only
+	ViewerID        int    `form:"viewer_id"` // available to admins only until sellers have accounts
+	common.Expansion
+}
+
 // updateProductRequest represents a request body for updating a product
 type updateProductRequest struct {
-	Price      *float64 `json:"price"`
-	Currency   *string  `json:"currency"`
-	CategoryID *int     `json:"category_id"`
+	Price      *money.Money `json:"price"`
+	Currency   *string      `json:"currency"`
+	CategoryID *int         `json:"category_id"`
+}
+
+// changeStatusRequest represents a request body for changing the status of a product,
+// reason is required when a product is rejected
+type changeStatusRequest struct {
+	UserID int     `json:"user_id" binding:"required,min=1"` // todo user_id should be got from token
+	Reason *string `json:"reason"`
 }
 
-// getProductsRequest represents a query for getting products by filters
+// getProductsRequest represents a query for getting products by filters, price_mode=effective makes
+// from_price and to_price filter by the discounted price instead of the base one. Price bounds are given
+// in display_currency and match products in any currency, currency only narrows the list to one listing currency.
+// category_id may be repeated, products of subcategories are matched too unless include_subcategories=false.
+// state=deleted|all is the admin view including soft deleted products and viewer_id is the view of the seller
+// who also sees their own products in any status. created_after and created_before are RFC 3339 times
+// bounding the creation time. fields limits returned fields and include embeds
+// category, translations, images and seller of products.
+// Attributes are attr.* parameters filtering by attributes of the category, see attributeParamPrefix
 type getProductsRequest struct {
-	FromPrice  float64 `form:"from_price"`
-	ToPrice    float64 `form:"to_price"`
-	Currency   string  `form:"currency"`
-	CategoryID int     `form:"category_id"`
-	UserID     int     `form:"user_id"`
+	FromPrice            money.Money         `form:"from_price"`
+	ToPrice              money.Money         `form:"to_price"`
+	PriceMode            string              `form:"price_mode"`
+	DisplayCurrency      string              `form:"display_currency"`
+	Currency             string              `form:"currency"`
+	CategoryIDs          []int               `form:"category_id"`
+	IncludeSubcategories *bool               `form:"include_subcategories"`
+	UserID               int                 `form:"user_id"`
+	State                string              `form:"state"`     // available to admins only
+	ViewerID             int                 `form:"viewer_id"` // available to admins only until sellers have accounts
+	CreatedAfter         *time.Time          `form:"created_after"`
+	CreatedBefore        *time.Time          `form:"created_before"`
+	Attributes           map[string][]string `form:"-"`
+	common.Expansion
 	common.Filters
 }
+
+// searchProductsRequest represents a query for full-text search of products, it accepts the same filters as listing
+type searchProductsRequest struct {
+	Query    string `form:"q"`
+	Language string `form:"language"`
+	getProductsRequest
+}
@@ -6,22 +6,40 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/category"
 	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/product_translation"
 	"ngMarketplace/internal/transport/http/router"
 	"ngMarketplace/pkg/logger"
 )
 
 const (
-	productURL  = "/products/:id"
-	productsURL = "/products"
+	productURL       = "/products/:id"
+	restoreURL       = "/products/:id/restore"
+	productsURL      = "/products"
+	searchURL        = "/products/search"
+	submitURL        = "/products/:id/submit"
+	publishURL       = "/products/:id/publish"
+	rejectURL        = "/products/:id/reject"
+	archiveURL       = "/products/:id/archive"
+	sellURL          = "/products/:id/sell"
+	statusHistoryURL = "/products/:id/status-history"
+	facetsURL        = "/categories/:id/facets"
+	batchGetURL      = "/products/batch-get"
 )
 
 type UseCase interface {
 	CreateProduct(ctx context.Context, product *Product) error
-	GetProduct(ctx context.Context, id int64) (*Product, error)
-	UpdateProduct(ctx context.Context, id int64, request *updateProductRequest) (*Product, error)
-	DeleteProduct(ctx context.Context, id int64) error
+	GetProduct(ctx context.Context, id int64, language string, displayCurrency string, visibility common.Visibility, expansion common.Expansion) (*Product, error)
+	BatchGetProducts(ctx context.Context, ids []int, displayCurrency string, visibility common.Visibility, expansion common.Expansion) ([]*Product, []int, error)
+	UpdateProduct(ctx context.Context, id int64, request *updateProductRequest, version int) (*Product, error)
+	DeleteProduct(ctx context.Context, id int64, version int) error
+	RestoreProduct(ctx context.Context, id int64) error
+	ChangeStatus(ctx context.Context, id int64, action string, request *changeStatusRequest) (*Product, error)
+	GetStatusHistory(ctx context.Context, id int64) ([]*StatusChange, error)
 	GetProducts(ctx context.Context, filters getProductsRequest) ([]*Product, common.Metadata, error)
+	SearchProducts(ctx context.Context, filters searchProductsRequest) ([]*SearchResult, common.Metadata, error)
+	GetFacets(ctx context.Context, categoryID int64, filters getProductsRequest) ([]*Facet, error)
 }
 
 type Handler struct {
@@ -41,7 +59,17 @@ func (h *Handler) Register(router *gin.Engine) {
 	router.GET(productURL, h.showProductHandler)
 	router.PATCH(productURL, h.updateProductHandler)
 	router.DELETE(productURL, h.deleteProductHandler)
+	router.POST(restoreURL, h.restoreProductHandler)
 	router.GET(productsURL, h.listProductsHandler)
+	router.GET(searchURL, h.searchProductsHandler)
+	router.POST(submitURL, h.changeStatusHandler(ActionSubmit))
+	router.POST(publishURL, h.changeStatusHandler(ActionPublish))
+	router.POST(rejectURL, h.changeStatusHandler(ActionReject))
+	router.POST(archiveURL, h.changeStatusHandler(ActionArchive))
+	router.POST(sellURL, h.changeStatusHandler(ActionSell))
+	router.GET(statusHistoryURL, h.statusHistoryHandler)
+	router.GET(facetsURL, h.facetsHandler)
+	router.POST(batchGetURL, h.batchGetProductsHandler)
 }
 
 // createProductHandler creates a new Product in Marketplace
@@ -62,6 +90,15 @@ func (h *Handler) createProductHandler(ctx *gin.Context) {
 		UserID:     req.UserID,
 	}
 
+	for _, translation := range req.Translations {
+		product.Translations = append(product.Translations, &product_translation.Translation{
+			Language:           translation.Language,
+			ProductName:        translation.ProductName,
+			ProductDescription: translation.ProductDescription,
+			Attributes:         translation.Attributes,
+		})
+	}
+
 	if err := h.useCase.CreateProduct(ctx, product); err != nil {
 		h.logger.Error("%s: h.useCase.Create: %v", op, err)
 		switch {
@@ -69,7 +106,9 @@ func (h *Handler) createProductHandler(ctx *gin.Context) {
 			apperror.WriteBadRequestResponse(ctx, err, err.Error())
 		case errors.Is(err, ErrInvalidForeignKey):
 			apperror.WriteBadRequestResponse(ctx, err, "Entered wrong category, or please sign out and sign in again")
-		case errors.Is(err, ErrConnectionFailed):
+		case errors.Is(err, product_translation.ErrDuplicateTranslation):
+			apperror.WriteConflictResponse(ctx, err, "Only one translation per language can be sent")
+		case errors.Is(err, ErrConnectionFailed), errors.Is(err, product_translation.ErrConnectionFailed):
 			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
@@ -77,14 +116,15 @@ func (h *Handler) createProductHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"product": product}, nil); err != nil {
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"product": product}, router.ETagHeader(product.Version)); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
 		ctx.JSON(http.StatusCreated, gin.H{"product": product})
 		return
 	}
 }
 
-// showProductHandler gets a product by product_id
+// showProductHandler gets a product by product_id, with ?language= the translation is embedded,
+// ?fields= and ?include= shape the response
 func (h *Handler) showProductHandler(ctx *gin.Context) {
 	const op = "getProductHandler"
 
@@ -95,26 +135,116 @@ func (h *Handler) showProductHandler(ctx *gin.Context) {
 		return
 	}
 
-	product, err := h.useCase.GetProduct(ctx, req.ID)
+	var query getProductQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrFailedQuery, "language must be one of tj, ru, en, display_currency one of TJS, RUB, USD and state one of active, deleted, all")
+		return
+	}
+
+	if common.AdminOnly(query.State, query.ViewerID) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state or viewer_id")
+		return
+	}
+
+	visibility := common.NewVisibility(query.State, query.ViewerID)
+
+	product, err := h.useCase.GetProduct(ctx, req.ID, query.Language, query.DisplayCurrency, visibility, query.Expansion)
 	if err != nil {
 		h.logger.Error("%s: h.useCase.GetProduct: %v", op, err)
 		switch {
 		case errors.Is(err, ErrProductNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking does not exist")
+		case errors.Is(err, common.ErrExpansionValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check fields and include parameters")
+		case errors.Is(err, ErrRateNotFound):
+			apperror.WriteSrvUnResponse(ctx, err, "Exchange rate of the display currency is not loaded yet, try again later")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
 		return
 	}
 
-	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": product}, nil); err != nil {
+	projected, err := query.Project(product)
+	if err != nil {
+		h.logger.Error("%s: query.Project: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	etag, err := router.RepresentationETag(product.Version, projected)
+	if err != nil {
+		h.logger.Error("%s: router.RepresentationETag: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if router.NotModified(ctx, etag) {
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": projected}, http.Header{"ETag": []string{etag}}); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
-		ctx.JSON(http.StatusOK, gin.H{"product": product})
+		ctx.JSON(http.StatusOK, gin.H{"product": projected})
 		return
 	}
 }
 
-// updateProductHandler updates product currency, price, or category
+// batchGetProductsHandler returns products by the list of ids in the order they were requested in,
+// ids of products which do not exist or are not visible are returned in not_found
+func (h *Handler) batchGetProductsHandler(ctx *gin.Context) {
+	const op = "batchGetProductsHandler"
+
+	var req batchGetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "ids must be sent as a list of product ids")
+		return
+	}
+
+	var query batchGetQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrFailedQuery, "some parameter was sent with incorrect type")
+		return
+	}
+
+	if common.AdminOnly(query.State, query.ViewerID) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state or viewer_id")
+		return
+	}
+
+	visibility := common.NewVisibility(query.State, query.ViewerID)
+
+	products, notFound, err := h.useCase.BatchGetProducts(ctx, req.IDs, query.DisplayCurrency, visibility, query.Expansion)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.BatchGetProducts: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrBatchValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check ids and parameters")
+		case errors.Is(err, ErrRateNotFound):
+			apperror.WriteSrvUnResponse(ctx, err, "Exchange rate of the display currency is not loaded yet, try again later")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	projected, err := common.ProjectAll(query.Expansion, products)
+	if err != nil {
+		h.logger.Error("%s: common.ProjectAll: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"products": projected, "not_found": notFound}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"products": projected, "not_found": notFound})
+		return
+	}
+}
+
+// updateProductHandler updates product currency, price, or category, If-Match must hold the ETag of the product
 func (h *Handler) updateProductHandler(ctx *gin.Context) {
 	const op = "updateProductHandler"
 
@@ -125,6 +255,13 @@ func (h *Handler) updateProductHandler(ctx *gin.Context) {
 		return
 	}
 
+	version, err := router.IfMatchVersion(ctx)
+	if err != nil {
+		h.logger.Error("%s: router.IfMatchVersion: %v", op, err)
+		apperror.WriteIfMatchErrResponse(ctx, err)
+		return
+	}
+
 	var input updateProductRequest
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -133,28 +270,32 @@ func (h *Handler) updateProductHandler(ctx *gin.Context) {
 		return
 	}
 
-	product, err := h.useCase.UpdateProduct(ctx, req.ID, &input)
+	product, err := h.useCase.UpdateProduct(ctx, req.ID, &input, version)
 	if err != nil {
 		h.logger.Error("%s: h.useCase.UpdateProduct: %v", op, err)
 		switch {
 		case errors.Is(err, ErrProductNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking to update does not exist")
+		case errors.Is(err, ErrVersionMismatch):
+			apperror.WritePreconditionFailedResponse(ctx, err, "Product was changed by someone else, read it again")
 		case errors.Is(err, ErrProductValidationFailed):
 			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrInvalidForeignKey):
+			apperror.WriteBadRequestResponse(ctx, err, "Entered wrong category")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
 		return
 	}
 
-	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": product}, nil); err != nil {
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": product}, router.ETagHeader(product.Version)); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
 		ctx.JSON(http.StatusOK, gin.H{"product": product})
 		return
 	}
 }
 
-// deleteProductHandler makes Product's field active false
+// deleteProductHandler makes Product's field active false, If-Match must hold the ETag of the product
 func (h *Handler) deleteProductHandler(ctx *gin.Context) {
 	const op = "deleteProductHandler"
 
@@ -165,11 +306,20 @@ func (h *Handler) deleteProductHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.useCase.DeleteProduct(ctx, req.ID); err != nil {
+	version, err := router.IfMatchVersion(ctx)
+	if err != nil {
+		h.logger.Error("%s: router.IfMatchVersion: %v", op, err)
+		apperror.WriteIfMatchErrResponse(ctx, err)
+		return
+	}
+
+	if err = h.useCase.DeleteProduct(ctx, req.ID, version); err != nil {
 		h.logger.Error("%s: h.useCase.DeleteProduct: %v", op, err)
 		switch {
 		case errors.Is(err, ErrProductNotFound):
 			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking to delete does not exist")
+		case errors.Is(err, ErrVersionMismatch):
+			apperror.WritePreconditionFailedResponse(ctx, err, "Product was changed by someone else, read it again")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
@@ -183,6 +333,124 @@ func (h *Handler) deleteProductHandler(ctx *gin.Context) {
 	}
 }
 
+// restoreProductHandler restores a soft deleted product, it is available to admins only
+func (h *Handler) restoreProductHandler(ctx *gin.Context) {
+	const op = "restoreProductHandler"
+
+	if !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, ErrAdminOnly, "Send the admin token to restore products")
+		return
+	}
+
+	var req getProductRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	if err := h.useCase.RestoreProduct(ctx, req.ID); err != nil {
+		h.logger.Error("%s: h.useCase.RestoreProduct: %v", op, err)
+		switch {
+		case errors.Is(err, ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking to restore does not exist or is not deleted")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"message": "product was successfully restored"}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"message": "product was successfully restored"})
+		return
+	}
+}
+
+// changeStatusHandler returns a handler moving the product through its lifecycle with the action
+func (h *Handler) changeStatusHandler(action string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		const op = "changeStatusHandler"
+
+		if transitions[action].moderated && !router.IsAdmin(ctx) {
+			apperror.WriteForbiddenResponse(ctx, ErrModeratorOnly, "Send the admin token to publish or reject products")
+			return
+		}
+
+		var req getProductRequest
+		if err := ctx.ShouldBindUri(&req); err != nil {
+			h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+			apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+			return
+		}
+
+		var input changeStatusRequest
+		if err := ctx.ShouldBindJSON(&input); err != nil {
+			h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+			apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+			return
+		}
+
+		product, err := h.useCase.ChangeStatus(ctx, req.ID, action, &input)
+		if err != nil {
+			h.logger.Error("%s: h.useCase.ChangeStatus(%s): %v", op, action, err)
+			switch {
+			case errors.Is(err, ErrProductNotFound):
+				apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking does not exist")
+			case errors.Is(err, ErrProductValidationFailed):
+				apperror.WriteBadRequestResponse(ctx, err, err.Error())
+			case errors.Is(err, ErrNotProductOwner):
+				apperror.WriteForbiddenResponse(ctx, err, "Only the seller of the product can do this")
+			case errors.Is(err, ErrInvalidTransition):
+				apperror.WriteConflictResponse(ctx, err, err.Error())
+			case errors.Is(err, ErrStatusChanged):
+				apperror.WriteConflictResponse(ctx, err, "Product status was changed meanwhile, try again")
+			case errors.Is(err, ErrClaimedByOther):
+				apperror.WriteConflictResponse(ctx, err, "Product is being reviewed by another moderator")
+			default:
+				apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+			}
+			return
+		}
+
+		if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"product": product}, nil); err != nil {
+			h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+			ctx.JSON(http.StatusOK, gin.H{"product": product})
+			return
+		}
+	}
+}
+
+// statusHistoryHandler returns status changes of the product
+func (h *Handler) statusHistoryHandler(ctx *gin.Context) {
+	const op = "statusHistoryHandler"
+
+	var req getProductRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	history, err := h.useCase.GetStatusHistory(ctx, req.ID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetStatusHistory: %v", op, err)
+		switch {
+		case errors.Is(err, ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are seeking does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"status_history": history}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"status_history": history})
+		return
+	}
+}
+
 // listProductsHandler returns a list of products by filters
 func (h *Handler) listProductsHandler(ctx *gin.Context) {
 	const op = "listProductsHandler"
@@ -195,20 +463,131 @@ func (h *Handler) listProductsHandler(ctx *gin.Context) {
 		return
 	}
 
+	req.Attributes = attributeParams(ctx.Request.URL.Query())
+
+	if common.AdminOnly(req.State, req.ViewerID) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state or viewer_id")
+		return
+	}
+
 	products, metadata, err := h.useCase.GetProducts(ctx, req)
 	if err != nil {
 		switch {
 		case errors.Is(err, common.ErrFilterValidationFailed):
 			apperror.WriteBadRequestResponse(ctx, err, "check filter parameters")
+		case errors.Is(err, ErrRateNotFound):
+			apperror.WriteSrvUnResponse(ctx, err, "Exchange rate of the display currency is not loaded yet, try again later")
 		default:
 			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
 		}
 		return
 	}
 
-	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"products": products, "metadata": metadata}, nil); err != nil {
+	projected, err := common.ProjectAll(req.Expansion, products)
+	if err != nil {
+		h.logger.Error("%s: common.ProjectAll: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"products": projected, "metadata": metadata}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"products": projected, "metadata": metadata})
+		return
+	}
+}
+
+// facetsHandler returns facets of filterable attributes of the category, it accepts the same filters as listing
+func (h *Handler) facetsHandler(ctx *gin.Context) {
+	const op = "facetsHandler"
+
+	var uri getProductRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct category id")
+		return
+	}
+
+	var req getProductsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some filter was sent with incorrect type")
+		return
+	}
+
+	req.Attributes = attributeParams(ctx.Request.URL.Query())
+
+	if common.AdminOnly(req.State, req.ViewerID) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state or viewer_id")
+		return
+	}
+
+	facets, err := h.useCase.GetFacets(ctx, uri.ID, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetFacets: %v", op, err)
+		switch {
+		case errors.Is(err, category.ErrCategoryNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Category you are seeking does not exist")
+		case errors.Is(err, common.ErrFilterValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check filter parameters")
+		case errors.Is(err, ErrRateNotFound):
+			apperror.WriteSrvUnResponse(ctx, err, "Exchange rate of the display currency is not loaded yet, try again later")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"facets": facets}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"facets": facets})
+		return
+	}
+}
+
+// searchProductsHandler returns products matching the full-text query ordered by relevance
+func (h *Handler) searchProductsHandler(ctx *gin.Context) {
+	const op = "searchProductsHandler"
+
+	var req searchProductsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some filter was sent with incorrect type")
+		return
+	}
+
+	req.Attributes = attributeParams(ctx.Request.URL.Query())
+
+	if common.AdminOnly(req.State, req.ViewerID) && !router.IsAdmin(ctx) {
+		apperror.WriteForbiddenResponse(ctx, common.ErrAdminOnly, "Send the admin token to read with state or viewer_id")
+		return
+	}
+
+	products, metadata, err := h.useCase.SearchProducts(ctx, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.SearchProducts: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrFilterValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check search parameters")
+		case errors.Is(err, ErrRateNotFound):
+			apperror.WriteSrvUnResponse(ctx, err, "Exchange rate of the display currency is not loaded yet, try again later")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	projected, err := common.ProjectAll(req.Expansion, products)
+	if err != nil {
+		h.logger.Error("%s: common.ProjectAll: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"products": projected, "metadata": metadata}, nil); err != nil {
 		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
-		ctx.JSON(http.StatusOK, gin.H{"products": products, "metadata": metadata})
+		ctx.JSON(http.StatusOK, gin.H{"products": projected, "metadata": metadata})
 		return
 	}
 }
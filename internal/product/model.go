@@ -2,25 +2,203 @@ package product
 
 import (
 	"errors"
+	"fmt"
+	"ngMarketplace/internal/category"
+	"ngMarketplace/internal/common/attribute_schema/parser"
+	"ngMarketplace/internal/discount"
+	"ngMarketplace/internal/product_image"
+	"ngMarketplace/internal/product_translation"
+	"ngMarketplace/internal/product_variant"
+	"ngMarketplace/pkg/money"
 	"ngMarketplace/pkg/validator"
 	"time"
 )
 
-// Product represents a product in marketplace
+// Product represents a product in marketplace, EffectivePrice is Price with the currently applied discounts.
+// Display prices are both prices converted to DisplayCurrency requested by the client
 type Product struct {
-	ProductID  int        `json:"product_id"`
-	Price      float64    `json:"price"`
-	Currency   string     `json:"currency"`
-	CategoryID int        `json:"category_id"`
-	UserID     int        `json:"user_id"`
-	Active     bool       `json:"-"`
-	CreatedAt  time.Time  `json:"-"`
-	UpdatedAt  time.Time  `json:"-"`
-	DeletedAt  *time.Time `json:"-"`
+	ProductID      int         `json:"product_id"`
+	Price          money.Money `json:"price"`
+	EffectivePrice money.Money `json:"effective_price"`
+	Currency       string      `json:"currency"`
+	Status         string      `json:"status"`
+	CategoryID     int         `json:"category_id"`
+	UserID         int         `json:"user_id"`
+	Active         bool        `json:"-"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	DeletedAt      *time.Time  `json:"deleted_at,omitempty"`
+	Version        int         `json:"-"`
+	ExternalSKU    *string     `json:"-"`
+
+	Translation  *product_translation.Translation   `json:"translation,omitempty"`
+	Translations []*product_translation.Translation `json:"translations,omitempty"`
+	Variants     []*product_variant.Variant         `json:"variants,omitempty"`
+	PriceRange   *PriceRange                        `json:"price_range,omitempty"`
+	Discounts    []*discount.Discount               `json:"discounts,omitempty"`
+	Category     *category.Category                 `json:"category,omitempty"`
+	Images       []*product_image.Image             `json:"images,omitempty"`
+	Seller       *Seller                            `json:"seller,omitempty"`
+
+	DisplayCurrency       string       `json:"display_currency,omitempty"`
+	DisplayPrice          *money.Money `json:"display_price,omitempty"`
+	DisplayEffectivePrice *money.Money `json:"display_effective_price,omitempty"`
+}
+
+// setCurrencies brings prices scanned from the database to the currencies they are in, the database keeps
+// amounts without currency and converted prices may have more decimal places than the currency allows
+func (p *Product) setCurrencies() {
+	p.Price = p.Price.RoundTo(p.Currency)
+	p.EffectivePrice = p.EffectivePrice.RoundTo(p.Currency)
+
+	if p.DisplayPrice != nil && p.DisplayEffectivePrice != nil {
+		displayPrice := p.DisplayPrice.RoundTo(p.DisplayCurrency)
+		displayEffectivePrice := p.DisplayEffectivePrice.RoundTo(p.DisplayCurrency)
+		p.DisplayPrice, p.DisplayEffectivePrice = &displayPrice, &displayEffectivePrice
+	}
+}
+
+// Seller represents the user selling a product as far as products know about them, there are no user
+// profiles yet, so it is the number of products the user has published
+type Seller struct {
+	UserID            int `json:"user_id"`
+	PublishedProducts int `json:"published_products"`
+}
+
+// Fields and relations of products clients may ask for with fields and include, search results
+// have searchFields besides
+var (
+	productFields = []string{
+		"product_id", "price", "effective_price", "currency", "status", "category_id", "user_id",
+		"translation", "variants", "price_range", "discounts",
+		"display_currency", "display_price", "display_effective_price",
+		"created_at", "updated_at", "deleted_at",
+	}
+	searchFields     = []string{"rank", "headline"}
+	productRelations = []string{relationCategory, relationTranslations, relationImages, relationSeller}
+)
+
+// Relations of products embedded on request, they are named after the keys they are embedded under
+const (
+	relationCategory     = "category"
+	relationTranslations = "translations"
+	relationImages       = "images"
+	relationSeller       = "seller"
+)
+
+// Price modes of listing filters: from_price and to_price are compared either with the base price
+// or with the price after discounts
+const (
+	priceModeBase      = "base"
+	priceModeEffective = "effective"
+)
+
+// maxCategoryIDs limits the number of category_id values of listing filters
+const maxCategoryIDs = 20
+
+// maxExternalSKULength limits the length of skus sellers import products with
+const maxExternalSKULength = 100
+
+// PriceRange represents the lowest and the highest price among variants of a product
+type PriceRange struct {
+	Min money.Money `json:"min"`
+	Max money.Money `json:"max"`
+}
+
+// calculatePriceRange returns the range of prices of variants before discounts, nil when there are no variants
+func calculatePriceRange(productPrice money.Money, variants []*product_variant.Variant) *PriceRange {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	priceRange := &PriceRange{Min: variants[0].BasePrice(productPrice), Max: variants[0].BasePrice(productPrice)}
+	for _, variant := range variants[1:] {
+		price := variant.BasePrice(productPrice)
+		if price.Cmp(priceRange.Min) < 0 {
+			priceRange.Min = price
+		}
+		if price.Cmp(priceRange.Max) > 0 {
+			priceRange.Max = price
+		}
+	}
+
+	return priceRange
+}
+
+// SearchResult represents a product found by full-text search with its relevance and highlighted snippet
+type SearchResult struct {
+	*Product
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+// Facet represents aggregates of one filterable attribute over listed products: counts of products
+// per value for string attributes and the range of values for numeric ones
+type Facet struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Values []*FacetValue `json:"values,omitempty"`
+	Min    *float64      `json:"min,omitempty"`
+	Max    *float64      `json:"max,omitempty"`
+
+	enum bool
+}
+
+// FacetValue represents the number of products having the attribute value
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// facetFor describes the field of the schema as a facet, values of enum fields are listed with zero counts
+// so that every option can be shown, false is returned for fields of types which cannot be aggregated
+func facetFor(field parser.FieldInfo) (*Facet, bool) {
+	facet := &Facet{Name: field.FieldName, Type: field.FieldType}
+
+	switch {
+	case field.IsNumeric():
+	case field.FieldType == "string":
+		facet.enum = len(field.Enum) > 0
+		facet.Values = make([]*FacetValue, 0, len(field.Enum))
+		for _, value := range field.Enum {
+			facet.Values = append(facet.Values, &FacetValue{Value: value})
+		}
+	default:
+		return nil, false
+	}
+
+	return facet, true
+}
+
+// addCount sets the count of the value, values of enum fields are already listed and values
+// no longer declared in the enum are skipped
+func (f *Facet) addCount(value string, count int) {
+	for _, v := range f.Values {
+		if v.Value == value {
+			v.Count = count
+			return
+		}
+	}
+
+	if !f.enum {
+		f.Values = append(f.Values, &FacetValue{Value: value, Count: count})
+	}
 }
 
 func validateProduct(v *validator.Validator, product *Product) {
 	v.Check(validator.In(product.Currency, "TJS", "RUB", "USD"), "currency", "must be TJS, RUB, or USD")
+	v.Check(product.Price.Cmp(money.FromMajor(1, product.Currency)) >= 0, "price", "must be at least 1")
+	v.Check(product.Price.FitsIn(product.Currency), "price", fmt.Sprintf("must not have more than %d decimal places", money.Exponent(product.Currency)))
+
+	languages := make([]string, 0, len(product.Translations))
+	for i, translation := range product.Translations {
+		tv := validator.New()
+		if product_translation.ValidateTranslation(tv, translation); !tv.Valid() {
+			v.AddError(fmt.Sprintf("translations[%d]", i), tv.Errors.Error())
+		}
+		languages = append(languages, translation.Language)
+	}
+	v.Check(validator.Unique(languages), "translations", "must contain at most one translation per language")
 }
 
 // Repository Errors
@@ -29,16 +207,21 @@ var (
 	ErrInvalidForeignKey = errors.New("category_id or user_id does not exist")
 	ErrConnectionFailed  = errors.New("database connection failed")
 	ErrProductNotFound   = errors.New("product not found")
+	ErrVersionMismatch   = errors.New("product was changed since it was read")
+	ErrDeletedSKU        = errors.New("product with the external sku was deleted")
 )
 
 // Service Errors
 var (
 	ErrProductValidationFailed = errors.New("product validation failed")
+	ErrRateNotFound            = errors.New("no exchange rate of the display currency is loaded yet")
 )
 
 // Handler Errors
 var (
-	ErrBindJSON    = errors.New("failed binding json")
-	ErrInvalidID   = errors.New("invalid category id was sent")
-	ErrFailedQuery = errors.New("failed to parse query")
+	ErrBindJSON      = errors.New("failed binding json")
+	ErrInvalidID     = errors.New("invalid category id was sent")
+	ErrFailedQuery   = errors.New("failed to parse query")
+	ErrAdminOnly     = errors.New("restoring is available to admins only")
+	ErrModeratorOnly = errors.New("publishing and rejecting is available to admins only")
 )
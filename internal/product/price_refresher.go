@@ -0,0 +1,54 @@
+package product
+
+import (
+	"context"
+	"ngMarketplace/pkg/logger"
+	"time"
+)
+
+// DuePriceRefresher refreshes stored prices of products whose discounts started or ended or whose rates took effect
+type DuePriceRefresher interface {
+	RefreshDuePrices(ctx context.Context) error
+}
+
+// PriceRefresher refreshes due prices every interval until ctx is done, it is meant to be started
+// with async.BackgroundRunner. Triggers refresh prices when rows change, while discount windows
+// and rates effective in the future pass without any change
+type PriceRefresher struct {
+	repository DuePriceRefresher
+	interval   time.Duration
+	logger     logger.Logger
+}
+
+func NewPriceRefresher(repository DuePriceRefresher, interval time.Duration, logger logger.Logger) *PriceRefresher {
+	return &PriceRefresher{
+		repository: repository,
+		interval:   interval,
+		logger:     logger,
+	}
+}
+
+// Run refreshes due prices right away and then every interval, a failed refresh is only logged and
+// retried on the next tick, since every refresh covers the time since the last successful one
+func (r *PriceRefresher) Run(ctx context.Context) {
+	const op = "PriceRefresher.Run"
+
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.repository.RefreshDuePrices(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("%s: %v", op, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
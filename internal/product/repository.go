@@ -5,44 +5,195 @@ import (
 	"errors"
 	"fmt"
 	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/price_history"
+	"ngMarketplace/internal/product_translation"
+	"ngMarketplace/pkg/money"
 	"ngMarketplace/pkg/postgres"
+	"strings"
 	"time"
 )
 
+// TranslationWriter creates and replaces product translations within the product transaction
+type TranslationWriter interface {
+	CreateTx(ctx context.Context, tx postgres.Tx, translation *product_translation.Translation) error
+	UpsertTx(ctx context.Context, tx postgres.Tx, translation *product_translation.Translation) (bool, error)
+}
+
+// PriceHistoryWriter records price changes of products within the product transaction
+type PriceHistoryWriter interface {
+	RecordTx(ctx context.Context, tx postgres.Tx, entry *price_history.Entry) error
+}
+
 type Repository struct {
-	client *postgres.Postgres
+	client       *postgres.Postgres
+	translations TranslationWriter
+	priceHistory PriceHistoryWriter
 }
 
-func NewRepository(client *postgres.Postgres) *Repository {
-	return &Repository{client: client}
+func NewRepository(client *postgres.Postgres, translations TranslationWriter, priceHistory PriceHistoryWriter) *Repository {
+	return &Repository{client: client, translations: translations, priceHistory: priceHistory}
 }
 
-// Create method creates a new product in db
+// Create method creates a new product in db as a draft, its initial price and status are recorded to the histories
+// and initial translations of the product are created in the same transaction
 func (r *Repository) Create(ctx context.Context, product *Product) error {
 	const op = "Create"
 
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		return r.createTx(ctx, tx, product)
+	})
+}
+
+func (r *Repository) createTx(ctx context.Context, tx postgres.Tx, product *Product) error {
+	if err := r.create(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.recordPrice(ctx, tx, product); err != nil {
+		return err
+	}
+
+	if err := r.recordStatus(ctx, tx, &StatusChange{
+		ProductID: product.ProductID,
+		ToStatus:  product.Status,
+		UserID:    product.UserID,
+	}); err != nil {
+		return err
+	}
+
+	for _, translation := range product.Translations {
+		translation.ProductID = product.ProductID
+		if err := r.translations.CreateTx(ctx, tx, translation); err != nil {
+			return err
+		}
+	}
+
+	// category and seller discounts apply to new products as well
+	return r.loadEffectivePrice(ctx, tx, product)
+}
+
+// UpsertBySKU method creates the product of the seller as Create does or updates the product imported with the sku before,
+// it reports whether the product was created. A product is updated as Update does, so product.Version other than 0
+// must match the stored one, and it is only written when its fields or translations change. A changed published
+// product is sent back to review, as it has to be moderated again. ErrDeletedSKU is returned when the product
+// with the sku is soft deleted
+func (r *Repository) UpsertBySKU(ctx context.Context, sku string, product *Product) (bool, error) {
+	const op = "UpsertBySKU"
+
+	product.ExternalSKU = &sku
+	created := false
+
+	err := r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		query := `
+			SELECT
+			    product_id, price, currency, category_id, status, active, created_at, updated_at, version
+			FROM
+			    products
+			WHERE
+			    user_id = $1
+			AND
+			    external_sku = $2
+			FOR UPDATE`
+
+		var stored Product
+
+		err := tx.QueryRow(ctx, query, product.UserID, sku).Scan(
+			&stored.ProductID,
+			&stored.Price,
+			&stored.Currency,
+			&stored.CategoryID,
+			&stored.Status,
+			&stored.Active,
+			&stored.CreatedAt,
+			&stored.UpdatedAt,
+			&stored.Version,
+		)
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			created = true
+			return r.createTx(ctx, tx, product)
+		case err != nil:
+			return postgres.ErrDoQuery(op, err)
+		case !stored.Active:
+			return ErrDeletedSKU
+		case product.Version != 0 && product.Version != stored.Version:
+			return ErrVersionMismatch
+		}
+
+		product.ProductID = stored.ProductID
+		product.Status = stored.Status
+		product.Active = stored.Active
+		product.CreatedAt = stored.CreatedAt
+		product.UpdatedAt = stored.UpdatedAt
+		product.Version = stored.Version
+
+		changed := product.Price.Cmp(stored.Price) != 0 || product.Currency != stored.Currency ||
+			product.CategoryID != stored.CategoryID
+
+		for _, translation := range product.Translations {
+			translation.ProductID = product.ProductID
+			written, err := r.translations.UpsertTx(ctx, tx, translation)
+			if err != nil {
+				return err
+			}
+			changed = changed || written
+		}
+
+		if !changed {
+			return r.loadEffectivePrice(ctx, tx, product)
+		}
+
+		if err = r.updateTx(ctx, tx, product); err != nil {
+			return err
+		}
+
+		if product.Status == StatusPublished {
+			from := product.Status
+			if err = r.changeStatusTx(ctx, tx, product, &StatusChange{
+				ProductID:  product.ProductID,
+				FromStatus: &from,
+				ToStatus:   StatusPendingReview,
+				UserID:     product.UserID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return r.loadEffectivePrice(ctx, tx, product)
+	})
+
+	return created, err
+}
+
+func (r *Repository) create(ctx context.Context, executor postgres.Executor, product *Product) error {
+	const op = "Create"
+
 	query := `
 		INSERT INTO 
-		    products (price, currency, category_id, user_id)
+		    products (price, currency, category_id, user_id, external_sku)
 		VALUES 
-		    ($1, $2, $3, $4)
-		RETURNING product_id, created_at, active`
+		    ($1, $2, $3, $4, $5)
+		RETURNING product_id, status, created_at, updated_at, active, version`
 
 	args := []interface{}{
 		product.Price,
 		product.Currency,
 		product.CategoryID,
 		product.UserID,
+		product.ExternalSKU,
 	}
 
-	if err := r.client.Pool.QueryRow(
+	if err := executor.QueryRow(
 		ctx,
 		query,
 		args...,
 	).Scan(
 		&product.ProductID,
+		&product.Status,
 		&product.CreatedAt,
+		&product.UpdatedAt,
 		&product.Active,
+		&product.Version,
 	); err != nil {
 		if postgres.IsPgErr(err) {
 			err = postgres.Conv2CustomErr(err)
@@ -51,6 +202,8 @@ func (r *Repository) Create(ctx context.Context, product *Product) error {
 		var pgErr *postgres.PostgresErr
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
+			case "23505":
+				return postgres.ErrDoQuery(op, ErrDuplicateProduct)
 			case "23503":
 				return postgres.ErrDoQuery(op, ErrInvalidForeignKey)
 			case "08000", "08001", "08003", "08006":
@@ -65,37 +218,48 @@ func (r *Repository) Create(ctx context.Context, product *Product) error {
 	return nil
 }
 
-// GetByID method gets a product by ID
+// GetByID method gets an active product by ID in any status, it is used by writes and checks of products
 func (r *Repository) GetByID(ctx context.Context, id int64) (*Product, error) {
-	const op = "GetByID"
+	return r.GetVisible(ctx, id, common.Visibility{Scope: common.VisibilityAdmin, State: common.StateActive})
+}
 
-	query := `
+// GetVisible method gets a product by ID if it is visible in the scope
+func (r *Repository) GetVisible(ctx context.Context, id int64, visibility common.Visibility) (*Product, error) {
+	const op = "GetVisible"
+
+	visibilityCond, visibilityArgs := visibilityCondition(visibility, "products", 2)
+
+	query := fmt.Sprintf(`
 		SELECT 
-		    product_id, price, currency, category_id, user_id, created_at, active, updated_at, deleted_at
+		    product_id, price, effective_price, currency, status, category_id, user_id,
+		    created_at, active, updated_at, deleted_at, version
 		FROM 
-		    products
+		    %s products
 		WHERE 
-		    active = true 
+		    %s 
 		AND 
 			product_id = $1
-		LIMIT 1`
+		LIMIT 1`, pricedProducts, visibilityCond)
 
 	var product Product
 
 	if err := r.client.Pool.QueryRow(
 		ctx,
 		query,
-		id,
+		append([]interface{}{id}, visibilityArgs...)...,
 	).Scan(
 		&product.ProductID,
 		&product.Price,
+		&product.EffectivePrice,
 		&product.Currency,
+		&product.Status,
 		&product.CategoryID,
 		&product.UserID,
 		&product.CreatedAt,
 		&product.Active,
 		&product.UpdatedAt,
 		&product.DeletedAt,
+		&product.Version,
 	); err != nil {
 		if errors.Is(err, postgres.ErrNoRows) {
 			return nil, ErrProductNotFound
@@ -103,14 +267,127 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Product, error) {
 		return nil, postgres.ErrDoQuery(op, err)
 	}
 
+	product.setCurrencies()
+
 	return &product, nil
 }
 
-// Update method updates product
+// GetVisibleByIDs method gets products visible in the scope by ids at once in no particular order, ids of invisible
+// products are skipped. When displayCurrency is not empty prices are also returned converted to it
+func (r *Repository) GetVisibleByIDs(ctx context.Context, ids []int, displayCurrency string, visibility common.Visibility) ([]*Product, error) {
+	const op = "GetVisibleByIDs"
+
+	visibilityCond, visibilityArgs := visibilityCondition(visibility, "products", 3)
+
+	query := fmt.Sprintf(`
+		SELECT 
+		    product_id, price, effective_price, currency, status, category_id, user_id,
+		    created_at, active, updated_at, deleted_at, version,
+		    convert_price(price, currency, nullif($2, '')), convert_price(effective_price, currency, nullif($2, ''))
+		FROM 
+		    %s products
+		WHERE 
+		    %s 
+		AND 
+			product_id = ANY($1::int[])`, pricedProducts, visibilityCond)
+
+	args := append([]interface{}{ids, displayCurrency}, visibilityArgs...)
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	products := []*Product{}
+
+	for rows.Next() {
+		var product Product
+		err = rows.Scan(
+			&product.ProductID,
+			&product.Price,
+			&product.EffectivePrice,
+			&product.Currency,
+			&product.Status,
+			&product.CategoryID,
+			&product.UserID,
+			&product.CreatedAt,
+			&product.Active,
+			&product.UpdatedAt,
+			&product.DeletedAt,
+			&product.Version,
+			&product.DisplayPrice,
+			&product.DisplayEffectivePrice,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		if product.DisplayPrice != nil {
+			product.DisplayCurrency = displayCurrency
+		}
+		product.setCurrencies()
+
+		products = append(products, &product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return products, nil
+}
+
+// Update method updates product of the version it was read in, a change of price or currency is recorded
+// to the price history in the same transaction
 func (r *Repository) Update(ctx context.Context, product *Product) error {
 	const op = "Update"
 
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		if err := r.updateTx(ctx, tx, product); err != nil {
+			return err
+		}
+
+		return r.loadEffectivePrice(ctx, tx, product)
+	})
+}
+
+// updateTx writes price, currency and category of the active product of product.Version within tx,
+// a changed price is recorded to the price history
+func (r *Repository) updateTx(ctx context.Context, tx postgres.Tx, product *Product) error {
+	const op = "Update"
+
 	query := `
+		SELECT
+		    price, currency, version
+		FROM
+		    products
+		WHERE
+		    product_id = $1
+		AND
+		    active = true
+		FOR UPDATE`
+
+	var (
+		oldPrice    money.Money
+		oldCurrency string
+		version     int
+	)
+
+	if err := tx.QueryRow(ctx, query, product.ProductID).Scan(&oldPrice, &oldCurrency, &version); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrProductNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	if version != product.Version {
+		return ErrVersionMismatch
+	}
+
+	query = `
 		UPDATE 
 		    products
 		SET 
@@ -121,7 +398,7 @@ func (r *Repository) Update(ctx context.Context, product *Product) error {
 		    product_id = $4
 		AND 
 		    active = true
-		RETURNING updated_at`
+		RETURNING updated_at, version`
 
 	args := []interface{}{
 		product.Price,
@@ -130,131 +407,927 @@ func (r *Repository) Update(ctx context.Context, product *Product) error {
 		product.ProductID,
 	}
 
-	if err := r.client.Pool.QueryRow(
+	if err := tx.QueryRow(
 		ctx,
 		query,
 		args...,
-	).Scan(&product.UpdatedAt); err != nil {
+	).Scan(&product.UpdatedAt, &product.Version); err != nil {
+		if postgres.IsPgErr(err) {
+			err = postgres.Conv2CustomErr(err)
+		}
+
+		var pgErr *postgres.PostgresErr
 		switch {
 		case errors.Is(err, postgres.ErrNoRows):
 			return ErrProductNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			return postgres.ErrDoQuery(op, ErrInvalidForeignKey)
 		default:
 			return postgres.ErrDoQuery(op, err)
 		}
 	}
 
+	if product.Price.Cmp(oldPrice) != 0 || product.Currency != oldCurrency {
+		if err := r.recordPrice(ctx, tx, product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RefreshDuePrices method refreshes stored prices of products whose discounts started or ended or whose rates
+// took effect since the previous refresh
+func (r *Repository) RefreshDuePrices(ctx context.Context) error {
+	const op = "RefreshDuePrices"
+
+	if _, err := r.client.Pool.Exec(ctx, `SELECT refresh_due_product_prices()`); err != nil {
+		return postgres.ErrExec(op, err)
+	}
+
 	return nil
 }
 
-// SoftDelete method deletes product softly, meaning that it makes active false and that's it
-func (r *Repository) SoftDelete(ctx context.Context, id int64) error {
+// loadEffectivePrice reads the discounted price of the product, it is called after the product is written,
+// since discounts depend on its category, seller and currency. The price is stored by the trigger of the write
+func (r *Repository) loadEffectivePrice(ctx context.Context, executor postgres.Executor, product *Product) error {
+	const op = "loadEffectivePrice"
+
+	query := `SELECT effective_price FROM product_prices WHERE product_id = $1`
+
+	if err := executor.QueryRow(ctx, query, product.ProductID).Scan(&product.EffectivePrice); err != nil {
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	product.EffectivePrice = product.EffectivePrice.RoundTo(product.Currency)
+
+	return nil
+}
+
+func (r *Repository) recordPrice(ctx context.Context, tx postgres.Tx, product *Product) error {
+	return r.priceHistory.RecordTx(ctx, tx, &price_history.Entry{
+		ProductID: product.ProductID,
+		Price:     product.Price,
+		Currency:  product.Currency,
+	})
+}
+
+// ChangeStatus method moves an active product from change.FromStatus to change.ToStatus and records
+// the change to the status history in one transaction. ErrStatusChanged is returned when the product
+// is no longer in FromStatus. A product leaving review is decided by change.UserID, so an unexpired claim
+// of another moderator makes ErrClaimedByOther, and the claim is released in the same transaction
+func (r *Repository) ChangeStatus(ctx context.Context, product *Product, change *StatusChange) error {
+	const op = "ChangeStatus"
+
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		return r.changeStatusTx(ctx, tx, product, change)
+	})
+}
+
+// changeStatusTx moves the product as ChangeStatus does within tx
+func (r *Repository) changeStatusTx(ctx context.Context, tx postgres.Tx, product *Product, change *StatusChange) error {
+	const op = "ChangeStatus"
+
+	if change.FromStatus != nil && *change.FromStatus == StatusPendingReview {
+		if err := r.releaseClaim(ctx, tx, change); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE
+		    products
+		SET
+		    status = $1
+		WHERE
+		    product_id = $2
+		AND
+		    status = $3
+		AND
+		    active = true
+		RETURNING updated_at, version`
+
+	args := []interface{}{
+		change.ToStatus,
+		change.ProductID,
+		change.FromStatus,
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(&product.UpdatedAt, &product.Version); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrStatusChanged
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	if err := r.recordStatus(ctx, tx, change); err != nil {
+		return err
+	}
+
+	product.Status = change.ToStatus
+
+	return nil
+}
+
+// releaseClaim removes the review claim of the product, the claim row is locked first, so a moderator
+// cannot claim the product while it is being decided
+func (r *Repository) releaseClaim(ctx context.Context, tx postgres.Tx, change *StatusChange) error {
+	const op = "releaseClaim"
+
+	query := `
+		SELECT
+		    moderator_id
+		FROM
+		    moderation_claims
+		WHERE
+		    product_id = $1
+		AND
+		    expires_at > now()
+		FOR UPDATE`
+
+	var moderatorID int
+
+	err := tx.QueryRow(ctx, query, change.ProductID).Scan(&moderatorID)
+	switch {
+	case errors.Is(err, postgres.ErrNoRows):
+	case err != nil:
+		return postgres.ErrDoQuery(op, err)
+	case moderatorID != change.UserID:
+		return ErrClaimedByOther
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM moderation_claims WHERE product_id = $1`, change.ProductID); err != nil {
+		return postgres.ErrExec(op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) recordStatus(ctx context.Context, tx postgres.Tx, change *StatusChange) error {
+	const op = "recordStatus"
+
+	query := `
+		INSERT INTO
+		    product_status_history (product_id, from_status, to_status, reason_code, reason, user_id)
+		VALUES
+		    ($1, $2, $3, $4, $5, $6)
+		RETURNING history_id, changed_at`
+
+	args := []interface{}{
+		change.ProductID,
+		change.FromStatus,
+		change.ToStatus,
+		change.ReasonCode,
+		change.Reason,
+		change.UserID,
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&change.HistoryID,
+		&change.ChangedAt,
+	); err != nil {
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	return nil
+}
+
+// GetStatusHistory method returns status changes of an active product from the oldest to the newest
+func (r *Repository) GetStatusHistory(ctx context.Context, productID int64) ([]*StatusChange, error) {
+	const op = "GetStatusHistory"
+
+	query := `
+		SELECT
+		    h.history_id, h.product_id, h.from_status, h.to_status, h.reason_code, h.reason, h.user_id, h.changed_at
+		FROM
+		    product_status_history h
+		JOIN
+		    products p ON p.product_id = h.product_id
+		WHERE
+		    p.active = true
+		AND
+		    h.product_id = $1
+		ORDER BY
+		    h.changed_at, h.history_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	changes := []*StatusChange{}
+
+	for rows.Next() {
+		var change StatusChange
+		err = rows.Scan(
+			&change.HistoryID,
+			&change.ProductID,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.ReasonCode,
+			&change.Reason,
+			&change.UserID,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		changes = append(changes, &change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return changes, nil
+}
+
+// GetSellers method returns sellers of several products at once, soft deleted products are not counted as published
+func (r *Repository) GetSellers(ctx context.Context, userIDs []int) ([]*Seller, error) {
+	const op = "GetSellers"
+
+	query := `
+		SELECT
+		    user_id, count(*) FILTER (WHERE status = 'published' AND active = true)
+		FROM
+		    products
+		WHERE
+		    user_id = ANY($1::int[])
+		GROUP BY
+		    user_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	sellers := []*Seller{}
+
+	for rows.Next() {
+		var seller Seller
+		if err = rows.Scan(&seller.UserID, &seller.PublishedProducts); err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		sellers = append(sellers, &seller)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return sellers, nil
+}
+
+// SoftDelete method deletes product softly, meaning that it makes active false and that's it. The product
+// must be of the version, 0 deletes any version
+func (r *Repository) SoftDelete(ctx context.Context, id int64, version int) error {
 	const op = "Delete"
 
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		query := `SELECT version FROM products WHERE product_id = $1 AND active = true FOR UPDATE`
+
+		var current int
+		if err := tx.QueryRow(ctx, query, id).Scan(&current); err != nil {
+			switch {
+			case errors.Is(err, postgres.ErrNoRows):
+				return ErrProductNotFound
+			default:
+				return postgres.ErrDoQuery(op, err)
+			}
+		}
+
+		if version != 0 && version != current {
+			return ErrVersionMismatch
+		}
+
+		query = `
+			UPDATE 
+			    products
+			SET 
+			    deleted_at = now(), 
+			    active = false
+			WHERE 
+			    product_id = $1`
+
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		return nil
+	})
+}
+
+// Restore method restores a soft deleted product
+func (r *Repository) Restore(ctx context.Context, id int64) error {
+	const op = "Restore"
+
 	query := `
 		UPDATE 
 		    products
 		SET 
-		    deleted_at = now(), 
-		    active = false
+		    deleted_at = NULL, 
+		    active = true
 		WHERE 
 		    product_id = $1 
 		AND 
-		    active = true
-		RETURNING deleted_at`
+		    deleted_at IS NOT NULL`
 
-	var deletedAt *time.Time
-	err := r.client.Pool.QueryRow(ctx, query, id).Scan(&deletedAt)
+	result, err := r.client.Pool.Exec(ctx, query, id)
 	if err != nil {
-		switch {
-		case errors.Is(err, postgres.ErrNoRows):
-			return ErrProductNotFound
-		default:
-			return postgres.ErrDoQuery(op, err)
-		}
+		return postgres.ErrExec(op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrProductNotFound
 	}
 
 	return nil
 }
 
-// GetPaginated method returns the list of products and total data for metadata
+// visibilityCondition builds the condition on products aliased as alias for the visibility numbering its argument
+// from param, the public sees published products only and owners also see their own ones in any status
+func visibilityCondition(visibility common.Visibility, alias string, param int) (string, []interface{}) {
+	condition := visibility.StateCondition(alias)
+
+	switch visibility.Scope {
+	case common.VisibilityPublic:
+		return fmt.Sprintf("%s AND %s.status = 'published'", condition, alias), nil
+	case common.VisibilityOwner:
+		return fmt.Sprintf("%s AND (%s.status = 'published' OR %s.user_id = $%d)", condition, alias, alias, param),
+			[]interface{}{visibility.OwnerID}
+	default:
+		return condition, nil
+	}
+}
+
+// pricedProducts adds to products their discounted price and both prices converted to the base currency,
+// so products listed in different currencies can be filtered and sorted together. Prices are stored
+// in product_prices and kept fresh by triggers and RefreshDuePrices
+const pricedProducts = `
+	(SELECT
+	    p.*, pp.effective_price, pp.base_price, pp.base_effective_price
+	FROM
+	    products p
+	JOIN
+	    product_prices pp ON pp.product_id = p.product_id)`
+
+// sortKeys maps sort columns to expressions products are ordered by and their SQL types, prices are compared
+// converted to the base currency. Expressions are formatted with the alias of products
+var sortKeys = map[string][2]string{
+	"product_id":      {"%sproduct_id", "integer"},
+	"price":           {"%sbase_price", "numeric"},
+	"effective_price": {"%sbase_effective_price", "numeric"},
+	"created_at":      {"%screated_at", "timestamptz"},
+	"updated_at":      {"%supdated_at", "timestamptz"},
+}
+
+// sortKeyConditions are conditions rows must meet to be ordered by the sort column. Products with no base price,
+// whose currency has no rate yet, cannot be placed among the others and are left out of listings sorted by price
+var sortKeyConditions = map[string]string{
+	"price":           "%sbase_price IS NOT NULL",
+	"effective_price": "%sbase_effective_price IS NOT NULL",
+}
+
+// sortKey returns the expression of the sort key for products aliased as alias, its type and the condition
+// of rows having it
+func sortKey(filters common.Filters, alias string) (string, string, string) {
+	key := sortKeys[filters.SortColumn()]
+	if alias != "" {
+		alias += "."
+	}
+
+	condition := "true"
+	if format, ok := sortKeyConditions[filters.SortColumn()]; ok {
+		condition = fmt.Sprintf(format, alias)
+	}
+
+	return fmt.Sprintf(key[0], alias), key[1], condition
+}
+
+// sortKeyType returns the SQL type of the sort key of the sort, the rank of search results is real
+func sortKeyType(sort string) string {
+	column := strings.TrimPrefix(sort, "-")
+	if column == "rank" {
+		return "real"
+	}
+
+	return sortKeys[column][1]
+}
+
+// GetPaginated method returns the list of products visible in the scope and metadata. Price bounds are given
+// in displayCurrency (the base currency when it is empty) and compared with prices of all currencies converted
+// to the base one, with byEffectivePrice the discounted price is compared. createdAfter and createdBefore bound
+// the creation time when they are not nil. When displayCurrency is not empty prices are also returned converted to it,
+// its rate is checked by the service. Products whose own currency has no rate yet have no base price, so they are
+// left out when price bounds are given or products are sorted by price, and their display prices are empty.
+// Products are paginated by the cursor of filters when it is given
 func (r *Repository) GetPaginated(
 	ctx context.Context,
 	currency string,
-	categoryID int,
+	categoryIDs []int,
 	userID int,
-	fromPrice float64,
-	toPrice float64,
+	fromPrice money.Money,
+	toPrice money.Money,
+	byEffectivePrice bool,
+	displayCurrency string,
+	createdAfter *time.Time,
+	createdBefore *time.Time,
+	attributes []attributeFilter,
+	visibility common.Visibility,
 	filters common.Filters,
 ) (
 	[]*Product,
-	int,
+	common.Metadata,
 	error,
 ) {
 	const op = "GetPaginated"
 
+	key, keyType, keyCondition := sortKey(filters, "")
+	keyset := filters.Keyset(key, keyType, "product_id", 12)
+	visibilityCond, visibilityArgs := visibilityCondition(visibility, "products", 12+len(keyset.Args))
+	attributesCondition, attributesArgs := attributeConditions(attributes, "products", 12+len(keyset.Args)+len(visibilityArgs))
+
 	query := fmt.Sprintf(`
 		SELECT 
-		    count(*) OVER(), product_id, price, currency, category_id, user_id, created_at, active, updated_at, deleted_at
+		    %[2]s, product_id, price, effective_price, currency, status, category_id, user_id,
+		    created_at, active, updated_at, deleted_at,
+		    convert_price(price, currency, nullif($9, '')), convert_price(effective_price, currency, nullif($9, '')),
+		    (%[3]s)::text
 		FROM 
-		    products
+		    %[1]s products
 		WHERE 
+		    %[7]s
+		AND
 		    (currency = $1 OR $1 = '')
 		AND
-		    (category_id = $2 OR $2 = 0)
+		    (category_id = ANY($2::int[]) OR coalesce(cardinality($2::int[]), 0) = 0)
 		AND
 		    (user_id = $3 OR $3 = 0)
 		AND 
-		    (price >= $4 OR $4 = 0)
+		    (CASE WHEN $6 THEN base_effective_price ELSE base_price END >=
+		        $4 * CASE WHEN $9 = '' THEN 1 ELSE exchange_rate($9) END OR $4 = 0)
 		AND 
-		    (price <= $5 OR $5 = 0)
+		    (CASE WHEN $6 THEN base_effective_price ELSE base_price END <=
+		        $5 * CASE WHEN $9 = '' THEN 1 ELSE exchange_rate($9) END OR $5 = 0)
+		AND
+		    (created_at > $10::timestamptz OR $10::timestamptz IS NULL)
+		AND
+		    (created_at < $11::timestamptz OR $11::timestamptz IS NULL)
+		AND
+		    %[4]s
+		AND
+		    %[5]s
+		AND
+		    %[8]s
 		ORDER BY
-		    %s %s, category_id ASC
-		LIMIT $6 
-		OFFSET $7`, filters.SortColumn(), filters.SortDirection())
+		    %[3]s %[6]s, product_id %[6]s
+		LIMIT $7 
+		OFFSET $8`, pricedProducts, keyset.Count, key, keyset.Condition, attributesCondition, keyset.Direction, visibilityCond, keyCondition)
 
 	args := []interface{}{
 		currency,
-		categoryID,
+		categoryIDs,
 		userID,
 		fromPrice,
 		toPrice,
-		filters.Limit(),
-		filters.Offset(),
+		byEffectivePrice,
+		filters.FetchLimit(),
+		keyset.Offset,
+		displayCurrency,
+		createdAfter,
+		createdBefore,
 	}
+	args = append(args, keyset.Args...)
+	args = append(args, visibilityArgs...)
+	args = append(args, attributesArgs...)
 
 	rows, err := r.client.Pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, postgres.ErrDoQuery(op, err)
+		return nil, common.Metadata{}, postgres.ErrDoQuery(op, err)
 	}
 	defer rows.Close()
 
 	totalRecords := 0
 	products := []*Product{}
+	keys := []common.Key{}
 
 	for rows.Next() {
-		var product Product
+		var (
+			product Product
+			key     common.Key
+		)
 		err = rows.Scan(
 			&totalRecords,
 			&product.ProductID,
 			&product.Price,
+			&product.EffectivePrice,
 			&product.Currency,
+			&product.Status,
 			&product.CategoryID,
 			&product.UserID,
 			&product.CreatedAt,
 			&product.Active,
 			&product.UpdatedAt,
 			&product.DeletedAt,
+			&product.DisplayPrice,
+			&product.DisplayEffectivePrice,
+			&key.Value,
 		)
 		if err != nil {
-			return nil, 0, postgres.ErrScan(op, err)
+			return nil, common.Metadata{}, postgres.ErrScan(op, err)
 		}
 
+		if product.DisplayPrice != nil {
+			product.DisplayCurrency = displayCurrency
+		}
+		product.setCurrencies()
+
+		key.ID = int64(product.ProductID)
 		products = append(products, &product)
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, common.Metadata{}, postgres.ErrReadRows(op, err)
+	}
+
+	products, metadata := common.Paginate(filters, products, keys, totalRecords)
+
+	return products, metadata, nil
+}
+
+// searchConfigs maps translation language to the Postgres text search configuration,
+// Tajik has no built-in one, so it is searched with 'simple'
+var searchConfigs = map[string]string{
+	"ru": "russian",
+	"en": "english",
+	"tj": "simple",
+}
+
+// Search method finds products visible in the scope by the text of their translations in the language, texts are the variants
+// of one search query (e.g. its Cyrillic and Latin spellings) and a product matching any of them is found.
+// Results are ranked with ts_rank and carry a highlighted snippet. Prices are filtered and converted
+// and paginated the same way as in GetPaginated
+func (r *Repository) Search(
+	ctx context.Context,
+	texts []string,
+	language string,
+	currency string,
+	categoryIDs []int,
+	userID int,
+	fromPrice money.Money,
+	toPrice money.Money,
+	byEffectivePrice bool,
+	displayCurrency string,
+	createdAfter *time.Time,
+	createdBefore *time.Time,
+	attributes []attributeFilter,
+	visibility common.Visibility,
+	filters common.Filters,
+) (
+	[]*SearchResult,
+	common.Metadata,
+	error,
+) {
+	const op = "Search"
+
+	config, ok := searchConfigs[language]
+	if !ok {
+		config = "simple"
+	}
+
+	// the document expression must match the index definition, so config is inlined instead of being a parameter
+	document := fmt.Sprintf("to_tsvector('%s', t.product_name || ' ' || coalesce(t.product_description, ''))", config)
+
+	key, keyType, keyCondition := fmt.Sprintf("ts_rank(%s, q)", document), "real", "true"
+	if filters.SortColumn() != "rank" {
+		key, keyType, keyCondition = sortKey(filters, "p")
+	}
+
+	keyset := filters.Keyset(key, keyType, "p.product_id", 14)
+	visibilityCond, visibilityArgs := visibilityCondition(visibility, "p", 14+len(keyset.Args))
+	attributesCondition, attributesArgs := attributeConditions(attributes, "p", 14+len(keyset.Args)+len(visibilityArgs))
+
+	query := fmt.Sprintf(`
+		SELECT
+		    found.total, found.product_id, found.price, found.effective_price, found.currency, found.status,
+		    found.category_id, found.user_id,
+		    found.created_at, found.active, found.updated_at, found.deleted_at,
+		    found.translation_id, found.language, found.product_name, found.product_description, found.attributes,
+		    found.t_created_at, found.t_updated_at, found.rank,
+		    convert_price(found.price, found.currency, nullif($11, '')),
+		    convert_price(found.effective_price, found.currency, nullif($11, '')),
+		    ts_headline('%[1]s', found.product_name || ' ' || coalesce(found.product_description, ''), found.q,
+		        'MaxFragments=2, MaxWords=30, MinWords=10, StartSel=<b>, StopSel=</b>'),
+		    found.sort_key
+		FROM (
+		    SELECT
+		        %[8]s AS total, p.product_id, p.price, p.effective_price, p.base_price, p.base_effective_price,
+		        p.currency, p.status, p.category_id, p.user_id,
+		        p.created_at, p.active, p.updated_at, p.deleted_at,
+		        t.translation_id, t.language, t.product_name, t.product_description, t.attributes,
+		        t.created_at AS t_created_at, t.updated_at AS t_updated_at,
+		        ts_rank(%[2]s, q) AS rank, q, %[3]s AS sort_value, (%[3]s)::text AS sort_key
+		    FROM
+		        %[6]s p
+		    JOIN
+		        product_translations t ON t.product_id = p.product_id AND t.deleted_at IS NULL
+		    CROSS JOIN
+		        (SELECT %[5]s AS q) search_query
+		    WHERE
+		        t.language = $2
+		    AND
+		        %[2]s @@ q
+		    AND
+		        %[10]s
+		    AND
+		        (p.currency = $3 OR $3 = '')
+		    AND
+		        (p.category_id = ANY($4::int[]) OR coalesce(cardinality($4::int[]), 0) = 0)
+		    AND
+		        (p.user_id = $5 OR $5 = 0)
+		    AND
+		        (CASE WHEN $8 THEN p.base_effective_price ELSE p.base_price END >=
+		            $6 * CASE WHEN $11 = '' THEN 1 ELSE exchange_rate($11) END OR $6 = 0)
+		    AND
+		        (CASE WHEN $8 THEN p.base_effective_price ELSE p.base_price END <=
+		            $7 * CASE WHEN $11 = '' THEN 1 ELSE exchange_rate($11) END OR $7 = 0)
+		    AND
+		        (p.created_at > $12::timestamptz OR $12::timestamptz IS NULL)
+		    AND
+		        (p.created_at < $13::timestamptz OR $13::timestamptz IS NULL)
+		    AND
+		        %[7]s
+		    AND
+		        %[9]s
+		    AND
+		        %[11]s
+		    ORDER BY
+		        sort_value %[4]s, product_id %[4]s
+		    LIMIT $9
+		    OFFSET $10
+		) found
+		ORDER BY
+		    found.sort_value %[4]s, found.product_id %[4]s`,
+		config,
+		document,
+		key,
+		keyset.Direction,
+		common.AnyTSQuery("websearch_to_tsquery", config, 1, len(texts)),
+		pricedProducts,
+		keyset.Condition,
+		keyset.Count,
+		attributesCondition,
+		visibilityCond,
+		keyCondition,
+	)
+
+	args := []interface{}{
+		texts,
+		language,
+		currency,
+		categoryIDs,
+		userID,
+		fromPrice,
+		toPrice,
+		byEffectivePrice,
+		filters.FetchLimit(),
+		keyset.Offset,
+		displayCurrency,
+		createdAfter,
+		createdBefore,
+	}
+	args = append(args, keyset.Args...)
+	args = append(args, visibilityArgs...)
+	args = append(args, attributesArgs...)
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, common.Metadata{}, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	results := []*SearchResult{}
+	keys := []common.Key{}
+
+	for rows.Next() {
+		product := Product{Translation: &product_translation.Translation{}}
+		result := SearchResult{Product: &product}
+		var key common.Key
+
+		err = rows.Scan(
+			&totalRecords,
+			&product.ProductID,
+			&product.Price,
+			&product.EffectivePrice,
+			&product.Currency,
+			&product.Status,
+			&product.CategoryID,
+			&product.UserID,
+			&product.CreatedAt,
+			&product.Active,
+			&product.UpdatedAt,
+			&product.DeletedAt,
+			&product.Translation.TranslationID,
+			&product.Translation.Language,
+			&product.Translation.ProductName,
+			&product.Translation.ProductDescription,
+			&product.Translation.Attributes,
+			&product.Translation.CreatedAt,
+			&product.Translation.UpdatedAt,
+			&result.Rank,
+			&product.DisplayPrice,
+			&product.DisplayEffectivePrice,
+			&result.Headline,
+			&key.Value,
+		)
+		if err != nil {
+			return nil, common.Metadata{}, postgres.ErrScan(op, err)
+		}
+
+		if product.DisplayPrice != nil {
+			product.DisplayCurrency = displayCurrency
+		}
+		product.setCurrencies()
+
+		product.Translation.ProductID = product.ProductID
+		key.ID = int64(product.ProductID)
+		results = append(results, &result)
+		keys = append(keys, key)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, 0, postgres.ErrReadRows(op, err)
+		return nil, common.Metadata{}, postgres.ErrReadRows(op, err)
+	}
+
+	results, metadata := common.Paginate(filters, results, keys, totalRecords)
+
+	return results, metadata, nil
+}
+
+// GetFacets method fills facets with aggregates over products visible in the scope and matching the filters, the same ones
+// as in GetPaginated. Every facet is computed without the filter on its own attribute, so the counts show
+// how many products the client would get by choosing another value
+func (r *Repository) GetFacets(
+	ctx context.Context,
+	facets []*Facet,
+	currency string,
+	categoryIDs []int,
+	userID int,
+	fromPrice money.Money,
+	toPrice money.Money,
+	byEffectivePrice bool,
+	displayCurrency string,
+	createdAfter *time.Time,
+	createdBefore *time.Time,
+	attributes []attributeFilter,
+	visibility common.Visibility,
+) error {
+	const op = "GetFacets"
+
+	if len(facets) == 0 {
+		return nil
+	}
+
+	args := []interface{}{
+		currency,
+		categoryIDs,
+		userID,
+		fromPrice,
+		toPrice,
+		byEffectivePrice,
+		displayCurrency,
+		createdAfter,
+		createdBefore,
 	}
 
-	return products, totalRecords, nil
+	visibilityCond, visibilityArgs := visibilityCondition(visibility, "products", len(args)+1)
+	args = append(args, visibilityArgs...)
+
+	byName := make(map[string]*Facet, len(facets))
+	selects := make([]string, 0, len(facets))
+
+	for _, facet := range facets {
+		byName[facet.Name] = facet
+
+		others := make([]attributeFilter, 0, len(attributes))
+		for _, filter := range attributes {
+			if filter.Name != facet.Name {
+				others = append(others, filter)
+			}
+		}
+
+		args = append(args, facet.Name)
+		name := len(args)
+
+		condition, conditionArgs := attributeConditions(others, "products", len(args)+1)
+		args = append(args, conditionArgs...)
+
+		if facet.Values != nil {
+			selects = append(selects, fmt.Sprintf(`
+			SELECT
+			    $%[1]d::text, t.attributes->>$%[1]d::text, count(DISTINCT products.product_id), NULL::float8, NULL::float8
+			FROM
+			    matched products
+			JOIN
+			    product_translations t ON t.product_id = products.product_id AND t.deleted_at IS NULL
+			WHERE
+			    jsonb_typeof(t.attributes->$%[1]d::text) = 'string'
+			AND
+			    %[2]s
+			GROUP BY
+			    2`, name, condition))
+			continue
+		}
+
+		selects = append(selects, fmt.Sprintf(`
+			SELECT
+			    $%[1]d::text, NULL::text, count(DISTINCT products.product_id),
+			    min((t.attributes->>$%[1]d::text)::float8), max((t.attributes->>$%[1]d::text)::float8)
+			FROM
+			    matched products
+			JOIN
+			    product_translations t ON t.product_id = products.product_id AND t.deleted_at IS NULL
+			WHERE
+			    jsonb_typeof(t.attributes->$%[1]d::text) = 'number'
+			AND
+			    %[2]s`, name, condition))
+	}
+
+	query := fmt.Sprintf(`
+		WITH matched AS (
+		    SELECT
+		        product_id
+		    FROM
+		        %s products
+		    WHERE
+		        %s
+		    AND
+		        (currency = $1 OR $1 = '')
+		    AND
+		        (category_id = ANY($2::int[]) OR coalesce(cardinality($2::int[]), 0) = 0)
+		    AND
+		        (user_id = $3 OR $3 = 0)
+		    AND
+		        (CASE WHEN $6 THEN base_effective_price ELSE base_price END >=
+		            $4 * CASE WHEN $7 = '' THEN 1 ELSE exchange_rate($7) END OR $4 = 0)
+		    AND
+		        (CASE WHEN $6 THEN base_effective_price ELSE base_price END <=
+		            $5 * CASE WHEN $7 = '' THEN 1 ELSE exchange_rate($7) END OR $5 = 0)
+		    AND
+		        (created_at > $8::timestamptz OR $8::timestamptz IS NULL)
+		    AND
+		        (created_at < $9::timestamptz OR $9::timestamptz IS NULL)
+		)
+		%s
+		ORDER BY
+		    1, 3 DESC, 2`, pricedProducts, visibilityCond, strings.Join(selects, "\n\t\tUNION ALL"))
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			name    string
+			value   *string
+			count   int
+			lowest  *float64
+			highest *float64
+		)
+		if err = rows.Scan(&name, &value, &count, &lowest, &highest); err != nil {
+			return postgres.ErrScan(op, err)
+		}
+
+		facet := byName[name]
+		if value == nil {
+			facet.Min, facet.Max = lowest, highest
+			continue
+		}
+
+		facet.addCount(*value, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return postgres.ErrReadRows(op, err)
+	}
+
+	return nil
 }
@@ -2,25 +2,104 @@ package product
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"ngMarketplace/internal/category"
 	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/common/attribute_schema/parser"
+	"ngMarketplace/internal/common/attribute_schema/translit"
+	"ngMarketplace/internal/discount"
+	"ngMarketplace/internal/product_image"
+	"ngMarketplace/internal/product_translation"
+	"ngMarketplace/internal/product_variant"
+	"ngMarketplace/pkg/money"
 	"ngMarketplace/pkg/validator"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type Storage interface {
 	Create(ctx context.Context, product *Product) error
+	UpsertBySKU(ctx context.Context, sku string, product *Product) (bool, error)
 	GetByID(ctx context.Context, id int64) (*Product, error)
+	GetVisible(ctx context.Context, id int64, visibility common.Visibility) (*Product, error)
+	GetVisibleByIDs(ctx context.Context, ids []int, displayCurrency string, visibility common.Visibility) ([]*Product, error)
 	Update(ctx context.Context, product *Product) error
-	SoftDelete(ctx context.Context, id int64) error
-	GetPaginated(ctx context.Context, currency string, categoryID int, userID int, fromPrice float64, toPrice float64, filters common.Filters) ([]*Product, int, error)
+	SoftDelete(ctx context.Context, id int64, version int) error
+	Restore(ctx context.Context, id int64) error
+	ChangeStatus(ctx context.Context, product *Product, change *StatusChange) error
+	GetStatusHistory(ctx context.Context, productID int64) ([]*StatusChange, error)
+	GetSellers(ctx context.Context, userIDs []int) ([]*Seller, error)
+	GetPaginated(ctx context.Context, currency string, categoryIDs []int, userID int, fromPrice money.Money, toPrice money.Money, byEffectivePrice bool, displayCurrency string, createdAfter *time.Time, createdBefore *time.Time, attributes []attributeFilter, visibility common.Visibility, filters common.Filters) ([]*Product, common.Metadata, error)
+	GetFacets(ctx context.Context, facets []*Facet, currency string, categoryIDs []int, userID int, fromPrice money.Money, toPrice money.Money, byEffectivePrice bool, displayCurrency string, createdAfter *time.Time, createdBefore *time.Time, attributes []attributeFilter, visibility common.Visibility) error
+	Search(ctx context.Context, texts []string, language string, currency string, categoryIDs []int, userID int, fromPrice money.Money, toPrice money.Money, byEffectivePrice bool, displayCurrency string, createdAfter *time.Time, createdBefore *time.Time, attributes []attributeFilter, visibility common.Visibility, filters common.Filters) ([]*SearchResult, common.Metadata, error)
+}
+
+// TranslationStorage gives access to translations of products
+type TranslationStorage interface {
+	GetByLanguage(ctx context.Context, productID int64, language string) (*product_translation.Translation, error)
+	GetByProductIDs(ctx context.Context, productIDs []int) ([]*product_translation.Translation, error)
+}
+
+// VariantStorage gives access to variants of products
+type VariantStorage interface {
+	GetByProductID(ctx context.Context, productID int64) ([]*product_variant.Variant, error)
+}
+
+// DiscountStorage gives access to discounts applied to products
+type DiscountStorage interface {
+	GetApplied(ctx context.Context, productID int64, variantID *int64) ([]*discount.Discount, error)
+}
+
+// CategoryStorage gives access to categories of products, their attribute schemas and subcategories
+type CategoryStorage interface {
+	GetByID(ctx context.Context, id int64) (*category.Category, error)
+	GetSubtreeIDs(ctx context.Context, ids []int) ([]int, error)
+	GetByIDs(ctx context.Context, ids []int) ([]*category.Category, error)
+}
+
+// ImageStorage gives access to images of products with their URLs
+type ImageStorage interface {
+	GetImagesByProductIDs(ctx context.Context, productIDs []int) ([]*product_image.Image, error)
+}
+
+// RateStorage converts prices between currencies with the current exchange rates
+type RateStorage interface {
+	Convert(ctx context.Context, amount money.Money, to string) (*money.Money, error)
+	HasRate(ctx context.Context, currency string) (bool, error)
 }
 
 type Service struct {
-	Repository Storage
+	Repository   Storage
+	Translations TranslationStorage
+	Variants     VariantStorage
+	Discounts    DiscountStorage
+	Rates        RateStorage
+	Categories   CategoryStorage
+	Images       ImageStorage
 }
 
-func NewUseCase(repository Storage) *Service {
-	return &Service{Repository: repository}
+func NewUseCase(
+	repository Storage,
+	translations TranslationStorage,
+	variants VariantStorage,
+	discounts DiscountStorage,
+	rates RateStorage,
+	categories CategoryStorage,
+	images ImageStorage,
+) *Service {
+	return &Service{
+		Repository:   repository,
+		Translations: translations,
+		Variants:     variants,
+		Discounts:    discounts,
+		Rates:        rates,
+		Categories:   categories,
+		Images:       images,
+	}
 }
 
 func (s *Service) CreateProduct(ctx context.Context, product *Product) error {
@@ -30,6 +109,8 @@ func (s *Service) CreateProduct(ctx context.Context, product *Product) error {
 		return fmt.Errorf("%w: %w", ErrProductValidationFailed, v.Errors)
 	}
 
+	product.Price = product.Price.RoundTo(product.Currency)
+
 	if err := s.Repository.Create(ctx, product); err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
@@ -37,16 +118,216 @@ func (s *Service) CreateProduct(ctx context.Context, product *Product) error {
 	return nil
 }
 
-func (s *Service) GetProduct(ctx context.Context, id int64) (*Product, error) {
-	return s.Repository.GetByID(ctx, id)
+// ImportProduct creates the product of the seller or updates the one imported with the same sku before, it is validated
+// as in CreateProduct and attributes of its translations are checked against the schema of the category.
+// product.Version other than 0 is the version the seller exported the product in, see Repository.UpsertBySKU.
+// It returns true when the product was created
+func (s *Service) ImportProduct(ctx context.Context, sku string, product *Product) (bool, error) {
+	v := validator.New()
+
+	v.Check(sku != "", "external_sku", "must be provided")
+	v.Check(utf8.RuneCountInString(sku) <= maxExternalSKULength, "external_sku", fmt.Sprintf("must not be more than %d characters long", maxExternalSKULength))
+	v.Check(product.Version >= 0, "version", "must not be negative")
+	validateProduct(v, product)
+
+	if err := s.checkAttributes(ctx, v, product); err != nil {
+		return false, err
+	}
+
+	if !v.Valid() {
+		return false, fmt.Errorf("%w: %w", ErrProductValidationFailed, v.Errors)
+	}
+
+	product.Price = product.Price.RoundTo(product.Currency)
+
+	created, err := s.Repository.UpsertBySKU(ctx, sku, product)
+	if err != nil {
+		return false, fmt.Errorf("failed to import product: %w", err)
+	}
+
+	return created, nil
+}
+
+// checkAttributes checks attributes of translations of the product against the schema of its category,
+// a category without a schema accepts any attributes
+func (s *Service) checkAttributes(ctx context.Context, v *validator.Validator, product *Product) error {
+	c, err := s.Categories.GetByID(ctx, int64(product.CategoryID))
+	switch {
+	case errors.Is(err, category.ErrCategoryNotFound):
+		v.AddError("category_id", "category does not exist")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if len(c.AttributeSchema) == 0 {
+		return nil
+	}
+
+	info, err := parser.ExtractInformation(c.AttributeSchema)
+	if err != nil {
+		return fmt.Errorf("failed to parse attribute schema of category %d: %w", c.CategoryID, err)
+	}
+
+	for i, translation := range product.Translations {
+		attributes := map[string]interface{}{}
+		if len(translation.Attributes) > 0 && json.Unmarshal(translation.Attributes, &attributes) != nil {
+			continue // reported by validateProduct
+		}
+
+		for name, message := range info.CheckAttributes(attributes) {
+			v.AddError(fmt.Sprintf("translations[%d].attributes.%s", i, name), message)
+		}
+	}
+
+	return nil
+}
+
+// GetProduct returns the product visible in the scope with its applied discounts, variants and their price range,
+// when language is not empty the translation in that language is embedded and when displayCurrency
+// is not empty prices are converted to it. Relations requested by include are embedded as well
+func (s *Service) GetProduct(ctx context.Context, id int64, language string, displayCurrency string, visibility common.Visibility, expansion common.Expansion) (*Product, error) {
+	v := validator.New()
+
+	if common.ValidateExpansion(v, expansion, productFields, productRelations); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", common.ErrExpansionValidationFailed, v.Errors)
+	}
+
+	if err := s.checkRate(ctx, displayCurrency); err != nil {
+		return nil, err
+	}
+
+	product, err := s.Repository.GetVisible(ctx, id, visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.expand(ctx, []*Product{product}, expansion); err != nil {
+		return nil, err
+	}
+
+	if displayCurrency != "" {
+		if err = s.convertPrices(ctx, product, displayCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	product.Discounts, err = s.Discounts.GetApplied(ctx, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := s.Variants.GetByProductID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(variants) > 0 {
+		product.Variants = variants
+		product.PriceRange = calculatePriceRange(product.Price, variants)
+	}
+
+	if language == "" {
+		return product, nil
+	}
+
+	translation, err := s.Translations.GetByLanguage(ctx, id, language)
+	switch {
+	case errors.Is(err, product_translation.ErrTranslationNotFound):
+		return product, nil
+	case err != nil:
+		return nil, err
+	}
+
+	product.Translation = translation
+
+	return product, nil
 }
 
-func (s *Service) UpdateProduct(ctx context.Context, id int64, request *updateProductRequest) (*Product, error) {
+// BatchGetProducts returns products visible in the scope in the order of ids together with ids of products which
+// were not found, they are returned as in listings with prices converted to displayCurrency and relations requested by include
+func (s *Service) BatchGetProducts(ctx context.Context, ids []int, displayCurrency string, visibility common.Visibility, expansion common.Expansion) ([]*Product, []int, error) {
+	v := validator.New()
+
+	common.ValidateBatch(v, ids)
+	v.Check(displayCurrency == "" || validator.In(displayCurrency, "TJS", "RUB", "USD"), "display_currency", "display_currency must be one of TJS, RUB, USD")
+	common.ValidateState(v, visibility.State)
+	common.ValidateExpansion(v, expansion, productFields, productRelations)
+
+	if !v.Valid() {
+		return nil, nil, fmt.Errorf("%w: %w", common.ErrBatchValidationFailed, v.Errors)
+	}
+
+	if err := s.checkRate(ctx, displayCurrency); err != nil {
+		return nil, nil, err
+	}
+
+	products, err := s.Repository.GetVisibleByIDs(ctx, ids, displayCurrency, visibility)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products, notFound := common.OrderByIDs(ids, products, func(product *Product) int { return product.ProductID })
+
+	if err = s.expand(ctx, products, expansion); err != nil {
+		return nil, nil, err
+	}
+
+	return products, notFound, nil
+}
+
+// checkRate fails with ErrRateNotFound when there is no rate of the display currency yet, prices could neither
+// be converted to it nor compared with price bounds given in it. Nothing is checked without a display currency
+func (s *Service) checkRate(ctx context.Context, displayCurrency string) error {
+	if displayCurrency == "" {
+		return nil
+	}
+
+	ok, err := s.Rates.HasRate(ctx, displayCurrency)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRateNotFound, displayCurrency)
+	}
+
+	return nil
+}
+
+// convertPrices fills display prices of the product, they stay empty when there is no rate for the currency
+// of the product
+func (s *Service) convertPrices(ctx context.Context, product *Product, displayCurrency string) error {
+	price, err := s.Rates.Convert(ctx, product.Price, displayCurrency)
+	if err != nil {
+		return err
+	}
+
+	effectivePrice, err := s.Rates.Convert(ctx, product.EffectivePrice, displayCurrency)
+	if err != nil {
+		return err
+	}
+
+	if price != nil {
+		product.DisplayCurrency = displayCurrency
+		product.DisplayPrice = price
+		product.DisplayEffectivePrice = effectivePrice
+	}
+
+	return nil
+}
+
+// UpdateProduct changes the product of the version, 0 updates any version
+func (s *Service) UpdateProduct(ctx context.Context, id int64, request *updateProductRequest, version int) (*Product, error) {
 	product, err := s.Repository.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if version != 0 && product.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
 	if request.CategoryID != nil {
 		product.CategoryID = *request.CategoryID
 	}
@@ -65,6 +346,8 @@ func (s *Service) UpdateProduct(ctx context.Context, id int64, request *updatePr
 		return nil, fmt.Errorf("%w: %w", ErrProductValidationFailed, v.Errors)
 	}
 
+	product.Price = product.Price.RoundTo(product.Currency)
+
 	if err = s.Repository.Update(ctx, product); err != nil {
 		return nil, err
 	}
@@ -72,8 +355,61 @@ func (s *Service) UpdateProduct(ctx context.Context, id int64, request *updatePr
 	return product, nil
 }
 
-func (s *Service) DeleteProduct(ctx context.Context, id int64) error {
-	return s.Repository.SoftDelete(ctx, id)
+// DeleteProduct deletes the product of the version softly, 0 deletes any version
+func (s *Service) DeleteProduct(ctx context.Context, id int64, version int) error {
+	return s.Repository.SoftDelete(ctx, id, version)
+}
+
+// RestoreProduct restores the soft deleted product
+func (s *Service) RestoreProduct(ctx context.Context, id int64) error {
+	return s.Repository.Restore(ctx, id)
+}
+
+// ChangeStatus moves the product through its lifecycle with the action, see transitions for the allowed moves
+func (s *Service) ChangeStatus(ctx context.Context, id int64, action string, request *changeStatusRequest) (*Product, error) {
+	product, err := s.Repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &StatusChange{
+		ProductID: product.ProductID,
+		Reason:    request.Reason,
+		UserID:    request.UserID,
+	}
+
+	v := validator.New()
+
+	if validateStatusChange(v, action, change); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrProductValidationFailed, v.Errors)
+	}
+
+	// moderated actions are only routed for admins, see changeStatusHandler
+	if !transitions[action].moderated && request.UserID != product.UserID {
+		return nil, ErrNotProductOwner
+	}
+
+	change.ToStatus, err = NextStatus(action, product.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	from := product.Status
+	change.FromStatus = &from
+
+	if err = s.Repository.ChangeStatus(ctx, product, change); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *Service) GetStatusHistory(ctx context.Context, id int64) ([]*StatusChange, error) {
+	if _, err := s.Repository.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.Repository.GetStatusHistory(ctx, id)
 }
 
 func (s *Service) GetProducts(ctx context.Context, filters getProductsRequest) ([]*Product, common.Metadata, error) {
@@ -89,38 +425,358 @@ func (s *Service) GetProducts(ctx context.Context, filters getProductsRequest) (
 		filters.Sort = "product_id"
 	}
 
-	if filters.Currency == "" {
-		filters.Currency = "TJS"
+	if filters.PriceMode == "" {
+		filters.PriceMode = priceModeBase
 	}
 
-	filters.SortSafeList = []string{"product_id", "price", "-product_id", "-price"}
+	filters.SortSafeList = []string{
+		"product_id", "price", "effective_price", "created_at", "updated_at",
+		"-product_id", "-price", "-effective_price", "-created_at", "-updated_at",
+	}
 
 	v := validator.New()
 
-	v.Check(filters.ToPrice >= 0, "to_price", "to_price cannot be negative")
-	v.Check(filters.FromPrice >= 0, "from_price", "from_price cannot be negative")
-	v.Check(validator.In(filters.Currency, "TJS", "RUB", "USD"), "currency", "currency must be one of TJS, RUB, USD")
-	v.Check(filters.CategoryID >= 0, "category_id", "category_id cannot be negative")
-	v.Check(filters.UserID >= 0, "user_id", "user_id cannot be negative")
+	validateProductFilters(v, &filters)
+	common.ValidateExpansion(v, filters.Expansion, productFields, productRelations)
+
+	attributes, err := s.attributeFilters(ctx, v, filters.CategoryIDs, filters.Attributes)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	common.ValidateCursor(v, filters.Filters, sortKeyType(filters.Sort))
 
 	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
 		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
 	}
 
-	products, totalRecords, err := s.Repository.GetPaginated(
+	if err = s.checkRate(ctx, filters.DisplayCurrency); err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	categoryIDs, err := s.categoryIDs(ctx, filters.CategoryIDs, filters.IncludeSubcategories)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	products, metadata, err := s.Repository.GetPaginated(
 		ctx,
 		filters.Currency,
-		filters.CategoryID,
+		categoryIDs,
 		filters.UserID,
 		filters.FromPrice,
 		filters.ToPrice,
+		filters.PriceMode == priceModeEffective,
+		filters.DisplayCurrency,
+		filters.CreatedAfter,
+		filters.CreatedBefore,
+		attributes,
+		common.NewVisibility(filters.State, filters.ViewerID),
 		filters.Filters,
 	)
 	if err != nil {
 		return nil, common.Metadata{}, err
 	}
 
-	metadata := common.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	if err = s.expand(ctx, products, filters.Expansion); err != nil {
+		return nil, common.Metadata{}, err
+	}
 
 	return products, metadata, nil
 }
+
+// GetFacets computes facets of attributes marked filterable in the schema of the category over products
+// listed in it with the filters, a category without a schema has no facets
+func (s *Service) GetFacets(ctx context.Context, categoryID int64, filters getProductsRequest) ([]*Facet, error) {
+	c, err := s.Categories.GetByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := []*Facet{}
+	if len(c.AttributeSchema) == 0 {
+		return facets, nil
+	}
+
+	info, err := parser.ExtractInformation(c.AttributeSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse attribute schema of category %d: %w", c.CategoryID, err)
+	}
+
+	for _, field := range info.FilterableProperties() {
+		if facet, ok := facetFor(field); ok {
+			facets = append(facets, facet)
+		}
+	}
+
+	if filters.PriceMode == "" {
+		filters.PriceMode = priceModeBase
+	}
+	filters.CategoryIDs = []int{int(categoryID)}
+
+	v := validator.New()
+
+	validateProductFilters(v, &filters)
+
+	var attributes []attributeFilter
+	if len(filters.Attributes) > 0 {
+		attributes = parseAttributeFilters(v, filters.Attributes, c.AttributeSchema)
+	}
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
+	}
+
+	if err = s.checkRate(ctx, filters.DisplayCurrency); err != nil {
+		return nil, err
+	}
+
+	categoryIDs, err := s.categoryIDs(ctx, filters.CategoryIDs, filters.IncludeSubcategories)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repository.GetFacets(
+		ctx,
+		facets,
+		filters.Currency,
+		categoryIDs,
+		filters.UserID,
+		filters.FromPrice,
+		filters.ToPrice,
+		filters.PriceMode == priceModeEffective,
+		filters.DisplayCurrency,
+		filters.CreatedAfter,
+		filters.CreatedBefore,
+		attributes,
+		common.NewVisibility(filters.State, filters.ViewerID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// categoryIDs resolves the filtered categories, unless include is false their subcategories are added
+// so that listing a parent category shows products of its leaves
+func (s *Service) categoryIDs(ctx context.Context, ids []int, include *bool) ([]int, error) {
+	if len(ids) == 0 || (include != nil && !*include) {
+		return ids, nil
+	}
+
+	return s.Categories.GetSubtreeIDs(ctx, ids)
+}
+
+// validateProductFilters checks filters shared by listing, search and facets
+func validateProductFilters(v *validator.Validator, filters *getProductsRequest) {
+	v.Check(!filters.ToPrice.IsNegative(), "to_price", "to_price cannot be negative")
+	v.Check(!filters.FromPrice.IsNegative(), "from_price", "from_price cannot be negative")
+	v.Check(validator.In(filters.PriceMode, priceModeBase, priceModeEffective), "price_mode", "price_mode must be base or effective")
+	v.Check(filters.Currency == "" || validator.In(filters.Currency, "TJS", "RUB", "USD"), "currency", "currency must be one of TJS, RUB, USD")
+	v.Check(filters.DisplayCurrency == "" || validator.In(filters.DisplayCurrency, "TJS", "RUB", "USD"), "display_currency", "display_currency must be one of TJS, RUB, USD")
+	v.Check(len(filters.CategoryIDs) <= maxCategoryIDs, "category_id", fmt.Sprintf("must not be given more than %d times", maxCategoryIDs))
+	for _, id := range filters.CategoryIDs {
+		v.Check(id > 0, "category_id", "category_id must be greater than zero")
+	}
+	v.Check(filters.UserID >= 0, "user_id", "user_id cannot be negative")
+	v.Check(filters.ViewerID >= 0, "viewer_id", "viewer_id cannot be negative")
+	common.ValidateState(v, filters.State)
+	common.ValidateCreated(v, filters.CreatedAfter, filters.CreatedBefore)
+}
+
+// attributeFilters checks attribute parameters against the schema of the filtered category,
+// filtering by attributes requires exactly one category_id
+func (s *Service) attributeFilters(ctx context.Context, v *validator.Validator, categoryIDs []int, params map[string][]string) ([]attributeFilter, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	if len(categoryIDs) != 1 {
+		v.AddError("category_id", "exactly one must be provided to filter by attributes")
+		return nil, nil
+	}
+
+	c, err := s.Categories.GetByID(ctx, int64(categoryIDs[0]))
+	switch {
+	case errors.Is(err, category.ErrCategoryNotFound):
+		v.AddError("category_id", "category does not exist")
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	return parseAttributeFilters(v, params, c.AttributeSchema), nil
+}
+
+func (s *Service) SearchProducts(ctx context.Context, filters searchProductsRequest) ([]*SearchResult, common.Metadata, error) {
+	if filters.Page == 0 {
+		filters.Page = 1
+	}
+
+	if filters.PageSize == 0 {
+		filters.PageSize = 20
+	}
+
+	if filters.Sort == "" {
+		filters.Sort = "-rank"
+	}
+
+	if filters.Language == "" {
+		filters.Language = "ru"
+	}
+
+	filters.Query = strings.TrimSpace(filters.Query)
+
+	if filters.PriceMode == "" {
+		filters.PriceMode = priceModeBase
+	}
+
+	filters.SortSafeList = []string{
+		"-rank", "product_id", "price", "effective_price", "created_at", "updated_at",
+		"-product_id", "-price", "-effective_price", "-created_at", "-updated_at",
+	}
+
+	v := validator.New()
+
+	v.Check(filters.Query != "", "q", "search query must be provided")
+	v.Check(utf8.RuneCountInString(filters.Query) <= 200, "q", "search query must not be more than 200 characters long")
+	v.Check(validator.In(filters.Language, "ru", "tj", "en"), "language", "language must be one of [tj ru en]")
+	validateProductFilters(v, &filters.getProductsRequest)
+	common.ValidateExpansion(v, filters.Expansion, slices.Concat(productFields, searchFields), productRelations)
+
+	attributes, err := s.attributeFilters(ctx, v, filters.CategoryIDs, filters.Attributes)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	common.ValidateCursor(v, filters.Filters, sortKeyType(filters.Sort))
+
+	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
+		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
+	}
+
+	if err = s.checkRate(ctx, filters.DisplayCurrency); err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	categoryIDs, err := s.categoryIDs(ctx, filters.CategoryIDs, filters.IncludeSubcategories)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	results, metadata, err := s.Repository.Search(
+		ctx,
+		translit.Variants(filters.Query, filters.Language),
+		filters.Language,
+		filters.Currency,
+		categoryIDs,
+		filters.UserID,
+		filters.FromPrice,
+		filters.ToPrice,
+		filters.PriceMode == priceModeEffective,
+		filters.DisplayCurrency,
+		filters.CreatedAfter,
+		filters.CreatedBefore,
+		attributes,
+		common.NewVisibility(filters.State, filters.ViewerID),
+		filters.Filters,
+	)
+	if err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	products := make([]*Product, 0, len(results))
+	for _, result := range results {
+		products = append(products, result.Product)
+	}
+
+	if err = s.expand(ctx, products, filters.Expansion); err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	return results, metadata, nil
+}
+
+// expand embeds the relations requested by include into products, every relation is loaded
+// for all products with one query
+func (s *Service) expand(ctx context.Context, products []*Product, expansion common.Expansion) error {
+	if len(products) == 0 || len(expansion.IncludeList()) == 0 {
+		return nil
+	}
+
+	productIDs := make([]int, 0, len(products))
+	categoryIDs := make([]int, 0, len(products))
+	userIDs := make([]int, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ProductID)
+		categoryIDs = append(categoryIDs, product.CategoryID)
+		userIDs = append(userIDs, product.UserID)
+	}
+
+	if expansion.Includes(relationCategory) {
+		categories, err := s.Categories.GetByIDs(ctx, categoryIDs)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[int]*category.Category, len(categories))
+		for _, c := range categories {
+			byID[c.CategoryID] = c
+		}
+
+		for _, product := range products {
+			product.Category = byID[product.CategoryID]
+		}
+	}
+
+	if expansion.Includes(relationTranslations) {
+		translations, err := s.Translations.GetByProductIDs(ctx, productIDs)
+		if err != nil {
+			return err
+		}
+
+		byProduct := make(map[int][]*product_translation.Translation, len(products))
+		for _, translation := range translations {
+			byProduct[translation.ProductID] = append(byProduct[translation.ProductID], translation)
+		}
+
+		for _, product := range products {
+			product.Translations = byProduct[product.ProductID]
+		}
+	}
+
+	if expansion.Includes(relationImages) {
+		images, err := s.Images.GetImagesByProductIDs(ctx, productIDs)
+		if err != nil {
+			return err
+		}
+
+		byProduct := make(map[int][]*product_image.Image, len(products))
+		for _, image := range images {
+			byProduct[image.ProductID] = append(byProduct[image.ProductID], image)
+		}
+
+		for _, product := range products {
+			product.Images = byProduct[product.ProductID]
+		}
+	}
+
+	if expansion.Includes(relationSeller) {
+		sellers, err := s.Repository.GetSellers(ctx, userIDs)
+		if err != nil {
+			return err
+		}
+
+		byUser := make(map[int]*Seller, len(sellers))
+		for _, seller := range sellers {
+			byUser[seller.UserID] = seller
+		}
+
+		for _, product := range products {
+			product.Seller = byUser[product.UserID]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+	"slices"
+	"time"
+)
+
+// Lifecycle statuses of a product, only published products are shown in public listings
+const (
+	StatusDraft         = "draft"
+	StatusPendingReview = "pending_review"
+	StatusPublished     = "published"
+	StatusArchived      = "archived"
+	StatusSold          = "sold"
+)
+
+// Actions changing the status of a product
+const (
+	ActionSubmit  = "submit"
+	ActionPublish = "publish"
+	ActionReject  = "reject"
+	ActionArchive = "archive"
+	ActionSell    = "sell"
+)
+
+// transition describes which statuses an action moves a product from and to. Moderated actions are taken
+// by admins, the rest only by the seller of the product
+type transition struct {
+	from      []string
+	to        string
+	moderated bool
+}
+
+// transitions is the state machine of product statuses: sellers submit drafts for review, admins publish them
+// or reject them back to drafts, sellers mark published products sold and archive them at any point but review
+var transitions = map[string]transition{
+	ActionSubmit:  {from: []string{StatusDraft}, to: StatusPendingReview},
+	ActionPublish: {from: []string{StatusPendingReview}, to: StatusPublished, moderated: true},
+	ActionReject:  {from: []string{StatusPendingReview}, to: StatusDraft, moderated: true},
+	ActionArchive: {from: []string{StatusDraft, StatusPublished, StatusSold}, to: StatusArchived},
+	ActionSell:    {from: []string{StatusPublished}, to: StatusSold},
+}
+
+// NextStatus returns the status the action moves a product in the status to,
+// ErrInvalidTransition is returned when the action is not allowed from it
+func NextStatus(action string, status string) (string, error) {
+	t, ok := transitions[action]
+	if !ok || !slices.Contains(t.from, status) {
+		return "", fmt.Errorf("%w: cannot %s a product in %s status", ErrInvalidTransition, action, status)
+	}
+
+	return t.to, nil
+}
+
+// StatusChange represents a change of the product status, FromStatus is nil for a created product.
+// Reason is given when admins reject a product, ReasonCode when they reject it from the moderation queue
+// with one of the predefined reasons
+type StatusChange struct {
+	HistoryID  int       `json:"history_id"`
+	ProductID  int       `json:"product_id"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ReasonCode *string   `json:"reason_code,omitempty"`
+	Reason     *string   `json:"reason,omitempty"`
+	UserID     int       `json:"user_id"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// maxReasonLength limits the text admins write when rejecting a product
+const maxReasonLength = 1000
+
+func validateStatusChange(v *validator.Validator, action string, change *StatusChange) {
+	v.Check(change.UserID > 0, "user_id", "must be greater than zero")
+
+	if action != ActionReject {
+		v.Check(change.Reason == nil, "reason", "must only be provided when rejecting a product")
+		return
+	}
+
+	v.Check(change.Reason != nil && *change.Reason != "", "reason", "must be provided when rejecting a product")
+	if change.Reason != nil {
+		v.Check(len(*change.Reason) <= maxReasonLength, "reason", "must not be more than 1000 bytes long")
+	}
+}
+
+// Repository Errors
+var (
+	ErrStatusChanged  = errors.New("product status was changed concurrently")
+	ErrClaimedByOther = errors.New("product is claimed for review by another moderator")
+)
+
+// Service Errors
+var (
+	ErrInvalidTransition = errors.New("transition is not allowed from the current status")
+	ErrNotProductOwner   = errors.New("only the seller of the product can do this")
+)
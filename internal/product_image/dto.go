@@ -1 +1,18 @@
 package product_image
+
+// productURIRequest represents the param request for images of a product
+type productURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// imageURIRequest represents the param request for an image of a product
+type imageURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+	ImageID   int64 `uri:"image_id" binding:"required,min=1"`
+}
+
+// updateImageRequest represents a request body for moving an image or making it the main one
+type updateImageRequest struct {
+	Position *int  `json:"position"`
+	IsMain   *bool `json:"is_main"`
+}
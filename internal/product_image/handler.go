@@ -1 +1,198 @@
 package product_image
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	imagesURL = "/products/:id/images"
+	imageURL  = "/products/:id/images/:image_id"
+
+	// imageFormField is the multipart field the file is expected in
+	imageFormField = "image"
+	// multipartOverhead is added to the image size limit to allow for multipart headers
+	multipartOverhead = 1 << 20
+)
+
+type UseCase interface {
+	UploadImage(ctx context.Context, productID int64, file io.Reader) (*Image, error)
+	GetImages(ctx context.Context, productID int64) ([]*Image, error)
+	UpdateImage(ctx context.Context, productID int64, imageID int64, request *updateImageRequest) (*Image, error)
+	DeleteImage(ctx context.Context, productID int64, imageID int64) error
+}
+
+type Handler struct {
+	useCase      UseCase
+	logger       logger.Logger
+	maxImageSize int64
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger, maxImageSize int64) *Handler {
+	return &Handler{
+		useCase:      useCase,
+		logger:       logger,
+		maxImageSize: maxImageSize,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(imagesURL, h.uploadImageHandler)
+	router.GET(imagesURL, h.listImagesHandler)
+	router.PATCH(imageURL, h.updateImageHandler)
+	router.DELETE(imageURL, h.deleteImageHandler)
+}
+
+// uploadImageHandler uploads a photo of the product sent as multipart/form-data in the "image" field
+func (h *Handler) uploadImageHandler(ctx *gin.Context) {
+	const op = "uploadImageHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, h.maxImageSize+multipartOverhead)
+
+	file, _, err := ctx.Request.FormFile(imageFormField)
+	if err != nil {
+		h.logger.Error("%s: ctx.Request.FormFile: %v", op, err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apperror.WritePayloadTooLargeResponse(ctx, ErrImageTooLarge, "Image is larger than allowed")
+			return
+		}
+		apperror.WriteBadRequestResponse(ctx, ErrNoFile, "Send the image as multipart/form-data in the \"image\" field")
+		return
+	}
+	defer file.Close()
+
+	image, err := h.useCase.UploadImage(ctx, uri.ProductID, file)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.UploadImage: %v", op, err)
+		switch {
+		case errors.Is(err, ErrImageTooLarge):
+			apperror.WritePayloadTooLargeResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrUnsupportedType), errors.Is(err, ErrInvalidImage):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrTooManyImages):
+			apperror.WriteConflictResponse(ctx, err, "Delete some images of the product first")
+		case errors.Is(err, ErrConcurrentUpload):
+			apperror.WriteConflictResponse(ctx, err, "Try to upload the image again")
+		case errors.Is(err, ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product of the image does not exist")
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusCreated, gin.H{"image": image}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"image": image})
+		return
+	}
+}
+
+// listImagesHandler returns images of the product in gallery order
+func (h *Handler) listImagesHandler(ctx *gin.Context) {
+	const op = "listImagesHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	images, err := h.useCase.GetImages(ctx, uri.ProductID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetImages: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"images": images}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"images": images})
+		return
+	}
+}
+
+// updateImageHandler changes position of the image or makes it the main one
+func (h *Handler) updateImageHandler(ctx *gin.Context) {
+	const op = "updateImageHandler"
+
+	var uri imageURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id and image id")
+		return
+	}
+
+	var input updateImageRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	image, err := h.useCase.UpdateImage(ctx, uri.ProductID, uri.ImageID, &input)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.UpdateImage: %v", op, err)
+		switch {
+		case errors.Is(err, ErrImageNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Image you are seeking to update does not exist")
+		case errors.Is(err, ErrImageValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"image": image}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"image": image})
+		return
+	}
+}
+
+// deleteImageHandler deletes the image together with its files
+func (h *Handler) deleteImageHandler(ctx *gin.Context) {
+	const op = "deleteImageHandler"
+
+	var uri imageURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id and image id")
+		return
+	}
+
+	if err := h.useCase.DeleteImage(ctx, uri.ProductID, uri.ImageID); err != nil {
+		h.logger.Error("%s: h.useCase.DeleteImage: %v", op, err)
+		switch {
+		case errors.Is(err, ErrImageNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Image you are seeking to delete does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"message": "image was successfully deleted"}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"message": "image was successfully deleted"})
+		return
+	}
+}
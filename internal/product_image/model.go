@@ -1 +1,61 @@
 package product_image
+
+import (
+	"errors"
+	"time"
+)
+
+// maxImagesPerProduct limits how many photos one listing can have
+const maxImagesPerProduct = 10
+
+// Image represents a photo of a product with its thumbnails
+type Image struct {
+	ImageID       int               `json:"image_id"`
+	ProductID     int               `json:"product_id"`
+	StorageKey    string            `json:"-"`
+	URL           string            `json:"url"`
+	ContentType   string            `json:"content_type"`
+	SizeBytes     int               `json:"size_bytes"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	Thumbnails    map[string]string `json:"-"`
+	ThumbnailURLs map[string]string `json:"thumbnails"`
+	Position      int               `json:"position"`
+	IsMain        bool              `json:"is_main"`
+	CreatedAt     time.Time         `json:"-"`
+	UpdatedAt     time.Time         `json:"-"`
+}
+
+// keys returns storage keys of the original and all thumbnails
+func (i *Image) keys() []string {
+	keys := []string{i.StorageKey}
+	for _, key := range i.Thumbnails {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Repository Errors
+var (
+	ErrProductNotFound  = errors.New("product not found")
+	ErrImageNotFound    = errors.New("image not found")
+	ErrConnectionFailed = errors.New("database connection failed")
+	ErrConcurrentUpload = errors.New("another image of the product was uploaded at the same time")
+)
+
+// Service Errors
+var (
+	ErrImageValidationFailed = errors.New("image validation failed")
+	ErrUnsupportedType       = errors.New("unsupported image type, only jpeg, png and gif are allowed")
+	ErrInvalidImage          = errors.New("image is corrupted")
+	ErrImageTooLarge         = errors.New("image is too large")
+	ErrTooManyImages         = errors.New("product already has the maximum number of images")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON  = errors.New("failed binding json")
+	ErrInvalidID = errors.New("invalid product id or image id was sent")
+	ErrNoFile    = errors.New("image file was not sent")
+)
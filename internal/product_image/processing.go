@@ -0,0 +1,285 @@
+package product_image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers GIF decoder for image.Decode
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// maxPixels protects from decompression bombs: small files that decode into huge images
+const maxPixels = 40_000_000
+
+// thumbnailSizes - names of generated thumbnails and the maximum length of their longest side
+var thumbnailSizes = []struct {
+	name    string
+	maxSide int
+}{
+	{name: "small", maxSide: 160},
+	{name: "medium", maxSide: 480},
+	{name: "large", maxSide: 1200},
+}
+
+// allowedTypes maps sniffed MIME types to the decoder format names
+var allowedTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// encodedImage is a re-encoded image ready to be stored
+type encodedImage struct {
+	data        []byte
+	contentType string
+	extension   string
+	width       int
+	height      int
+}
+
+// processedImage holds the re-encoded original and its thumbnails
+type processedImage struct {
+	original   encodedImage
+	thumbnails map[string]encodedImage
+}
+
+// processImage sniffs and decodes the upload, applies EXIF orientation and re-encodes it.
+// Re-encoding drops every metadata block (EXIF, GPS, comments), so nothing from the camera leaks to buyers
+func processImage(data []byte) (*processedImage, error) {
+	contentType := http.DetectContentType(data)
+	format, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	rgba := orient(toRGBA(img), exifOrientation(data))
+
+	original, err := encode(rgba, format)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := &processedImage{
+		original:   original,
+		thumbnails: make(map[string]encodedImage, len(thumbnailSizes)),
+	}
+
+	for _, size := range thumbnailSizes {
+		thumbnail, err := encode(resize(rgba, size.maxSide), format)
+		if err != nil {
+			return nil, err
+		}
+		processed.thumbnails[size.name] = thumbnail
+	}
+
+	return processed, nil
+}
+
+// encode writes JPEG sources as JPEG and everything else as PNG, GIF animations keep only the first frame
+func encode(img *image.RGBA, format string) (encodedImage, error) {
+	var buf bytes.Buffer
+
+	encoded := encodedImage{
+		width:  img.Bounds().Dx(),
+		height: img.Bounds().Dy(),
+	}
+
+	switch format {
+	case "jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return encodedImage{}, fmt.Errorf("failed to encode jpeg: %w", err)
+		}
+		encoded.contentType, encoded.extension = "image/jpeg", "jpg"
+	default:
+		if err := png.Encode(&buf, img); err != nil {
+			return encodedImage{}, fmt.Errorf("failed to encode png: %w", err)
+		}
+		encoded.contentType, encoded.extension = "image/png", "png"
+	}
+
+	encoded.data = buf.Bytes()
+
+	return encoded, nil
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// resize scales the image down with a box filter, so that its longest side is not more than maxSide.
+// Images that already fit are returned as is
+func resize(src *image.RGBA, maxSide int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= maxSide && sh <= maxSide {
+		return src
+	}
+
+	dw, dh := maxSide, sh*maxSide/sw
+	if sh > sw {
+		dw, dh = sw*maxSide/sh, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0 := y * sh / dh
+		y1 := max((y+1)*sh/dh, y0+1)
+
+		for x := 0; x < dw; x++ {
+			x0 := x * sw / dw
+			x1 := max((x+1)*sw/dw, x0+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[offset])
+					g += int(src.Pix[offset+1])
+					b += int(src.Pix[offset+2])
+					a += int(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// orient rotates and flips the image according to EXIF orientation (1-8), so it looks right without metadata
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+
+	return dst
+}
+
+// exifOrientation reads the orientation tag (0x0112) from the EXIF block of a JPEG, 1 is returned when there is none
+func exifOrientation(data []byte) int {
+	const defaultOrientation = 1
+
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return defaultOrientation
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return defaultOrientation
+		}
+
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return defaultOrientation
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return defaultOrientation
+}
+
+func tiffOrientation(tiff []byte) int {
+	const defaultOrientation = 1
+
+	if len(tiff) < 8 {
+		return defaultOrientation
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return defaultOrientation
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return defaultOrientation
+	}
+
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return defaultOrientation
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8 : entry+10]))
+		}
+	}
+
+	return defaultOrientation
+}
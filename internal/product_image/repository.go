@@ -1 +1,318 @@
 package product_image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/postgres"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// Create method saves a new image of an active product at the end of its gallery,
+// the first image of a product becomes the main one
+func (r *Repository) Create(ctx context.Context, image *Image) error {
+	const op = "Create"
+
+	query := `
+		INSERT INTO
+		    product_images (product_id, storage_key, content_type, size_bytes, width, height, thumbnails, position, is_main)
+		SELECT
+		    $1::integer, $2::varchar, $3::varchar, $4::integer, $5::integer, $6::integer, $7::jsonb,
+		    coalesce((SELECT max(position) + 1 FROM product_images WHERE product_id = $1), 0),
+		    NOT EXISTS (SELECT 1 FROM product_images WHERE product_id = $1 AND is_main = true)
+		WHERE EXISTS
+		    (SELECT 1 FROM products WHERE product_id = $1 AND active = true)
+		RETURNING image_id, position, is_main, created_at, updated_at`
+
+	args := []interface{}{
+		image.ProductID,
+		image.StorageKey,
+		image.ContentType,
+		image.SizeBytes,
+		image.Width,
+		image.Height,
+		image.Thumbnails,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&image.ImageID,
+		&image.Position,
+		&image.IsMain,
+		&image.CreatedAt,
+		&image.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return ErrProductNotFound
+		}
+
+		if postgres.IsPgErr(err) {
+			err = postgres.Conv2CustomErr(err)
+		}
+
+		var pgErr *postgres.PostgresErr
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return postgres.ErrDoQuery(op, ErrConcurrentUpload)
+			case "23503":
+				return postgres.ErrDoQuery(op, ErrProductNotFound)
+			case "08000", "08001", "08003", "08006":
+				return postgres.ErrDoQuery(op, ErrConnectionFailed)
+			default:
+				return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+			}
+		}
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	return nil
+}
+
+// GetByProductID method gets images of the product in gallery order
+func (r *Repository) GetByProductID(ctx context.Context, productID int64) ([]*Image, error) {
+	const op = "GetByProductID"
+
+	query := `
+		SELECT
+		    image_id, product_id, storage_key, content_type, size_bytes, width, height, thumbnails, position, is_main, created_at, updated_at
+		FROM
+		    product_images
+		WHERE
+		    product_id = $1
+		ORDER BY
+		    position, image_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	images := []*Image{}
+
+	for rows.Next() {
+		var image Image
+		err = rows.Scan(
+			&image.ImageID,
+			&image.ProductID,
+			&image.StorageKey,
+			&image.ContentType,
+			&image.SizeBytes,
+			&image.Width,
+			&image.Height,
+			&image.Thumbnails,
+			&image.Position,
+			&image.IsMain,
+			&image.CreatedAt,
+			&image.UpdatedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		images = append(images, &image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return images, nil
+}
+
+// GetByProductIDs method gets images of the products at once, ordered by product and then in gallery order
+func (r *Repository) GetByProductIDs(ctx context.Context, productIDs []int) ([]*Image, error) {
+	const op = "GetByProductIDs"
+
+	query := `
+		SELECT
+		    image_id, product_id, storage_key, content_type, size_bytes, width, height, thumbnails, position, is_main, created_at, updated_at
+		FROM
+		    product_images
+		WHERE
+		    product_id = ANY($1::int[])
+		ORDER BY
+		    product_id, position, image_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	images := []*Image{}
+
+	for rows.Next() {
+		var image Image
+		err = rows.Scan(
+			&image.ImageID,
+			&image.ProductID,
+			&image.StorageKey,
+			&image.ContentType,
+			&image.SizeBytes,
+			&image.Width,
+			&image.Height,
+			&image.Thumbnails,
+			&image.Position,
+			&image.IsMain,
+			&image.CreatedAt,
+			&image.UpdatedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		images = append(images, &image)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return images, nil
+}
+
+// CountByProductID method counts images of the product
+func (r *Repository) CountByProductID(ctx context.Context, productID int64) (int, error) {
+	const op = "CountByProductID"
+
+	query := `SELECT count(*) FROM product_images WHERE product_id = $1`
+
+	var count int
+	if err := r.client.Pool.QueryRow(ctx, query, productID).Scan(&count); err != nil {
+		return 0, postgres.ErrDoQuery(op, err)
+	}
+
+	return count, nil
+}
+
+// Reorder method sets positions of images by their order in imageIDs
+func (r *Repository) Reorder(ctx context.Context, productID int64, imageIDs []int) error {
+	const op = "Reorder"
+
+	query := `
+		UPDATE
+		    product_images
+		SET
+		    position = array_position($2::integer[], image_id) - 1
+		WHERE
+		    product_id = $1
+		AND
+		    image_id = ANY($2::integer[])`
+
+	if _, err := r.client.Pool.Exec(ctx, query, productID, imageIDs); err != nil {
+		return postgres.ErrExec(op, err)
+	}
+
+	return nil
+}
+
+// SetMain method makes the image the only main image of the product
+func (r *Repository) SetMain(ctx context.Context, productID int64, imageID int64) error {
+	const op = "SetMain"
+
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		query := `
+			UPDATE
+			    product_images
+			SET
+			    is_main = false
+			WHERE
+			    product_id = $1
+			AND
+			    is_main = true
+			AND
+			    image_id <> $2`
+
+		if _, err := tx.Exec(ctx, query, productID, imageID); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		query = `
+			UPDATE
+			    product_images
+			SET
+			    is_main = true
+			WHERE
+			    product_id = $1
+			AND
+			    image_id = $2`
+
+		result, err := tx.Exec(ctx, query, productID, imageID)
+		if err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return ErrImageNotFound
+		}
+
+		return nil
+	})
+}
+
+// Delete method deletes the image and returns it, so its files could be removed.
+// When the main image is deleted, the first remaining image becomes the main one
+func (r *Repository) Delete(ctx context.Context, productID int64, imageID int64) (*Image, error) {
+	const op = "Delete"
+
+	var image Image
+
+	err := r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		query := `
+			DELETE FROM
+			    product_images
+			WHERE
+			    product_id = $1
+			AND
+			    image_id = $2
+			RETURNING image_id, product_id, storage_key, thumbnails, is_main`
+
+		if err := tx.QueryRow(ctx, query, productID, imageID).Scan(
+			&image.ImageID,
+			&image.ProductID,
+			&image.StorageKey,
+			&image.Thumbnails,
+			&image.IsMain,
+		); err != nil {
+			if errors.Is(err, postgres.ErrNoRows) {
+				return ErrImageNotFound
+			}
+			return postgres.ErrDoQuery(op, err)
+		}
+
+		if !image.IsMain {
+			return nil
+		}
+
+		query = `
+			UPDATE
+			    product_images
+			SET
+			    is_main = true
+			WHERE
+			    image_id = (SELECT image_id FROM product_images WHERE product_id = $1 ORDER BY position, image_id LIMIT 1)`
+
+		if _, err := tx.Exec(ctx, query, productID); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
@@ -1 +1,232 @@
 package product_image
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"ngMarketplace/pkg/logger"
+	"ngMarketplace/pkg/storage"
+	"ngMarketplace/pkg/validator"
+	"slices"
+)
+
+type Storage interface {
+	Create(ctx context.Context, image *Image) error
+	GetByProductID(ctx context.Context, productID int64) ([]*Image, error)
+	GetByProductIDs(ctx context.Context, productIDs []int) ([]*Image, error)
+	CountByProductID(ctx context.Context, productID int64) (int, error)
+	Reorder(ctx context.Context, productID int64, imageIDs []int) error
+	SetMain(ctx context.Context, productID int64, imageID int64) error
+	Delete(ctx context.Context, productID int64, imageID int64) (*Image, error)
+}
+
+type Service struct {
+	Repository   Storage
+	Files        storage.FileStorage
+	MaxImageSize int64
+	logger       logger.Logger
+}
+
+func NewUseCase(repository Storage, files storage.FileStorage, maxImageSize int64, logger logger.Logger) *Service {
+	return &Service{Repository: repository, Files: files, MaxImageSize: maxImageSize, logger: logger}
+}
+
+// UploadImage validates and processes the uploaded file, stores the original with thumbnails and
+// appends the image to the product gallery
+func (s *Service) UploadImage(ctx context.Context, productID int64, file io.Reader) (*Image, error) {
+	count, err := s.Repository.CountByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= maxImagesPerProduct {
+		return nil, fmt.Errorf("%w: %d", ErrTooManyImages, maxImagesPerProduct)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	if int64(len(data)) > s.MaxImageSize {
+		return nil, fmt.Errorf("%w: must not be more than %d bytes", ErrImageTooLarge, s.MaxImageSize)
+	}
+
+	processed, err := processImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := randomPrefix(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &Image{
+		ProductID:   int(productID),
+		StorageKey:  fmt.Sprintf("%s/original.%s", prefix, processed.original.extension),
+		ContentType: processed.original.contentType,
+		SizeBytes:   len(processed.original.data),
+		Width:       processed.original.width,
+		Height:      processed.original.height,
+		Thumbnails:  make(map[string]string, len(processed.thumbnails)),
+	}
+
+	if err = s.Files.Save(ctx, image.StorageKey, image.ContentType, bytes.NewReader(processed.original.data)); err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	for name, thumbnail := range processed.thumbnails {
+		key := fmt.Sprintf("%s/%s.%s", prefix, name, thumbnail.extension)
+		image.Thumbnails[name] = key
+
+		if err = s.Files.Save(ctx, key, thumbnail.contentType, bytes.NewReader(thumbnail.data)); err != nil {
+			s.deleteFiles(image)
+			return nil, fmt.Errorf("failed to save thumbnail: %w", err)
+		}
+	}
+
+	if err = s.Repository.Create(ctx, image); err != nil {
+		s.deleteFiles(image)
+		return nil, fmt.Errorf("failed to create image: %w", err)
+	}
+
+	s.fillURLs(image)
+
+	return image, nil
+}
+
+func (s *Service) GetImages(ctx context.Context, productID int64) ([]*Image, error) {
+	images, err := s.Repository.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		s.fillURLs(image)
+	}
+
+	return images, nil
+}
+
+// GetImagesByProductIDs returns images of several products at once, it lets listings embed images
+// without querying them product by product
+func (s *Service) GetImagesByProductIDs(ctx context.Context, productIDs []int) ([]*Image, error) {
+	images, err := s.Repository.GetByProductIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		s.fillURLs(image)
+	}
+
+	return images, nil
+}
+
+// UpdateImage moves the image to another position of the gallery and/or makes it the main one
+func (s *Service) UpdateImage(ctx context.Context, productID int64, imageID int64, request *updateImageRequest) (*Image, error) {
+	images, err := s.Repository.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(images, func(image *Image) bool {
+		return int64(image.ImageID) == imageID
+	})
+	if index < 0 {
+		return nil, ErrImageNotFound
+	}
+
+	v := validator.New()
+
+	if request.Position != nil {
+		v.Check(*request.Position >= 0 && *request.Position < len(images), "position", fmt.Sprintf("must be between 0 and %d", len(images)-1))
+	}
+
+	if request.IsMain != nil {
+		v.Check(*request.IsMain, "is_main", "an image stops being main only when another image becomes main")
+	}
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrImageValidationFailed, v.Errors)
+	}
+
+	if request.Position != nil && *request.Position != index {
+		moved := images[index]
+		images = slices.Insert(slices.Delete(images, index, index+1), *request.Position, moved)
+
+		imageIDs := make([]int, 0, len(images))
+		for _, image := range images {
+			imageIDs = append(imageIDs, image.ImageID)
+		}
+
+		if err = s.Repository.Reorder(ctx, productID, imageIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.IsMain != nil {
+		if err = s.Repository.SetMain(ctx, productID, imageID); err != nil {
+			return nil, err
+		}
+	}
+
+	images, err = s.GetImages(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		if int64(image.ImageID) == imageID {
+			return image, nil
+		}
+	}
+
+	return nil, ErrImageNotFound
+}
+
+// DeleteImage deletes the image and its files
+func (s *Service) DeleteImage(ctx context.Context, productID int64, imageID int64) error {
+	image, err := s.Repository.Delete(ctx, productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	s.deleteFiles(image)
+
+	return nil
+}
+
+func (s *Service) fillURLs(image *Image) {
+	image.URL = s.Files.URL(image.StorageKey)
+	image.ThumbnailURLs = make(map[string]string, len(image.Thumbnails))
+	for name, key := range image.Thumbnails {
+		image.ThumbnailURLs[name] = s.Files.URL(key)
+	}
+}
+
+// deleteFiles removes files of the image, failures are only logged since the image is already gone for clients
+func (s *Service) deleteFiles(image *Image) {
+	const op = "deleteFiles"
+
+	for _, key := range image.keys() {
+		if err := s.Files.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrInvalidKey) {
+			s.logger.Error("%s: failed to delete file %s: %v", op, key, err)
+		}
+	}
+}
+
+// randomPrefix returns a unique storage folder for files of one image
+func randomPrefix(productID int64) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate image key: %w", err)
+	}
+
+	return fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(random)), nil
+}
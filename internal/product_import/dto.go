@@ -0,0 +1,20 @@
+package product_import
+
+import "ngMarketplace/internal/common"
+
+// createJobRequest represents the form fields sent with an import file, format is taken from the file name
+// when it is not provided
+type createJobRequest struct {
+	UserID int    `form:"user_id" binding:"required,min=1"` // todo user_id should be got from token
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+// jobURIRequest represents the param request for an import job
+type jobURIRequest struct {
+	ID int `uri:"id" binding:"required,min=1"`
+}
+
+// getRowErrorsRequest represents a query for failed rows of an import job, they are always in the order of the file
+type getRowErrorsRequest struct {
+	common.Filters
+}
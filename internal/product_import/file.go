@@ -0,0 +1,271 @@
+package product_import
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"ngMarketplace/pkg/money"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// byteOrderMark is written by spreadsheet editors at the start of UTF-8 files
+const byteOrderMark = "\uFEFF"
+
+// languages products may be translated to, every language has its own columns in CSV files
+var languages = []string{"tj", "ru", "en"}
+
+// requiredColumns must be present in the header of CSV files
+var requiredColumns = []string{"external_sku", "price", "currency", "category_id"}
+
+// versionColumn is the optional column of the version a product was exported in, an updated product must
+// still be in it, so changes made since the export are not overwritten
+const versionColumn = "version"
+
+// translationColumns returns the columns of the translation in the language: its name, description
+// and attributes as a JSON object
+func translationColumns(language string) (name string, description string, attributes string) {
+	return "product_name_" + language, "product_description_" + language, "attributes_" + language
+}
+
+// parseFile reads rows of the import file. A file that cannot be read as a whole is an error, while a malformed
+// row is only failed with its errors, so the rest of the file can still be imported
+func parseFile(format string, r io.Reader) ([]*Row, error) {
+	var (
+		rows []*Row
+		err  error
+	)
+
+	switch format {
+	case FormatCSV:
+		rows, err = parseCSV(r)
+	case FormatJSONL:
+		rows, err = parseJSONL(r)
+	default:
+		return nil, ErrInvalidFormat
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	return rows, nil
+}
+
+// parseCSV reads a CSV file with a header, rows are numbered by their lines, so they match the lines
+// of the spreadsheet the file was exported from
+func parseCSV(r io.Reader) ([]*Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, ErrEmptyFile
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	columns, err := csvColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []*Row{}
+
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		if strings.TrimSpace(strings.Join(fields, "")) == "" {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: at most %d allowed", ErrTooManyRows, maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRow(line, fields, columns))
+	}
+
+	return rows, nil
+}
+
+// csvColumns checks the header and returns positions of columns by their names
+func csvColumns(header []string) (map[string]int, error) {
+	known := append(slices.Clone(requiredColumns), versionColumn)
+	for _, language := range languages {
+		name, description, attributes := translationColumns(language)
+		known = append(known, name, description, attributes)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, byteOrderMark))
+
+		switch _, duplicate := columns[column]; {
+		case !slices.Contains(known, column):
+			return nil, fmt.Errorf("%w: unknown column %q, columns are %s", ErrInvalidFile, column, strings.Join(known, ", "))
+		case duplicate:
+			return nil, fmt.Errorf("%w: column %q is repeated", ErrInvalidFile, column)
+		}
+
+		columns[column] = i
+	}
+
+	for _, column := range requiredColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("%w: column %q is missing", ErrInvalidFile, column)
+		}
+	}
+
+	return columns, nil
+}
+
+// csvRow reads the record of the row, a translation is read when any of its columns is filled
+func csvRow(line int, fields []string, columns map[string]int) *Row {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	row := &Row{RowNumber: line, Status: RowStatusPending}
+	errs := make(map[string]string)
+
+	if len(fields) != len(columns) {
+		errs["row"] = fmt.Sprintf("must have %d fields, has %d", len(columns), len(fields))
+	}
+
+	record := &Record{
+		ExternalSKU: value("external_sku"),
+		Currency:    value("currency"),
+	}
+
+	if record.ExternalSKU != "" {
+		row.ExternalSKU = &record.ExternalSKU
+	}
+
+	if price := value("price"); price != "" {
+		parsed, err := money.Parse(price, record.Currency)
+		if err != nil {
+			errs["price"] = err.Error()
+		}
+		record.Price = parsed
+	} else {
+		errs["price"] = "must be provided"
+	}
+
+	if categoryID, err := strconv.Atoi(value("category_id")); err == nil {
+		record.CategoryID = categoryID
+	} else {
+		errs["category_id"] = "must be an integer"
+	}
+
+	if version := value(versionColumn); version != "" {
+		if parsed, err := strconv.Atoi(version); err == nil {
+			record.Version = parsed
+		} else {
+			errs[versionColumn] = "must be an integer"
+		}
+	}
+
+	for _, language := range languages {
+		nameColumn, descriptionColumn, attributesColumn := translationColumns(language)
+		name, description, attributes := value(nameColumn), value(descriptionColumn), value(attributesColumn)
+		if name == "" && description == "" && attributes == "" {
+			continue
+		}
+
+		translation := RecordTranslation{Language: language, ProductName: name}
+		if description != "" {
+			translation.ProductDescription = &description
+		}
+		if attributes != "" {
+			if !json.Valid([]byte(attributes)) {
+				errs[attributesColumn] = "must be a JSON object"
+			}
+			translation.Attributes = json.RawMessage(attributes)
+		}
+
+		record.Translations = append(record.Translations, translation)
+	}
+
+	if len(errs) > 0 {
+		row.fail(errs)
+		return row
+	}
+
+	row.Record = record
+
+	return row
+}
+
+// parseJSONL reads a file of JSON objects of Record one per line, rows are numbered by their lines
+// and blank lines are skipped
+func parseJSONL(r io.Reader) ([]*Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportSize)
+
+	rows := []*Row{}
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := bytes.TrimSpace(bytes.TrimPrefix(scanner.Bytes(), []byte(byteOrderMark)))
+		if len(text) == 0 {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: at most %d allowed", ErrTooManyRows, maxImportRows)
+		}
+
+		rows = append(rows, jsonlRow(line, text))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	return rows, nil
+}
+
+// jsonlRow reads the record of the line, fields Record does not have are not allowed
+func jsonlRow(line int, text []byte) *Row {
+	row := &Row{RowNumber: line, Status: RowStatusPending}
+
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.DisallowUnknownFields()
+
+	var record Record
+	if err := decoder.Decode(&record); err != nil {
+		row.fail(map[string]string{"row": fmt.Sprintf("must be a JSON object of a product: %v", err)})
+		return row
+	}
+
+	if record.ExternalSKU != "" {
+		row.ExternalSKU = &record.ExternalSKU
+	}
+	row.Record = &record
+
+	return row
+}
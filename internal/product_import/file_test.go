@@ -0,0 +1,188 @@
+package product_import
+
+import (
+	"encoding/json"
+	"errors"
+	"ngMarketplace/pkg/money"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string, currency string) money.Money {
+	t.Helper()
+
+	m, err := money.Parse(s, currency)
+	if err != nil {
+		t.Fatalf("money.Parse(%q, %q) error = %v", s, currency, err)
+	}
+	return m
+}
+
+func TestParseCSV(t *testing.T) {
+	description := "Dual SIM"
+
+	file := byteOrderMark + "external_sku,price,currency,category_id,product_name_en,product_description_en,attributes_en\n" +
+		"A-1,19.99,USD,3,Phone,Dual SIM,\"{\"\"ram\"\":8}\"\n" +
+		"\n" +
+		"A-2,1.999,USD,x,,,not json\n" +
+		"A-3,5,USD,3\n" +
+		",,USD,3,,,\n"
+
+	rows, err := parseFile(FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("parseFile() returned %d rows, want 4", len(rows))
+	}
+
+	want := &Record{
+		ExternalSKU: "A-1",
+		Price:       mustParse(t, "19.99", "USD"),
+		Currency:    "USD",
+		CategoryID:  3,
+		Translations: []RecordTranslation{{
+			Language:           "en",
+			ProductName:        "Phone",
+			ProductDescription: &description,
+			Attributes:         json.RawMessage(`{"ram":8}`),
+		}},
+	}
+	if first := rows[0]; first.RowNumber != 2 || first.Status != RowStatusPending || !reflect.DeepEqual(first.Record, want) {
+		t.Errorf("rows[0] = %+v with record %+v, want row 2 pending with record %+v", first, first.Record, want)
+	}
+
+	tests := []struct {
+		row    *Row
+		line   int
+		sku    string
+		errors []string
+	}{
+		{rows[1], 4, "A-2", []string{"price", "category_id", "attributes_en"}},
+		{rows[2], 5, "A-3", []string{"row"}},
+		{rows[3], 6, "", []string{"price"}},
+	}
+
+	for _, tt := range tests {
+		if tt.row.RowNumber != tt.line || tt.row.Status != RowStatusFailed || tt.row.Record != nil {
+			t.Errorf("row %+v, want line %d failed without a record", tt.row, tt.line)
+		}
+		if sku := tt.row.ExternalSKU; (sku == nil) != (tt.sku == "") || sku != nil && *sku != tt.sku {
+			t.Errorf("row %d external_sku = %v, want %q", tt.line, sku, tt.sku)
+		}
+		if len(tt.row.Errors) != len(tt.errors) {
+			t.Errorf("row %d errors = %v, want keys %v", tt.line, tt.row.Errors, tt.errors)
+		}
+		for _, key := range tt.errors {
+			if _, ok := tt.row.Errors[key]; !ok {
+				t.Errorf("row %d errors = %v, want key %q", tt.line, tt.row.Errors, key)
+			}
+		}
+	}
+}
+
+func TestParseCSVVersion(t *testing.T) {
+	file := "external_sku,price,currency,category_id,version\n" +
+		"A-1,5,USD,3,2\n" +
+		"A-2,5,USD,3,\n" +
+		"A-3,5,USD,3,latest\n"
+
+	rows, err := parseFile(FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("parseFile() returned %d rows, want 3", len(rows))
+	}
+
+	if rows[0].Record == nil || rows[0].Record.Version != 2 {
+		t.Errorf("rows[0] record = %+v, want version 2", rows[0].Record)
+	}
+	if rows[1].Record == nil || rows[1].Record.Version != 0 {
+		t.Errorf("rows[1] record = %+v, want no version", rows[1].Record)
+	}
+	if rows[2].Status != RowStatusFailed || rows[2].Errors[versionColumn] == "" {
+		t.Errorf("rows[2] = %+v, want failed with a version error", rows[2])
+	}
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"unknown column", "external_sku,price,currency,category_id,colour"},
+		{"repeated column", "external_sku,price,currency,category_id,price"},
+		{"missing column", "external_sku,price,category_id"},
+		{"unknown language", "external_sku,price,currency,category_id,product_name_de"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFile(FormatCSV, strings.NewReader(tt.header+"\nA-1,1,USD,3\n"))
+			if !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("parseFile() error = %v, want %v", err, ErrInvalidFile)
+			}
+		})
+	}
+}
+
+func TestParseJSONL(t *testing.T) {
+	file := byteOrderMark + `{"external_sku":"A-1","price":"19.99","currency":"USD","category_id":3,"translations":[{"language":"en","product_name":"Phone"}]}` + "\n" +
+		"\n" +
+		`{"external_sku":"A-2","colour":"red"}` + "\n" +
+		"not json\n"
+
+	rows, err := parseFile(FormatJSONL, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("parseFile() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("parseFile() returned %d rows, want 3", len(rows))
+	}
+
+	want := &Record{
+		ExternalSKU:  "A-1",
+		Price:        mustParse(t, "19.99", ""),
+		Currency:     "USD",
+		CategoryID:   3,
+		Translations: []RecordTranslation{{Language: "en", ProductName: "Phone"}},
+	}
+	if first := rows[0]; first.RowNumber != 1 || first.Status != RowStatusPending || !reflect.DeepEqual(first.Record, want) {
+		t.Errorf("rows[0] = %+v with record %+v, want row 1 pending with record %+v", first, first.Record, want)
+	}
+	if sku := rows[0].ExternalSKU; sku == nil || *sku != "A-1" {
+		t.Errorf("rows[0] external_sku = %v, want %q", sku, "A-1")
+	}
+
+	for i, line := range []int{3, 4} {
+		row := rows[i+1]
+		if row.RowNumber != line || row.Status != RowStatusFailed || row.Record != nil || row.Errors["row"] == "" {
+			t.Errorf("rows[%d] = %+v, want line %d failed with a row error", i+1, row, line)
+		}
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		err    error
+	}{
+		{"unknown format", "xlsx", "external_sku\n", ErrInvalidFormat},
+		{"empty CSV", FormatCSV, "", ErrEmptyFile},
+		{"CSV of a header only", FormatCSV, "external_sku,price,currency,category_id\n\n", ErrEmptyFile},
+		{"blank JSONL", FormatJSONL, "\n  \n", ErrEmptyFile},
+		{"unterminated quote", FormatCSV, "external_sku,price,currency,category_id\n\"A-1,1,USD,3\n", ErrInvalidFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseFile(tt.format, strings.NewReader(tt.file)); !errors.Is(err, tt.err) {
+				t.Errorf("parseFile() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
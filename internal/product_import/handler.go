@@ -0,0 +1,178 @@
+package product_import
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	importsURL      = "/products/imports"
+	importURL       = "/products/imports/:id"
+	importErrorsURL = "/products/imports/:id/errors"
+
+	// fileFormField is the multipart field the file is expected in
+	fileFormField = "file"
+	// multipartOverhead is added to the file size limit to allow for multipart headers and form fields
+	multipartOverhead = 1 << 20
+)
+
+type UseCase interface {
+	CreateJob(ctx context.Context, userID int, format string, filename string, file io.Reader) (*Job, error)
+	GetJob(ctx context.Context, jobID int) (*Job, error)
+	GetRowErrors(ctx context.Context, jobID int, filters getRowErrorsRequest) ([]*Row, common.Metadata, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(importsURL, h.createJobHandler)
+	router.GET(importURL, h.showJobHandler)
+	router.GET(importErrorsURL, h.listRowErrorsHandler)
+}
+
+// createJobHandler accepts a CSV or JSONL file of products sent as multipart/form-data in the "file" field,
+// the file is imported in the background and the job is answered right away
+func (h *Handler) createJobHandler(ctx *gin.Context) {
+	const op = "createJobHandler"
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize+multipartOverhead)
+
+	file, header, err := ctx.Request.FormFile(fileFormField)
+	if err != nil {
+		h.logger.Error("%s: ctx.Request.FormFile: %v", op, err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apperror.WritePayloadTooLargeResponse(ctx, ErrFileTooLarge, fmt.Sprintf("File must not be larger than %d MB", maxImportSize>>20))
+			return
+		}
+		apperror.WriteBadRequestResponse(ctx, ErrNoFile, "Send the file as multipart/form-data in the \"file\" field")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportSize {
+		apperror.WritePayloadTooLargeResponse(ctx, ErrFileTooLarge, fmt.Sprintf("File must not be larger than %d MB", maxImportSize>>20))
+		return
+	}
+
+	var req createJobRequest
+	if err = ctx.ShouldBind(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBind: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide user_id and optionally format as csv or jsonl")
+		return
+	}
+
+	job, err := h.useCase.CreateJob(ctx, req.UserID, req.Format, header.Filename, file)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.CreateJob: %v", op, err)
+		switch {
+		case errors.Is(err, ErrInvalidParams):
+			apperror.WriteBadRequestResponse(ctx, err, "Provide format as csv or jsonl or name the file with .csv or .jsonl extension")
+		case errors.Is(err, ErrInvalidFile), errors.Is(err, ErrEmptyFile), errors.Is(err, ErrInvalidFormat):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrTooManyRows):
+			apperror.WritePayloadTooLargeResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("%s/%d", importsURL, job.JobID))
+
+	if err = router.WriteJSON(ctx, http.StatusAccepted, gin.H{"import": job}, headers); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusAccepted, gin.H{"import": job})
+		return
+	}
+}
+
+// showJobHandler returns the job with its progress
+func (h *Handler) showJobHandler(ctx *gin.Context) {
+	const op = "showJobHandler"
+
+	var uri jobURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct import id")
+		return
+	}
+
+	job, err := h.useCase.GetJob(ctx, uri.ID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetJob: %v", op, err)
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Import does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"import": job}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"import": job})
+		return
+	}
+}
+
+// listRowErrorsHandler returns failed rows of the job with errors of their fields
+func (h *Handler) listRowErrorsHandler(ctx *gin.Context) {
+	const op = "listRowErrorsHandler"
+
+	var uri jobURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct import id")
+		return
+	}
+
+	var req getRowErrorsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some filter was sent with incorrect type")
+		return
+	}
+
+	rows, metadata, err := h.useCase.GetRowErrors(ctx, uri.ID, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetRowErrors: %v", op, err)
+		switch {
+		case errors.Is(err, common.ErrFilterValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, "check filter parameters")
+		case errors.Is(err, ErrJobNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Import does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"errors": rows, "metadata": metadata}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"errors": rows, "metadata": metadata})
+		return
+	}
+}
@@ -0,0 +1,151 @@
+package product_import
+
+import (
+	"encoding/json"
+	"errors"
+	"ngMarketplace/internal/product"
+	"ngMarketplace/internal/product_translation"
+	"ngMarketplace/pkg/money"
+	"time"
+)
+
+// Formats of import files
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Statuses of import jobs: a job is pending until the worker takes it, completed jobs may still have failed rows
+// and failed jobs were stopped by an unexpected error, rows imported before it stay imported
+const (
+	JobStatusPending    = "pending"
+	JobStatusProcessing = "processing"
+	JobStatusCompleted  = "completed"
+	JobStatusFailed     = "failed"
+)
+
+// Statuses of import rows
+const (
+	RowStatusPending = "pending"
+	RowStatusCreated = "created"
+	RowStatusUpdated = "updated"
+	RowStatusFailed  = "failed"
+)
+
+// maxImportSize limits the size of an import file
+const maxImportSize = 10 << 20
+
+// maxImportRows limits the number of products in an import file
+const maxImportRows = 5000
+
+// rowBatchSize is the number of rows the worker reads at once
+const rowBatchSize = 100
+
+// jobLease is how long a processing job may go without a heartbeat of its worker, after that the worker
+// is considered stopped and the job is requeued for another one
+const jobLease = 5 * time.Minute
+
+// Job represents an import of products of a seller from a file, rows are counted as they are processed
+type Job struct {
+	JobID         int        `json:"import_id"`
+	UserID        int        `json:"user_id"`
+	Format        string     `json:"format"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	CreatedRows   int        `json:"created_rows"`
+	UpdatedRows   int        `json:"updated_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Progress      int        `json:"progress"`
+	Error         *string    `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// setProgress calculates the percentage of processed rows
+func (j *Job) setProgress() {
+	j.Progress = 100
+	if j.TotalRows > 0 {
+		j.Progress = j.ProcessedRows * 100 / j.TotalRows
+	}
+}
+
+// Row represents a row of an import file, RowNumber is the line of the file it was read from.
+// Record is nil for rows which could not be read, they are failed right away
+type Row struct {
+	RowNumber   int               `json:"row"`
+	ExternalSKU *string           `json:"external_sku,omitempty"`
+	Status      string            `json:"status"`
+	ProductID   *int              `json:"product_id,omitempty"`
+	Errors      map[string]string `json:"errors,omitempty"`
+	Record      *Record           `json:"-"`
+}
+
+// fail marks the row as failed with the errors of its fields
+func (r *Row) fail(errs map[string]string) {
+	r.Status = RowStatusFailed
+	r.Errors = errs
+}
+
+// Record represents a product described by a row, it is the JSON object of a JSONL line
+type Record struct {
+	ExternalSKU  string              `json:"external_sku"`
+	Price        money.Money         `json:"price"`
+	Currency     string              `json:"currency"`
+	CategoryID   int                 `json:"category_id"`
+	Version      int                 `json:"version,omitempty"`
+	Translations []RecordTranslation `json:"translations"`
+}
+
+// RecordTranslation represents a translation of an imported product
+type RecordTranslation struct {
+	Language           string          `json:"language"`
+	ProductName        string          `json:"product_name"`
+	ProductDescription *string         `json:"product_description,omitempty"`
+	Attributes         json.RawMessage `json:"attributes,omitempty"`
+}
+
+// product returns the product of the seller described by the record
+func (r *Record) product(userID int) *product.Product {
+	p := &product.Product{
+		Price:      r.Price,
+		Currency:   r.Currency,
+		CategoryID: r.CategoryID,
+		UserID:     userID,
+		Version:    r.Version,
+	}
+
+	for _, translation := range r.Translations {
+		p.Translations = append(p.Translations, &product_translation.Translation{
+			Language:           translation.Language,
+			ProductName:        translation.ProductName,
+			ProductDescription: translation.ProductDescription,
+			Attributes:         translation.Attributes,
+		})
+	}
+
+	return p
+}
+
+// Repository Errors
+var (
+	ErrJobNotFound      = errors.New("import job not found")
+	ErrConnectionFailed = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrInvalidFile   = errors.New("import file cannot be read")
+	ErrEmptyFile     = errors.New("import file has no rows")
+	ErrTooManyRows   = errors.New("import file has too many rows")
+	ErrFileTooLarge  = errors.New("import file is too large")
+	ErrInvalidFormat = errors.New("import format must be csv or jsonl")
+)
+
+// Handler Errors
+var (
+	ErrInvalidID     = errors.New("invalid import id was sent")
+	ErrInvalidParams = errors.New("invalid import parameters were sent")
+	ErrNoFile        = errors.New("import file was not sent")
+)
@@ -0,0 +1,423 @@
+package product_import
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// jobColumns are the columns of product_imports scanned by scanJob
+const jobColumns = `
+		import_id, user_id, format, status, total_rows, processed_rows, created_rows, updated_rows, failed_rows,
+		error, created_at, started_at, finished_at`
+
+// CreateJob method saves the job with all rows of its file in one transaction, rows which could not be read
+// are saved failed and counted as processed right away
+func (r *Repository) CreateJob(ctx context.Context, job *Job, rows []*Row) error {
+	const op = "CreateJob"
+
+	jobQuery := fmt.Sprintf(`
+		INSERT INTO
+		    product_imports (user_id, format, total_rows, processed_rows, failed_rows)
+		VALUES
+		    ($1, $2, $3, $4, $4)
+		RETURNING %s`, jobColumns)
+
+	rowsQuery := `
+		INSERT INTO
+		    product_import_rows (import_id, row_number, external_sku, payload, status, errors)
+		SELECT
+		    $1, r.row_number, r.external_sku, r.payload, r.status, r.errors
+		FROM
+		    unnest($2::integer[], $3::varchar[], $4::jsonb[], $5::varchar[], $6::jsonb[])
+		        AS r(row_number, external_sku, payload, status, errors)`
+
+	var (
+		numbers  = make([]int, 0, len(rows))
+		skus     = make([]*string, 0, len(rows))
+		payloads = make([]*string, 0, len(rows))
+		statuses = make([]string, 0, len(rows))
+		errs     = make([]*string, 0, len(rows))
+		failed   = 0
+	)
+
+	for _, row := range rows {
+		payload, err := marshalNullable(row.Record)
+		if err != nil {
+			return fmt.Errorf("%s: marshal row %d: %w", op, row.RowNumber, err)
+		}
+
+		rowErrors, err := marshalNullable(row.Errors)
+		if err != nil {
+			return fmt.Errorf("%s: marshal errors of row %d: %w", op, row.RowNumber, err)
+		}
+
+		if row.Status == RowStatusFailed {
+			failed++
+		}
+
+		numbers = append(numbers, row.RowNumber)
+		skus = append(skus, row.ExternalSKU)
+		payloads = append(payloads, payload)
+		statuses = append(statuses, row.Status)
+		errs = append(errs, rowErrors)
+	}
+
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		created, err := scanJob(tx.QueryRow(ctx, jobQuery, job.UserID, job.Format, len(rows), failed))
+		if err != nil {
+			if postgres.IsPgErr(err) {
+				err = postgres.Conv2CustomErr(err)
+			}
+
+			var pgErr *postgres.PostgresErr
+			if errors.As(err, &pgErr) {
+				switch pgErr.Code {
+				case "08000", "08001", "08003", "08006":
+					return postgres.ErrDoQuery(op, ErrConnectionFailed)
+				default:
+					return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+				}
+			}
+			return postgres.ErrDoQuery(op, err)
+		}
+
+		if _, err = tx.Exec(ctx, rowsQuery, created.JobID, numbers, skus, payloads, statuses, errs); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		*job = *created
+
+		return nil
+	})
+}
+
+// GetJob method gets the job by its id
+func (r *Repository) GetJob(ctx context.Context, jobID int) (*Job, error) {
+	const op = "GetJob"
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM
+		    product_imports
+		WHERE
+		    import_id = $1`, jobColumns)
+
+	job, err := scanJob(r.client.Pool.QueryRow(ctx, query, jobID))
+	if err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	return job, nil
+}
+
+// ClaimNext method moves the oldest pending job to processing and returns it, jobs locked by another
+// worker are skipped. ErrJobNotFound is returned when there is nothing to process
+func (r *Repository) ClaimNext(ctx context.Context) (*Job, error) {
+	const op = "ClaimNext"
+
+	query := fmt.Sprintf(`
+		UPDATE
+		    product_imports
+		SET
+		    status = 'processing',
+		    started_at = coalesce(started_at, now()),
+		    heartbeat_at = now()
+		WHERE
+		    import_id = (
+		        SELECT
+		            import_id
+		        FROM
+		            product_imports
+		        WHERE
+		            status = 'pending'
+		        ORDER BY
+		            import_id
+		        LIMIT 1
+		        FOR UPDATE SKIP LOCKED)
+		RETURNING %s`, jobColumns)
+
+	job, err := scanJob(r.client.Pool.QueryRow(ctx, query))
+	if err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	return job, nil
+}
+
+// Requeue method moves processing jobs without a heartbeat for the lease back to pending, they were left
+// by a stopped worker, while jobs of live workers are kept. Processed rows of requeued jobs are kept
+func (r *Repository) Requeue(ctx context.Context, lease time.Duration) (int, error) {
+	const op = "Requeue"
+
+	query := `
+		UPDATE
+		    product_imports
+		SET
+		    status = 'pending'
+		WHERE
+		    status = 'processing'
+		AND
+		    (heartbeat_at IS NULL OR heartbeat_at < now() - make_interval(secs => $1))`
+
+	tag, err := r.client.Pool.Exec(ctx, query, lease.Seconds())
+	if err != nil {
+		return 0, postgres.ErrExec(op, err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// GetPendingRows method gets up to limit rows of the job which are not processed yet in the order of the file
+func (r *Repository) GetPendingRows(ctx context.Context, jobID int, limit int) ([]*Row, error) {
+	const op = "GetPendingRows"
+
+	query := `
+		SELECT
+		    row_number, external_sku, status, product_id, errors, payload
+		FROM
+		    product_import_rows
+		WHERE
+		    import_id = $1
+		AND
+		    status = 'pending'
+		ORDER BY
+		    row_number
+		LIMIT $2`
+
+	rows, err := r.client.Pool.Query(ctx, query, jobID, limit)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	pending := []*Row{}
+
+	for rows.Next() {
+		var row Row
+		err = rows.Scan(
+			&row.RowNumber,
+			&row.ExternalSKU,
+			&row.Status,
+			&row.ProductID,
+			&row.Errors,
+			&row.Record,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		pending = append(pending, &row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return pending, nil
+}
+
+// FinishRow method saves the result of the pending row and counts it in its job in one transaction,
+// a row which is already processed is left as is, so it is never counted twice. Counting the row
+// is the heartbeat of the worker processing the job
+func (r *Repository) FinishRow(ctx context.Context, jobID int, row *Row) error {
+	const op = "FinishRow"
+
+	rowQuery := `
+		UPDATE
+		    product_import_rows
+		SET
+		    status = $3,
+		    product_id = $4,
+		    errors = $5::jsonb
+		WHERE
+		    import_id = $1
+		AND
+		    row_number = $2
+		AND
+		    status = 'pending'`
+
+	jobQuery := `
+		UPDATE
+		    product_imports
+		SET
+		    processed_rows = processed_rows + 1,
+		    created_rows = created_rows + ($2 = 'created')::integer,
+		    updated_rows = updated_rows + ($2 = 'updated')::integer,
+		    failed_rows = failed_rows + ($2 = 'failed')::integer,
+		    heartbeat_at = now()
+		WHERE
+		    import_id = $1`
+
+	rowErrors, err := marshalNullable(row.Errors)
+	if err != nil {
+		return fmt.Errorf("%s: marshal errors of row %d: %w", op, row.RowNumber, err)
+	}
+
+	return r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		tag, err := tx.Exec(ctx, rowQuery, jobID, row.RowNumber, row.Status, row.ProductID, rowErrors)
+		if err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		if _, err = tx.Exec(ctx, jobQuery, jobID, row.Status); err != nil {
+			return postgres.ErrExec(op, err)
+		}
+
+		return nil
+	})
+}
+
+// FinishJob method moves the job to its final status, errMsg is the reason a failed job was stopped
+func (r *Repository) FinishJob(ctx context.Context, jobID int, status string, errMsg *string) error {
+	const op = "FinishJob"
+
+	query := `
+		UPDATE
+		    product_imports
+		SET
+		    status = $2,
+		    error = $3,
+		    finished_at = now()
+		WHERE
+		    import_id = $1`
+
+	tag, err := r.client.Pool.Exec(ctx, query, jobID, status, errMsg)
+	if err != nil {
+		return postgres.ErrExec(op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// GetFailedRows method returns failed rows of the job in the order of the file and total data for metadata
+func (r *Repository) GetFailedRows(ctx context.Context, jobID int, filters common.Filters) ([]*Row, int, error) {
+	const op = "GetFailedRows"
+
+	query := `
+		SELECT
+		    count(*) OVER(), row_number, external_sku, status, product_id, errors
+		FROM
+		    product_import_rows
+		WHERE
+		    import_id = $1
+		AND
+		    status = 'failed'
+		ORDER BY
+		    row_number
+		LIMIT $2
+		OFFSET $3`
+
+	rows, err := r.client.Pool.Query(ctx, query, jobID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, 0, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	failed := []*Row{}
+
+	for rows.Next() {
+		var row Row
+		err = rows.Scan(
+			&totalRecords,
+			&row.RowNumber,
+			&row.ExternalSKU,
+			&row.Status,
+			&row.ProductID,
+			&row.Errors,
+		)
+		if err != nil {
+			return nil, 0, postgres.ErrScan(op, err)
+		}
+
+		failed = append(failed, &row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, postgres.ErrReadRows(op, err)
+	}
+
+	return failed, totalRecords, nil
+}
+
+// scanner is satisfied by both pgx.Row and pgx.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row scanner) (*Job, error) {
+	var job Job
+
+	err := row.Scan(
+		&job.JobID,
+		&job.UserID,
+		&job.Format,
+		&job.Status,
+		&job.TotalRows,
+		&job.ProcessedRows,
+		&job.CreatedRows,
+		&job.UpdatedRows,
+		&job.FailedRows,
+		&job.Error,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.setProgress()
+
+	return &job, nil
+}
+
+// marshalNullable encodes the value to JSON for a jsonb column, nil pointers and empty maps are stored as NULL
+func marshalNullable(value any) (*string, error) {
+	switch v := value.(type) {
+	case *Record:
+		if v == nil {
+			return nil, nil
+		}
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(encoded)
+
+	return &s, nil
+}
@@ -0,0 +1,233 @@
+package product_import
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"ngMarketplace/internal/common"
+	"ngMarketplace/internal/product"
+	"ngMarketplace/pkg/validator"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Storage interface {
+	CreateJob(ctx context.Context, job *Job, rows []*Row) error
+	GetJob(ctx context.Context, jobID int) (*Job, error)
+	ClaimNext(ctx context.Context) (*Job, error)
+	Requeue(ctx context.Context, lease time.Duration) (int, error)
+	GetPendingRows(ctx context.Context, jobID int, limit int) ([]*Row, error)
+	FinishRow(ctx context.Context, jobID int, row *Row) error
+	FinishJob(ctx context.Context, jobID int, status string, errMsg *string) error
+	GetFailedRows(ctx context.Context, jobID int, filters common.Filters) ([]*Row, int, error)
+}
+
+// ProductImporter creates or updates a product of the seller by its external sku,
+// it reports whether the product was created
+type ProductImporter interface {
+	ImportProduct(ctx context.Context, sku string, product *product.Product) (bool, error)
+}
+
+type Service struct {
+	Repository Storage
+	Products   ProductImporter
+	uploaded   chan struct{}
+}
+
+func NewUseCase(repository Storage, products ProductImporter) *Service {
+	return &Service{
+		Repository: repository,
+		Products:   products,
+		uploaded:   make(chan struct{}, 1),
+	}
+}
+
+// Uploaded signals the worker that a new job is waiting, signals of several uploads may be merged into one
+func (s *Service) Uploaded() <-chan struct{} {
+	return s.uploaded
+}
+
+// CreateJob reads the file and saves its rows as a pending job of the seller, the format is taken
+// from the file name when it is not provided. Malformed rows do not stop the import, they are failed
+// with their errors, while a file that cannot be read at all is rejected
+func (s *Service) CreateJob(ctx context.Context, userID int, format string, filename string, file io.Reader) (*Job, error) {
+	if format == "" {
+		format = formatOf(filename)
+	}
+
+	v := validator.New()
+
+	v.Check(userID > 0, "user_id", "must be greater than zero")
+	v.Check(validator.In(format, FormatCSV, FormatJSONL), "format", "must be csv or jsonl")
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidParams, v.Errors)
+	}
+
+	rows, err := parseFile(format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{UserID: userID, Format: format}
+
+	if err = s.Repository.CreateJob(ctx, job, rows); err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	select {
+	case s.uploaded <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// formatOf guesses the format of the file by its extension
+func formatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	default:
+		return ""
+	}
+}
+
+func (s *Service) GetJob(ctx context.Context, jobID int) (*Job, error) {
+	return s.Repository.GetJob(ctx, jobID)
+}
+
+// GetRowErrors returns failed rows of the job with their errors in the order of the file
+func (s *Service) GetRowErrors(ctx context.Context, jobID int, filters getRowErrorsRequest) ([]*Row, common.Metadata, error) {
+	if filters.Page == 0 {
+		filters.Page = 1
+	}
+
+	if filters.PageSize == 0 {
+		filters.PageSize = 20
+	}
+
+	if filters.Sort == "" {
+		filters.Sort = "row"
+	}
+
+	filters.SortSafeList = []string{"row"}
+
+	v := validator.New()
+
+	if common.ValidateFilters(v, filters.Filters); !v.Valid() {
+		return nil, common.Metadata{}, fmt.Errorf("%w: %w", common.ErrFilterValidationFailed, v.Errors)
+	}
+
+	if _, err := s.Repository.GetJob(ctx, jobID); err != nil {
+		return nil, common.Metadata{}, err
+	}
+
+	rows, totalRecords, err := s.Repository.GetFailedRows(ctx, jobID, filters.Filters)
+	if err != nil {
+		return nil, common.Metadata{}, fmt.Errorf("failed to get import errors: %w", err)
+	}
+
+	return rows, common.CalculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Requeue returns jobs left processing by a stopped worker to the queue, a job is considered left
+// when its worker has not finished a row for jobLease
+func (s *Service) Requeue(ctx context.Context) (int, error) {
+	return s.Repository.Requeue(ctx, jobLease)
+}
+
+// ProcessNext imports rows of the oldest pending job and reports whether there was one. A job stopped by ctx
+// stays processing and is requeued once its lease expires, any other error fails the job
+func (s *Service) ProcessNext(ctx context.Context) (bool, error) {
+	job, err := s.Repository.ClaimNext(ctx)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to claim import job: %w", err)
+	}
+
+	err = s.process(ctx, job)
+	switch {
+	case ctx.Err() != nil:
+		return true, ctx.Err()
+	case err != nil:
+		msg := err.Error()
+		if finishErr := s.Repository.FinishJob(ctx, job.JobID, JobStatusFailed, &msg); finishErr != nil {
+			return true, fmt.Errorf("failed to fail import job %d: %w: %w", job.JobID, err, finishErr)
+		}
+		return true, fmt.Errorf("import job %d failed: %w", job.JobID, err)
+	}
+
+	if err = s.Repository.FinishJob(ctx, job.JobID, JobStatusCompleted, nil); err != nil {
+		return true, fmt.Errorf("failed to complete import job %d: %w", job.JobID, err)
+	}
+
+	return true, nil
+}
+
+// process imports pending rows of the job batch by batch, every row is saved as soon as it is imported,
+// so imported rows are kept when the job is stopped
+func (s *Service) process(ctx context.Context, job *Job) error {
+	for {
+		rows, err := s.Repository.GetPendingRows(ctx, job.JobID, rowBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			if err = s.importRow(ctx, job, row); err != nil {
+				return err
+			}
+
+			if err = s.Repository.FinishRow(ctx, job.JobID, row); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// importRow creates or updates the product of the row, errors caused by the row itself fail only the row
+func (s *Service) importRow(ctx context.Context, job *Job, row *Row) error {
+	if row.Record == nil {
+		row.fail(map[string]string{"row": "could not be read"})
+		return nil
+	}
+
+	p := row.Record.product(job.UserID)
+
+	created, err := s.Products.ImportProduct(ctx, row.Record.ExternalSKU, p)
+
+	var errs validator.Errors
+	switch {
+	case errors.As(err, &errs):
+		row.fail(errs)
+	case errors.Is(err, product.ErrInvalidForeignKey):
+		row.fail(map[string]string{"category_id": "category does not exist"})
+	case errors.Is(err, product.ErrDeletedSKU):
+		row.fail(map[string]string{"external_sku": "product with the sku was deleted, use another sku"})
+	case errors.Is(err, product.ErrVersionMismatch):
+		row.fail(map[string]string{"version": "product was changed since the version, export it again"})
+	case errors.Is(err, product.ErrDuplicateProduct):
+		row.fail(map[string]string{"external_sku": "product with the sku is being imported by another job, upload the row again"})
+	case err != nil:
+		return fmt.Errorf("row %d: %w", row.RowNumber, err)
+	default:
+		row.Status = RowStatusUpdated
+		if created {
+			row.Status = RowStatusCreated
+		}
+		row.ProductID = &p.ProductID
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package product_import
+
+import (
+	"context"
+	"ngMarketplace/pkg/logger"
+	"time"
+)
+
+// pollInterval is how often the worker looks for jobs when it is not signalled about uploads,
+// e.g. jobs uploaded to another instance
+const pollInterval = time.Minute
+
+// JobProcessor processes import jobs one by one
+type JobProcessor interface {
+	Requeue(ctx context.Context) (int, error)
+	ProcessNext(ctx context.Context) (bool, error)
+	Uploaded() <-chan struct{}
+}
+
+// Worker processes pending import jobs until ctx is done, it is meant to be started with async.BackgroundRunner
+type Worker struct {
+	useCase JobProcessor
+	logger  logger.Logger
+}
+
+func NewWorker(useCase JobProcessor, logger logger.Logger) *Worker {
+	return &Worker{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+// Run processes jobs as they are uploaded, a failed job is only logged. Jobs abandoned by stopped workers
+// of any instance are requeued at the start and on every poll
+func (w *Worker) Run(ctx context.Context) {
+	const op = "Worker.Run"
+
+	w.requeue(ctx)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := w.useCase.ProcessNext(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				w.logger.Error("%s: %v", op, err)
+			}
+
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.useCase.Uploaded():
+		case <-ticker.C:
+			w.requeue(ctx)
+		}
+	}
+}
+
+// requeue returns abandoned jobs to the queue, a failure is only logged and retried on the next poll
+func (w *Worker) requeue(ctx context.Context) {
+	const op = "Worker.requeue"
+
+	requeued, err := w.useCase.Requeue(ctx)
+	if err != nil {
+		w.logger.Error("%s: requeue interrupted jobs: %v", op, err)
+	} else if requeued > 0 {
+		w.logger.Info("%s: requeued %d interrupted jobs", op, requeued)
+	}
+}
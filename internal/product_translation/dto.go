@@ -1 +1,28 @@
 package product_translation
+
+import "encoding/json"
+
+// translationURIRequest represents the param request for addressing a translation
+type translationURIRequest struct {
+	ProductID int64  `uri:"id" binding:"required,min=1"`
+	Language  string `uri:"language" binding:"required,oneof=tj ru en"`
+}
+
+// productURIRequest represents the param request for getting all translations of a product
+type productURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// createTranslationRequest represents a request body for creating a translation
+type createTranslationRequest struct {
+	ProductName        string          `json:"product_name" binding:"required"`
+	ProductDescription *string         `json:"product_description"`
+	Attributes         json.RawMessage `json:"attributes"`
+}
+
+// updateTranslationRequest represents a request body for updating a translation
+type updateTranslationRequest struct {
+	ProductName        *string         `json:"product_name"`
+	ProductDescription *string         `json:"product_description"`
+	Attributes         json.RawMessage `json:"attributes"`
+}
@@ -1 +1,217 @@
 package product_translation
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	translationsURL = "/products/:id/translations"
+	translationURL  = "/products/:id/translations/:language"
+)
+
+type UseCase interface {
+	CreateTranslation(ctx context.Context, translation *Translation) error
+	GetTranslation(ctx context.Context, productID int64, language string) (*Translation, error)
+	GetTranslations(ctx context.Context, productID int64) ([]*Translation, error)
+	UpdateTranslation(ctx context.Context, productID int64, language string, request *updateTranslationRequest) (*Translation, error)
+	DeleteTranslation(ctx context.Context, productID int64, language string) error
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(translationURL, h.createTranslationHandler)
+	router.GET(translationURL, h.showTranslationHandler)
+	router.PATCH(translationURL, h.updateTranslationHandler)
+	router.DELETE(translationURL, h.deleteTranslationHandler)
+	router.GET(translationsURL, h.listTranslationsHandler)
+}
+
+// createTranslationHandler creates a translation of the product in the language
+func (h *Handler) createTranslationHandler(ctx *gin.Context) {
+	const op = "createTranslationHandler"
+
+	var uri translationURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id and language (tj, ru, en)")
+		return
+	}
+
+	var req createTranslationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	translation := &Translation{
+		ProductID:          int(uri.ProductID),
+		Language:           uri.Language,
+		ProductName:        req.ProductName,
+		ProductDescription: req.ProductDescription,
+		Attributes:         req.Attributes,
+	}
+
+	if err := h.useCase.CreateTranslation(ctx, translation); err != nil {
+		h.logger.Error("%s: h.useCase.CreateTranslation: %v", op, err)
+		switch {
+		case errors.Is(err, ErrTranslationValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		case errors.Is(err, ErrProductNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Product you are translating does not exist")
+		case errors.Is(err, ErrDuplicateTranslation):
+			apperror.WriteConflictResponse(ctx, err, "Product already has a translation in this language")
+		case errors.Is(err, ErrConnectionFailed):
+			apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"translation": translation}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"translation": translation})
+		return
+	}
+}
+
+// showTranslationHandler gets a translation of the product in the language
+func (h *Handler) showTranslationHandler(ctx *gin.Context) {
+	const op = "showTranslationHandler"
+
+	var uri translationURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id and language (tj, ru, en)")
+		return
+	}
+
+	translation, err := h.useCase.GetTranslation(ctx, uri.ProductID, uri.Language)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetTranslation: %v", op, err)
+		switch {
+		case errors.Is(err, ErrTranslationNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Translation you are seeking does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"translation": translation}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"translation": translation})
+		return
+	}
+}
+
+// updateTranslationHandler updates name, description or attributes of the translation
+func (h *Handler) updateTranslationHandler(ctx *gin.Context) {
+	const op = "updateTranslationHandler"
+
+	var uri translationURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id and language (tj, ru, en)")
+		return
+	}
+
+	var input updateTranslationRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	translation, err := h.useCase.UpdateTranslation(ctx, uri.ProductID, uri.Language, &input)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.UpdateTranslation: %v", op, err)
+		switch {
+		case errors.Is(err, ErrTranslationNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Translation you are seeking to update does not exist")
+		case errors.Is(err, ErrTranslationValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"translation": translation}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"translation": translation})
+		return
+	}
+}
+
+// deleteTranslationHandler deletes the translation softly
+func (h *Handler) deleteTranslationHandler(ctx *gin.Context) {
+	const op = "deleteTranslationHandler"
+
+	var uri translationURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id and language (tj, ru, en)")
+		return
+	}
+
+	if err := h.useCase.DeleteTranslation(ctx, uri.ProductID, uri.Language); err != nil {
+		h.logger.Error("%s: h.useCase.DeleteTranslation: %v", op, err)
+		switch {
+		case errors.Is(err, ErrTranslationNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Translation you are seeking to delete does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"message": "translation was successfully deleted"}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"message": "translation was successfully deleted"})
+		return
+	}
+}
+
+// listTranslationsHandler returns all translations of the product
+func (h *Handler) listTranslationsHandler(ctx *gin.Context) {
+	const op = "listTranslationsHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidParams, "Provide correct product id")
+		return
+	}
+
+	translations, err := h.useCase.GetTranslations(ctx, uri.ProductID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetTranslations: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"translations": translations}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"translations": translations})
+		return
+	}
+}
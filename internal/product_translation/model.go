@@ -1 +1,58 @@
 package product_translation
+
+import (
+	"encoding/json"
+	"errors"
+	"ngMarketplace/pkg/validator"
+	"time"
+	"unicode/utf8"
+)
+
+// Translation represents localized name, description and attributes of a product
+type Translation struct {
+	TranslationID      int             `json:"translation_id"`
+	ProductID          int             `json:"product_id"`
+	Language           string          `json:"language"`
+	ProductName        string          `json:"product_name"`
+	ProductDescription *string         `json:"product_description"`
+	Attributes         json.RawMessage `json:"attributes"`
+	CreatedAt          time.Time       `json:"-"`
+	UpdatedAt          time.Time       `json:"-"`
+	DeletedAt          *time.Time      `json:"-"`
+}
+
+// ValidateTranslation checks the fields of Translation, it is exported
+// so products could validate their initial translations before creating them
+func ValidateTranslation(v *validator.Validator, translation *Translation) {
+	v.Check(validator.In(translation.Language, "tj", "ru", "en"), "language", "must be tj, ru, or en")
+	v.Check(translation.ProductName != "", "product_name", "must be provided")
+	v.Check(utf8.RuneCountInString(translation.ProductName) <= 200, "product_name", "must not be more than 200 characters long")
+
+	if translation.ProductDescription != nil {
+		v.Check(utf8.RuneCountInString(*translation.ProductDescription) <= 10_000, "product_description", "must not be more than 10000 characters long")
+	}
+
+	if len(translation.Attributes) > 0 {
+		var attributes map[string]interface{}
+		v.Check(json.Unmarshal(translation.Attributes, &attributes) == nil && attributes != nil, "attributes", "must be a JSON object")
+	}
+}
+
+// Repository Errors
+var (
+	ErrDuplicateTranslation = errors.New("translation for this language already exists")
+	ErrProductNotFound      = errors.New("product not found")
+	ErrTranslationNotFound  = errors.New("translation not found")
+	ErrConnectionFailed     = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrTranslationValidationFailed = errors.New("translation validation failed")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON      = errors.New("failed binding json")
+	ErrInvalidParams = errors.New("invalid product id or language was sent")
+)
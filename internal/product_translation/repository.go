@@ -1 +1,350 @@
 package product_translation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// Create method creates a new translation of an active product
+func (r *Repository) Create(ctx context.Context, translation *Translation) error {
+	return r.create(ctx, r.client.Pool, translation)
+}
+
+// CreateTx method creates a new translation within tx, so it could be a part of product creation
+func (r *Repository) CreateTx(ctx context.Context, tx postgres.Tx, translation *Translation) error {
+	return r.create(ctx, tx, translation)
+}
+
+func (r *Repository) create(ctx context.Context, executor postgres.Executor, translation *Translation) error {
+	const op = "Create"
+
+	query := `
+		INSERT INTO
+		    product_translations (product_id, language, product_name, product_description, attributes)
+		SELECT
+		    $1::integer, $2::varchar, $3::text, $4::text, COALESCE($5::jsonb, '{}'::jsonb)
+		WHERE EXISTS
+		    (SELECT 1 FROM products WHERE product_id = $1 AND active = true)
+		RETURNING translation_id, attributes, created_at, updated_at`
+
+	args := []interface{}{
+		translation.ProductID,
+		translation.Language,
+		translation.ProductName,
+		translation.ProductDescription,
+		translation.Attributes,
+	}
+
+	if err := executor.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&translation.TranslationID,
+		&translation.Attributes,
+		&translation.CreatedAt,
+		&translation.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return ErrProductNotFound
+		}
+
+		if postgres.IsPgErr(err) {
+			err = postgres.Conv2CustomErr(err)
+		}
+
+		var pgErr *postgres.PostgresErr
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return postgres.ErrDoQuery(op, ErrDuplicateTranslation)
+			case "23503":
+				return postgres.ErrDoQuery(op, ErrProductNotFound)
+			case "08000", "08001", "08003", "08006":
+				return postgres.ErrDoQuery(op, ErrConnectionFailed)
+			default:
+				return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+			}
+		}
+		return postgres.ErrDoQuery(op, err)
+	}
+
+	return nil
+}
+
+// UpsertTx method creates the translation of the product in its language or replaces the existing one within tx,
+// so a product could be imported repeatedly. It reports whether the translation was written, an existing translation
+// equal to the given one is left as is and the fields of translation are not filled then
+func (r *Repository) UpsertTx(ctx context.Context, tx postgres.Tx, translation *Translation) (bool, error) {
+	const op = "UpsertTx"
+
+	query := `
+		INSERT INTO
+		    product_translations (product_id, language, product_name, product_description, attributes)
+		VALUES
+		    ($1, $2, $3, $4, COALESCE($5::jsonb, '{}'::jsonb))
+		ON CONFLICT (product_id, language) WHERE deleted_at IS NULL DO UPDATE
+		SET
+		    product_name = EXCLUDED.product_name,
+		    product_description = EXCLUDED.product_description,
+		    attributes = EXCLUDED.attributes
+		WHERE
+		    (product_translations.product_name, product_translations.product_description, product_translations.attributes)
+		    IS DISTINCT FROM
+		    (EXCLUDED.product_name, EXCLUDED.product_description, EXCLUDED.attributes)
+		RETURNING translation_id, attributes, created_at, updated_at`
+
+	args := []interface{}{
+		translation.ProductID,
+		translation.Language,
+		translation.ProductName,
+		translation.ProductDescription,
+		translation.Attributes,
+	}
+
+	if err := tx.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&translation.TranslationID,
+		&translation.Attributes,
+		&translation.CreatedAt,
+		&translation.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return false, nil
+		}
+		return false, postgres.ErrDoQuery(op, err)
+	}
+
+	return true, nil
+}
+
+// GetByLanguage method gets a translation of the product in the language
+func (r *Repository) GetByLanguage(ctx context.Context, productID int64, language string) (*Translation, error) {
+	const op = "GetByLanguage"
+
+	query := `
+		SELECT
+		    translation_id, product_id, language, product_name, product_description, attributes, created_at, updated_at, deleted_at
+		FROM
+		    product_translations
+		WHERE
+		    deleted_at IS NULL
+		AND
+			product_id = $1
+		AND
+		    language = $2
+		LIMIT 1`
+
+	var translation Translation
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		productID,
+		language,
+	).Scan(
+		&translation.TranslationID,
+		&translation.ProductID,
+		&translation.Language,
+		&translation.ProductName,
+		&translation.ProductDescription,
+		&translation.Attributes,
+		&translation.CreatedAt,
+		&translation.UpdatedAt,
+		&translation.DeletedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrTranslationNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	return &translation, nil
+}
+
+// GetByProductID method gets all translations of the product
+func (r *Repository) GetByProductID(ctx context.Context, productID int64) ([]*Translation, error) {
+	const op = "GetByProductID"
+
+	query := `
+		SELECT
+		    translation_id, product_id, language, product_name, product_description, attributes, created_at, updated_at, deleted_at
+		FROM
+		    product_translations
+		WHERE
+		    deleted_at IS NULL
+		AND
+			product_id = $1
+		ORDER BY
+		    language`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	translations := []*Translation{}
+
+	for rows.Next() {
+		var translation Translation
+		err = rows.Scan(
+			&translation.TranslationID,
+			&translation.ProductID,
+			&translation.Language,
+			&translation.ProductName,
+			&translation.ProductDescription,
+			&translation.Attributes,
+			&translation.CreatedAt,
+			&translation.UpdatedAt,
+			&translation.DeletedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		translations = append(translations, &translation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return translations, nil
+}
+
+// GetByProductIDs method gets all translations of the products at once, ordered by product
+func (r *Repository) GetByProductIDs(ctx context.Context, productIDs []int) ([]*Translation, error) {
+	const op = "GetByProductIDs"
+
+	query := `
+		SELECT
+		    translation_id, product_id, language, product_name, product_description, attributes, created_at, updated_at, deleted_at
+		FROM
+		    product_translations
+		WHERE
+		    deleted_at IS NULL
+		AND
+			product_id = ANY($1::int[])
+		ORDER BY
+		    product_id, language`
+
+	rows, err := r.client.Pool.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	translations := []*Translation{}
+
+	for rows.Next() {
+		var translation Translation
+		err = rows.Scan(
+			&translation.TranslationID,
+			&translation.ProductID,
+			&translation.Language,
+			&translation.ProductName,
+			&translation.ProductDescription,
+			&translation.Attributes,
+			&translation.CreatedAt,
+			&translation.UpdatedAt,
+			&translation.DeletedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		translations = append(translations, &translation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return translations, nil
+}
+
+// Update method updates translation name, description and attributes
+func (r *Repository) Update(ctx context.Context, translation *Translation) error {
+	const op = "Update"
+
+	query := `
+		UPDATE
+		    product_translations
+		SET
+		    product_name = $1,
+		    product_description = $2,
+		    attributes = COALESCE($3, '{}'::jsonb)
+		WHERE
+		    translation_id = $4
+		AND
+		    deleted_at IS NULL
+		RETURNING attributes, updated_at`
+
+	args := []interface{}{
+		translation.ProductName,
+		translation.ProductDescription,
+		translation.Attributes,
+		translation.TranslationID,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(&translation.Attributes, &translation.UpdatedAt); err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrTranslationNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	return nil
+}
+
+// SoftDelete method deletes translation softly by setting deleted_at
+func (r *Repository) SoftDelete(ctx context.Context, productID int64, language string) error {
+	const op = "Delete"
+
+	query := `
+		UPDATE
+		    product_translations
+		SET
+		    deleted_at = now()
+		WHERE
+		    product_id = $1
+		AND
+		    language = $2
+		AND
+		    deleted_at IS NULL
+		RETURNING deleted_at`
+
+	var deletedAt *time.Time
+	err := r.client.Pool.QueryRow(ctx, query, productID, language).Scan(&deletedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrTranslationNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	return nil
+}
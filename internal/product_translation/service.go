@@ -1 +1,80 @@
 package product_translation
+
+import (
+	"context"
+	"fmt"
+	"ngMarketplace/pkg/validator"
+)
+
+type Storage interface {
+	Create(ctx context.Context, translation *Translation) error
+	GetByLanguage(ctx context.Context, productID int64, language string) (*Translation, error)
+	GetByProductID(ctx context.Context, productID int64) ([]*Translation, error)
+	Update(ctx context.Context, translation *Translation) error
+	SoftDelete(ctx context.Context, productID int64, language string) error
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+func (s *Service) CreateTranslation(ctx context.Context, translation *Translation) error {
+	v := validator.New()
+
+	if ValidateTranslation(v, translation); !v.Valid() {
+		return fmt.Errorf("%w: %w", ErrTranslationValidationFailed, v.Errors)
+	}
+
+	if err := s.Repository.Create(ctx, translation); err != nil {
+		return fmt.Errorf("failed to create translation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetTranslation(ctx context.Context, productID int64, language string) (*Translation, error) {
+	return s.Repository.GetByLanguage(ctx, productID, language)
+}
+
+func (s *Service) GetTranslations(ctx context.Context, productID int64) ([]*Translation, error) {
+	return s.Repository.GetByProductID(ctx, productID)
+}
+
+func (s *Service) UpdateTranslation(ctx context.Context, productID int64, language string, request *updateTranslationRequest) (*Translation, error) {
+	translation, err := s.Repository.GetByLanguage(ctx, productID, language)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ProductName != nil {
+		translation.ProductName = *request.ProductName
+	}
+
+	if request.ProductDescription != nil {
+		translation.ProductDescription = request.ProductDescription
+	}
+
+	if request.Attributes != nil {
+		translation.Attributes = request.Attributes
+	}
+
+	v := validator.New()
+
+	if ValidateTranslation(v, translation); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrTranslationValidationFailed, v.Errors)
+	}
+
+	if err = s.Repository.Update(ctx, translation); err != nil {
+		return nil, err
+	}
+
+	return translation, nil
+}
+
+func (s *Service) DeleteTranslation(ctx context.Context, productID int64, language string) error {
+	return s.Repository.SoftDelete(ctx, productID, language)
+}
@@ -1 +1,42 @@
 package product_variant
+
+import (
+	"encoding/json"
+	"ngMarketplace/pkg/money"
+)
+
+// productURIRequest represents the param request for variants of a product
+type productURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// variantURIRequest represents the param request for a variant of a product
+type variantURIRequest struct {
+	ProductID int64 `uri:"id" binding:"required,min=1"`
+	VariantID int64 `uri:"variant_id" binding:"required,min=1"`
+}
+
+// createVariantRequest represents a request body for creating a variant
+type createVariantRequest struct {
+	SKU           string          `json:"sku" binding:"required"`
+	Price         *money.Money    `json:"price"`
+	StockQuantity int             `json:"stock_quantity"`
+	Attributes    json.RawMessage `json:"attributes" binding:"required"`
+}
+
+// updateVariantRequest represents a request body for updating a variant, clear_price drops the price override,
+// so the variant is sold at the price of its product again
+type updateVariantRequest struct {
+	SKU           *string         `json:"sku"`
+	Price         *money.Money    `json:"price"`
+	ClearPrice    bool            `json:"clear_price"`
+	StockQuantity *int            `json:"stock_quantity"`
+	Attributes    json.RawMessage `json:"attributes"`
+}
+
+// generateVariantsRequest represents a request body for generating variants from selected enum values
+type generateVariantsRequest struct {
+	Attributes    map[string][]string `json:"attributes" binding:"required"`
+	Price         *money.Money        `json:"price"`
+	StockQuantity int                 `json:"stock_quantity"`
+}
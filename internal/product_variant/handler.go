@@ -1 +1,256 @@
 package product_variant
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	variantsURL = "/products/:id/variants"
+	variantURL  = "/products/:id/variants/:variant_id"
+	generateURL = "/products/:id/variants/generate"
+)
+
+type UseCase interface {
+	CreateVariant(ctx context.Context, variant *Variant) error
+	GetVariant(ctx context.Context, productID int64, variantID int64) (*Variant, error)
+	GetVariants(ctx context.Context, productID int64) ([]*Variant, error)
+	UpdateVariant(ctx context.Context, productID int64, variantID int64, request *updateVariantRequest) (*Variant, error)
+	DeleteVariant(ctx context.Context, productID int64, variantID int64) error
+	GenerateVariants(ctx context.Context, productID int64, request *generateVariantsRequest) (*GenerateResult, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.POST(variantsURL, h.createVariantHandler)
+	router.GET(variantsURL, h.listVariantsHandler)
+	router.GET(variantURL, h.showVariantHandler)
+	router.PATCH(variantURL, h.updateVariantHandler)
+	router.DELETE(variantURL, h.deleteVariantHandler)
+	router.POST(generateURL, h.generateVariantsHandler)
+}
+
+// createVariantHandler creates a new variant of the product
+func (h *Handler) createVariantHandler(ctx *gin.Context) {
+	const op = "createVariantHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	var req createVariantRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	variant := &Variant{
+		ProductID:     int(uri.ProductID),
+		SKU:           req.SKU,
+		Price:         req.Price,
+		StockQuantity: req.StockQuantity,
+		Attributes:    req.Attributes,
+	}
+
+	if err := h.useCase.CreateVariant(ctx, variant); err != nil {
+		h.logger.Error("%s: h.useCase.CreateVariant: %v", op, err)
+		writeVariantError(ctx, err)
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusCreated, gin.H{"variant": variant}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"variant": variant})
+		return
+	}
+}
+
+// listVariantsHandler returns all variants of the product
+func (h *Handler) listVariantsHandler(ctx *gin.Context) {
+	const op = "listVariantsHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	variants, err := h.useCase.GetVariants(ctx, uri.ProductID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetVariants: %v", op, err)
+		apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"variants": variants}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"variants": variants})
+		return
+	}
+}
+
+// showVariantHandler gets a variant of the product
+func (h *Handler) showVariantHandler(ctx *gin.Context) {
+	const op = "showVariantHandler"
+
+	var uri variantURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id and variant id")
+		return
+	}
+
+	variant, err := h.useCase.GetVariant(ctx, uri.ProductID, uri.VariantID)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GetVariant: %v", op, err)
+		switch {
+		case errors.Is(err, ErrVariantNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Variant you are seeking does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"variant": variant}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"variant": variant})
+		return
+	}
+}
+
+// updateVariantHandler updates sku, price, stock or attributes of the variant
+func (h *Handler) updateVariantHandler(ctx *gin.Context) {
+	const op = "updateVariantHandler"
+
+	var uri variantURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id and variant id")
+		return
+	}
+
+	var input updateVariantRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	variant, err := h.useCase.UpdateVariant(ctx, uri.ProductID, uri.VariantID, &input)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.UpdateVariant: %v", op, err)
+		writeVariantError(ctx, err)
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"variant": variant}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"variant": variant})
+		return
+	}
+}
+
+// deleteVariantHandler makes Variant's field active false
+func (h *Handler) deleteVariantHandler(ctx *gin.Context) {
+	const op = "deleteVariantHandler"
+
+	var uri variantURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id and variant id")
+		return
+	}
+
+	if err := h.useCase.DeleteVariant(ctx, uri.ProductID, uri.VariantID); err != nil {
+		h.logger.Error("%s: h.useCase.DeleteVariant: %v", op, err)
+		switch {
+		case errors.Is(err, ErrVariantNotFound):
+			apperror.WriteNotFoundResponse(ctx, err, "Variant you are seeking to delete does not exist")
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	if err := router.WriteJSON(ctx, http.StatusOK, gin.H{"message": "variant was successfully deleted"}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"message": "variant was successfully deleted"})
+		return
+	}
+}
+
+// generateVariantsHandler creates variants for all combinations of the selected enum values
+func (h *Handler) generateVariantsHandler(ctx *gin.Context) {
+	const op = "generateVariantsHandler"
+
+	var uri productURIRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindUri: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrInvalidID, "Provide correct product id")
+		return
+	}
+
+	var req generateVariantsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindJSON: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, ErrBindJSON, "Something is missing or was not sent correctly")
+		return
+	}
+
+	result, err := h.useCase.GenerateVariants(ctx, uri.ProductID, &req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.GenerateVariants: %v", op, err)
+		writeVariantError(ctx, err)
+		return
+	}
+
+	if err = router.WriteJSON(ctx, http.StatusCreated, gin.H{"result": result}, nil); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusCreated, gin.H{"result": result})
+		return
+	}
+}
+
+// writeVariantError answers with the error of creating or updating a variant
+func writeVariantError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrVariantValidationFailed):
+		apperror.WriteBadRequestResponse(ctx, err, err.Error())
+	case errors.Is(err, ErrProductNotFound):
+		apperror.WriteNotFoundResponse(ctx, err, "Product of the variant does not exist")
+	case errors.Is(err, ErrVariantNotFound):
+		apperror.WriteNotFoundResponse(ctx, err, "Variant you are seeking to update does not exist")
+	case errors.Is(err, ErrDuplicateSKU):
+		apperror.WriteConflictResponse(ctx, err, "Product already has a variant with this sku")
+	case errors.Is(err, ErrDuplicateAttributes):
+		apperror.WriteConflictResponse(ctx, err, "Product already has a variant with these attributes")
+	case errors.Is(err, ErrSKUNotGenerated):
+		apperror.WriteConflictResponse(ctx, err, "Skus of variants were taken meanwhile, try generating again")
+	case errors.Is(err, ErrConnectionFailed):
+		apperror.WriteSrvUnResponse(ctx, err, "Database connection failed")
+	default:
+		apperror.WriteInternalErrResponse(ctx, err, "Internal server error")
+	}
+}
@@ -1 +1,168 @@
 package product_variant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ngMarketplace/internal/common/attribute_schema/parser"
+	"ngMarketplace/pkg/money"
+	"ngMarketplace/pkg/validator"
+	"regexp"
+	"time"
+)
+
+// Variant represents a sellable variant of a product, e.g. a size and color combination.
+// EffectivePrice is the price with discounts applied, it is only filled when variants are listed
+type Variant struct {
+	VariantID      int             `json:"variant_id"`
+	ProductID      int             `json:"product_id"`
+	SKU            string          `json:"sku"`
+	Price          *money.Money    `json:"price"`
+	StockQuantity  int             `json:"stock_quantity"`
+	Attributes     json.RawMessage `json:"attributes"`
+	EffectivePrice *money.Money    `json:"effective_price,omitempty"`
+	Active         bool            `json:"-"`
+	CreatedAt      time.Time       `json:"-"`
+	UpdatedAt      time.Time       `json:"-"`
+	DeletedAt      *time.Time      `json:"-"`
+}
+
+// BasePrice returns the own price of the variant or productPrice when the variant does not override it
+func (v *Variant) BasePrice(productPrice money.Money) money.Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return productPrice
+}
+
+// setCurrency brings prices of the variant to the currency of its product, variants have no currency of their own
+func (v *Variant) setCurrency(currency string) {
+	if v.Price != nil {
+		price := v.Price.RoundTo(currency)
+		v.Price = &price
+	}
+
+	if v.EffectivePrice != nil {
+		effectivePrice := v.EffectivePrice.RoundTo(currency)
+		v.EffectivePrice = &effectivePrice
+	}
+}
+
+var skuRX = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// validateVariant checks the fields of Variant, schema is the attribute_schema of the product category
+// and currency is the currency of the product
+func validateVariant(v *validator.Validator, variant *Variant, schema json.RawMessage, currency string) {
+	v.Check(validator.Matches(variant.SKU, skuRX), "sku", "must be 1-64 latin letters, digits, '.', '_' or '-'")
+	v.Check(variant.StockQuantity >= 0, "stock_quantity", "cannot be negative")
+	validatePrice(v, variant.Price, currency)
+
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(variant.Attributes, &attributes); err != nil || len(attributes) == 0 {
+		v.AddError("attributes", "must be a non-empty JSON object")
+		return
+	}
+
+	validateAttributes(v, attributes, schema)
+}
+
+// validatePrice checks an optional price of a variant in the currency of its product
+func validatePrice(v *validator.Validator, price *money.Money, currency string) {
+	if price == nil {
+		return
+	}
+
+	v.Check(!price.IsNegative(), "price", "cannot be negative")
+	v.Check(price.FitsIn(currency), "price", fmt.Sprintf("must not have more than %d decimal places", money.Exponent(currency)))
+}
+
+// validateAttributes checks that every variant attribute is declared in the category schema and has a correct value
+func validateAttributes(v *validator.Validator, attributes map[string]interface{}, schema json.RawMessage) {
+	info, err := parser.ExtractInformation(schema)
+	if err != nil {
+		v.AddError("attributes", "category of the product has no valid attribute_schema")
+		return
+	}
+
+	for name, value := range attributes {
+		field, ok := info.Property(name)
+		if !ok {
+			v.AddError(fmt.Sprintf("attributes.%s", name), "is not declared in the category attribute_schema")
+			continue
+		}
+
+		if err = field.CheckValue(value); err != nil {
+			v.AddError(fmt.Sprintf("attributes.%s", name), err.Error())
+		}
+	}
+}
+
+// GenerateResult reports which variants were created from the selected values and which combinations already existed
+type GenerateResult struct {
+	Created []*Variant        `json:"created"`
+	Skipped []json.RawMessage `json:"skipped"`
+}
+
+// maxGeneratedVariants limits the size of the cartesian product a single request can create
+const maxGeneratedVariants = 200
+
+// maxSKUAttempts limits how many suffixes are tried for a generated sku taken concurrently
+const maxSKUAttempts = 10
+
+// validateSelection checks that every selected attribute is an enum of the category schema
+// and that only its declared values are selected
+func validateSelection(v *validator.Validator, selection map[string][]string, schema json.RawMessage) {
+	v.Check(len(selection) > 0, "attributes", "at least one attribute must be selected")
+
+	info, err := parser.ExtractInformation(schema)
+	if err != nil {
+		v.AddError("attributes", "category of the product has no valid attribute_schema")
+		return
+	}
+
+	combinations := 1
+	for name, values := range selection {
+		key := fmt.Sprintf("attributes.%s", name)
+
+		field, ok := info.Property(name)
+		if !ok {
+			v.AddError(key, "is not declared in the category attribute_schema")
+			continue
+		}
+
+		v.Check(len(field.Enum) > 0, key, "must be an enum attribute")
+		v.Check(len(values) > 0, key, "at least one value must be selected")
+		v.Check(validator.Unique(values), key, "values must be unique")
+
+		for _, value := range values {
+			if err = field.CheckValue(value); err != nil {
+				v.AddError(key, err.Error())
+			}
+		}
+
+		combinations = min(combinations*max(len(values), 1), maxGeneratedVariants+1)
+	}
+
+	v.Check(combinations <= maxGeneratedVariants, "attributes", fmt.Sprintf("must not produce more than %d variants", maxGeneratedVariants))
+}
+
+// Repository Errors
+var (
+	ErrDuplicateSKU        = errors.New("variant with this sku already exists")
+	ErrDuplicateAttributes = errors.New("variant with these attributes already exists")
+	ErrProductNotFound     = errors.New("product not found")
+	ErrVariantNotFound     = errors.New("variant not found")
+	ErrSKUNotGenerated     = errors.New("no free sku could be generated for the variant")
+	ErrConnectionFailed    = errors.New("database connection failed")
+)
+
+// Service Errors
+var (
+	ErrVariantValidationFailed = errors.New("variant validation failed")
+)
+
+// Handler Errors
+var (
+	ErrBindJSON  = errors.New("failed binding json")
+	ErrInvalidID = errors.New("invalid product id or variant id was sent")
+)
@@ -1 +1,387 @@
 package product_variant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"ngMarketplace/pkg/postgres"
+	"time"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// Create method creates a new variant of an active product
+func (r *Repository) Create(ctx context.Context, variant *Variant) error {
+	const op = "Create"
+
+	query := `
+		INSERT INTO
+		    product_variants (product_id, sku, price, stock_quantity, attributes)
+		SELECT
+		    $1::integer, $2::varchar, $3::decimal, $4::integer, $5::jsonb
+		WHERE EXISTS
+		    (SELECT 1 FROM products WHERE product_id = $1 AND active = true)
+		RETURNING variant_id, created_at, active, updated_at`
+
+	args := []interface{}{
+		variant.ProductID,
+		variant.SKU,
+		variant.Price,
+		variant.StockQuantity,
+		variant.Attributes,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(
+		&variant.VariantID,
+		&variant.CreatedAt,
+		&variant.Active,
+		&variant.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		return convWriteErr(op, err)
+	}
+
+	return nil
+}
+
+// CreateBatch method creates variants of an active product in one transaction. Variants whose attributes
+// already exist are skipped, so only the created ones are returned. A sku taken in the meantime gets
+// a numeric suffix, e.g. "P12-BLACK-M-2", instead of failing the whole batch, while ErrSKUNotGenerated is returned
+// when maxSKUAttempts suffixes are taken as well, so it is not mistaken for an existing combination
+func (r *Repository) CreateBatch(ctx context.Context, variants []*Variant) ([]*Variant, error) {
+	const op = "CreateBatch"
+
+	query := `
+		INSERT INTO
+		    product_variants (product_id, sku, price, stock_quantity, attributes)
+		SELECT
+		    $1::integer, $2::varchar, $3::decimal, $4::integer, $5::jsonb
+		WHERE EXISTS
+		    (SELECT 1 FROM products WHERE product_id = $1 AND active = true)
+		ON CONFLICT DO NOTHING
+		RETURNING variant_id, attributes, created_at, active, updated_at`
+
+	conflictQuery := `
+		SELECT
+		    EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND attributes = $2::jsonb AND deleted_at IS NULL),
+		    EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND sku = $3 AND deleted_at IS NULL)`
+
+	created := make([]*Variant, 0, len(variants))
+
+	err := r.client.WithinTx(ctx, op, func(tx postgres.Tx) error {
+		for _, variant := range variants {
+			base := variant.SKU
+
+			for suffix := 2; ; suffix++ {
+				args := []interface{}{
+					variant.ProductID,
+					variant.SKU,
+					variant.Price,
+					variant.StockQuantity,
+					variant.Attributes,
+				}
+
+				err := tx.QueryRow(
+					ctx,
+					query,
+					args...,
+				).Scan(
+					&variant.VariantID,
+					&variant.Attributes,
+					&variant.CreatedAt,
+					&variant.Active,
+					&variant.UpdatedAt,
+				)
+				if err == nil {
+					created = append(created, variant)
+					break
+				}
+				if !errors.Is(err, postgres.ErrNoRows) {
+					return convWriteErr(op, err)
+				}
+
+				var attributesTaken, skuTaken bool
+				if err = tx.QueryRow(ctx, conflictQuery, variant.ProductID, variant.Attributes, variant.SKU).Scan(
+					&attributesTaken,
+					&skuTaken,
+				); err != nil {
+					return postgres.ErrDoQuery(op, err)
+				}
+
+				// the combination exists or the product is gone, nothing to create
+				if attributesTaken || !skuTaken {
+					break
+				}
+
+				if suffix > maxSKUAttempts {
+					return fmt.Errorf("%w: %s and %d suffixed skus are taken", ErrSKUNotGenerated, base, maxSKUAttempts-1)
+				}
+
+				variant.SKU = skuWithSuffix(base, suffix)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetByID method gets a variant of the product by ID
+func (r *Repository) GetByID(ctx context.Context, productID int64, variantID int64) (*Variant, error) {
+	const op = "GetByID"
+
+	query := `
+		SELECT
+		    v.variant_id, v.product_id, v.sku, v.price, v.stock_quantity, v.attributes, p.currency,
+		    v.created_at, v.active, v.updated_at, v.deleted_at
+		FROM
+		    product_variants v
+		JOIN
+		    products p ON p.product_id = v.product_id
+		WHERE
+		    v.active = true
+		AND
+			v.product_id = $1
+		AND
+		    v.variant_id = $2
+		LIMIT 1`
+
+	var (
+		variant  Variant
+		currency string
+	)
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		productID,
+		variantID,
+	).Scan(
+		&variant.VariantID,
+		&variant.ProductID,
+		&variant.SKU,
+		&variant.Price,
+		&variant.StockQuantity,
+		&variant.Attributes,
+		&currency,
+		&variant.CreatedAt,
+		&variant.Active,
+		&variant.UpdatedAt,
+		&variant.DeletedAt,
+	); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+
+	variant.setCurrency(currency)
+
+	return &variant, nil
+}
+
+// GetByProductID method gets all active variants of the product with their discounted prices
+func (r *Repository) GetByProductID(ctx context.Context, productID int64) ([]*Variant, error) {
+	const op = "GetByProductID"
+
+	query := `
+		SELECT
+		    v.variant_id, v.product_id, v.sku, v.price, v.stock_quantity, v.attributes,
+		    effective_price(v.product_id, v.variant_id, coalesce(v.price, p.price)), p.currency,
+		    v.created_at, v.active, v.updated_at, v.deleted_at
+		FROM
+		    product_variants v
+		JOIN
+		    products p ON p.product_id = v.product_id
+		WHERE
+		    v.active = true
+		AND
+			v.product_id = $1
+		ORDER BY
+		    v.variant_id`
+
+	rows, err := r.client.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	variants := []*Variant{}
+
+	for rows.Next() {
+		var (
+			variant  Variant
+			currency string
+		)
+		err = rows.Scan(
+			&variant.VariantID,
+			&variant.ProductID,
+			&variant.SKU,
+			&variant.Price,
+			&variant.StockQuantity,
+			&variant.Attributes,
+			&variant.EffectivePrice,
+			&currency,
+			&variant.CreatedAt,
+			&variant.Active,
+			&variant.UpdatedAt,
+			&variant.DeletedAt,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		variant.setCurrency(currency)
+		variants = append(variants, &variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return variants, nil
+}
+
+// Update method updates the variant entirely
+func (r *Repository) Update(ctx context.Context, variant *Variant) error {
+	const op = "Update"
+
+	query := `
+		UPDATE
+		    product_variants
+		SET
+		    sku = $1,
+		    price = $2,
+		    stock_quantity = $3,
+		    attributes = $4
+		WHERE
+		    variant_id = $5
+		AND
+		    active = true
+		RETURNING updated_at`
+
+	args := []interface{}{
+		variant.SKU,
+		variant.Price,
+		variant.StockQuantity,
+		variant.Attributes,
+		variant.VariantID,
+	}
+
+	if err := r.client.Pool.QueryRow(
+		ctx,
+		query,
+		args...,
+	).Scan(&variant.UpdatedAt); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return ErrVariantNotFound
+		}
+		return convWriteErr(op, err)
+	}
+
+	return nil
+}
+
+// SoftDelete method deletes variant softly, meaning that it makes active false
+func (r *Repository) SoftDelete(ctx context.Context, productID int64, variantID int64) error {
+	const op = "Delete"
+
+	query := `
+		UPDATE
+		    product_variants
+		SET
+		    deleted_at = now(),
+		    active = false
+		WHERE
+		    product_id = $1
+		AND
+		    variant_id = $2
+		AND
+		    active = true
+		RETURNING deleted_at`
+
+	var deletedAt *time.Time
+	err := r.client.Pool.QueryRow(ctx, query, productID, variantID).Scan(&deletedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrNoRows):
+			return ErrVariantNotFound
+		default:
+			return postgres.ErrDoQuery(op, err)
+		}
+	}
+
+	return nil
+}
+
+// GetSchemaAndCurrency method returns attribute_schema of the category and the currency of an active product
+func (r *Repository) GetSchemaAndCurrency(ctx context.Context, productID int64) (json.RawMessage, string, error) {
+	const op = "GetSchemaAndCurrency"
+
+	query := `
+		SELECT
+		    c.attribute_schema, p.currency
+		FROM
+		    products p
+		LEFT JOIN
+		    categories c ON c.category_id = p.category_id
+		WHERE
+		    p.active = true
+		AND
+		    p.product_id = $1`
+
+	var (
+		schema   json.RawMessage
+		currency string
+	)
+
+	if err := r.client.Pool.QueryRow(ctx, query, productID).Scan(&schema, &currency); err != nil {
+		if errors.Is(err, postgres.ErrNoRows) {
+			return nil, "", ErrProductNotFound
+		}
+		return nil, "", postgres.ErrDoQuery(op, err)
+	}
+
+	return schema, currency, nil
+}
+
+// convWriteErr converts errors of insert and update queries into repository errors
+func convWriteErr(op string, err error) error {
+	if postgres.IsPgErr(err) {
+		err = postgres.Conv2CustomErr(err)
+	}
+
+	var pgErr *postgres.PostgresErr
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505" && pgErr.ConstraintName == "idx_product_variants_sku":
+			return postgres.ErrDoQuery(op, ErrDuplicateSKU)
+		case pgErr.Code == "23505":
+			return postgres.ErrDoQuery(op, ErrDuplicateAttributes)
+		case pgErr.Code == "23503":
+			return postgres.ErrDoQuery(op, ErrProductNotFound)
+		case pgErr.Code == "08000", pgErr.Code == "08001", pgErr.Code == "08003", pgErr.Code == "08006":
+			return postgres.ErrDoQuery(op, ErrConnectionFailed)
+		default:
+			return postgres.ErrDoQuery(op, fmt.Errorf("unexpected database error: %w", err))
+		}
+	}
+	return postgres.ErrDoQuery(op, err)
+}
@@ -1 +1,266 @@
 package product_variant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"ngMarketplace/internal/common/attribute_schema/translit"
+	"ngMarketplace/pkg/validator"
+	"slices"
+	"strings"
+)
+
+type Storage interface {
+	Create(ctx context.Context, variant *Variant) error
+	GetByID(ctx context.Context, productID int64, variantID int64) (*Variant, error)
+	GetByProductID(ctx context.Context, productID int64) ([]*Variant, error)
+	Update(ctx context.Context, variant *Variant) error
+	SoftDelete(ctx context.Context, productID int64, variantID int64) error
+	GetSchemaAndCurrency(ctx context.Context, productID int64) (json.RawMessage, string, error)
+	CreateBatch(ctx context.Context, variants []*Variant) ([]*Variant, error)
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+func (s *Service) CreateVariant(ctx context.Context, variant *Variant) error {
+	schema, currency, err := s.Repository.GetSchemaAndCurrency(ctx, int64(variant.ProductID))
+	if err != nil {
+		return err
+	}
+
+	v := validator.New()
+
+	if validateVariant(v, variant, schema, currency); !v.Valid() {
+		return fmt.Errorf("%w: %w", ErrVariantValidationFailed, v.Errors)
+	}
+
+	variant.setCurrency(currency)
+
+	if err = s.Repository.Create(ctx, variant); err != nil {
+		return fmt.Errorf("failed to create variant: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetVariant(ctx context.Context, productID int64, variantID int64) (*Variant, error) {
+	return s.Repository.GetByID(ctx, productID, variantID)
+}
+
+func (s *Service) GetVariants(ctx context.Context, productID int64) ([]*Variant, error) {
+	return s.Repository.GetByProductID(ctx, productID)
+}
+
+func (s *Service) UpdateVariant(ctx context.Context, productID int64, variantID int64, request *updateVariantRequest) (*Variant, error) {
+	variant, err := s.Repository.GetByID(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.SKU != nil {
+		variant.SKU = *request.SKU
+	}
+
+	if request.Price != nil {
+		variant.Price = request.Price
+	}
+
+	if request.ClearPrice {
+		variant.Price = nil
+	}
+
+	if request.StockQuantity != nil {
+		variant.StockQuantity = *request.StockQuantity
+	}
+
+	if request.Attributes != nil {
+		variant.Attributes = request.Attributes
+	}
+
+	schema, currency, err := s.Repository.GetSchemaAndCurrency(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validator.New()
+
+	v.Check(!request.ClearPrice || request.Price == nil, "clear_price", "must not be sent together with price")
+
+	if validateVariant(v, variant, schema, currency); !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrVariantValidationFailed, v.Errors)
+	}
+
+	variant.setCurrency(currency)
+
+	if err = s.Repository.Update(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func (s *Service) DeleteVariant(ctx context.Context, productID int64, variantID int64) error {
+	return s.Repository.SoftDelete(ctx, productID, variantID)
+}
+
+// GenerateVariants creates a variant for every combination of the selected enum values, combinations
+// the product already has are skipped and reported back
+func (s *Service) GenerateVariants(ctx context.Context, productID int64, request *generateVariantsRequest) (*GenerateResult, error) {
+	schema, currency, err := s.Repository.GetSchemaAndCurrency(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validator.New()
+
+	validateSelection(v, request.Attributes, schema)
+	v.Check(request.StockQuantity >= 0, "stock_quantity", "cannot be negative")
+	validatePrice(v, request.Price, currency)
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrVariantValidationFailed, v.Errors)
+	}
+
+	existing, err := s.Repository.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	existingAttributes := make(map[string]bool, len(existing))
+	existingSKUs := make(map[string]bool, len(existing))
+	for _, variant := range existing {
+		existingAttributes[canonicalAttributes(variant.Attributes)] = true
+		existingSKUs[variant.SKU] = true
+	}
+
+	result := &GenerateResult{Created: []*Variant{}, Skipped: []json.RawMessage{}}
+
+	var variants []*Variant
+	for _, combination := range combine(request.Attributes) {
+		attributes, err := json.Marshal(combination)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal attributes: %w", err)
+		}
+
+		if existingAttributes[string(attributes)] {
+			result.Skipped = append(result.Skipped, attributes)
+			continue
+		}
+
+		sku := generateSKU(productID, combination, existingSKUs)
+		existingSKUs[sku] = true
+
+		variant := &Variant{
+			ProductID:     int(productID),
+			SKU:           sku,
+			Price:         request.Price,
+			StockQuantity: request.StockQuantity,
+			Attributes:    attributes,
+		}
+		variant.setCurrency(currency)
+
+		variants = append(variants, variant)
+	}
+
+	if len(variants) == 0 {
+		return result, nil
+	}
+
+	created, err := s.Repository.CreateBatch(ctx, variants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate variants: %w", err)
+	}
+
+	result.Created = created
+
+	// combinations created concurrently by someone else were skipped by the database
+	if len(created) < len(variants) {
+		for _, variant := range variants {
+			if !slices.Contains(created, variant) {
+				result.Skipped = append(result.Skipped, variant.Attributes)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// combine returns the cartesian product of the selected values
+func combine(selection map[string][]string) []map[string]string {
+	combinations := []map[string]string{{}}
+
+	for _, name := range sortedKeys(selection) {
+		next := make([]map[string]string, 0, len(combinations)*len(selection[name]))
+		for _, combination := range combinations {
+			for _, value := range selection[name] {
+				extended := make(map[string]string, len(combination)+1)
+				for k, val := range combination {
+					extended[k] = val
+				}
+				extended[name] = value
+				next = append(next, extended)
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// generateSKU builds a readable sku like "P12-BLACK-M" and makes it unique among taken ones
+func generateSKU(productID int64, combination map[string]string, taken map[string]bool) string {
+	parts := []string{fmt.Sprintf("P%d", productID)}
+	for _, name := range sortedKeys(combination) {
+		if slug := translit.Slugify(combination[name]); slug != "" {
+			parts = append(parts, strings.ToUpper(slug))
+		}
+	}
+
+	base := strings.Join(parts, "-")
+	if len(base) > 56 {
+		base = strings.TrimRight(base[:56], "-")
+	}
+
+	sku := base
+	for i := 2; taken[sku]; i++ {
+		sku = skuWithSuffix(base, i)
+	}
+
+	return sku
+}
+
+// skuWithSuffix numbers a generated sku which is already taken
+func skuWithSuffix(base string, suffix int) string {
+	return fmt.Sprintf("%s-%d", base, suffix)
+}
+
+// canonicalAttributes re-encodes attributes, so equal objects give equal strings regardless of key order
+func canonicalAttributes(attributes json.RawMessage) string {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(attributes, &decoded); err != nil {
+		return string(attributes)
+	}
+
+	canonical, err := json.Marshal(decoded)
+	if err != nil {
+		return string(attributes)
+	}
+
+	return string(canonical)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
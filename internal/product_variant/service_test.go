@@ -0,0 +1,111 @@
+package product_variant
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection map[string][]string
+		want      []map[string]string
+	}{
+		{
+			name:      "one attribute",
+			selection: map[string][]string{"color": {"black", "white"}},
+			want:      []map[string]string{{"color": "black"}, {"color": "white"}},
+		},
+		{
+			name:      "attributes are combined in the order of their names",
+			selection: map[string][]string{"size": {"M", "L"}, "color": {"black", "white"}},
+			want: []map[string]string{
+				{"color": "black", "size": "M"},
+				{"color": "black", "size": "L"},
+				{"color": "white", "size": "M"},
+				{"color": "white", "size": "L"},
+			},
+		},
+		{
+			name:      "attribute without values gives nothing",
+			selection: map[string][]string{"color": {"black"}, "size": {}},
+			want:      []map[string]string{},
+		},
+		{
+			name:      "no attributes give one empty combination",
+			selection: map[string][]string{},
+			want:      []map[string]string{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combine(tt.selection); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("combine(%v) = %v, want %v", tt.selection, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSKU(t *testing.T) {
+	tests := []struct {
+		name        string
+		productID   int64
+		combination map[string]string
+		taken       map[string]bool
+		want        string
+	}{
+		{
+			name:        "values in the order of attribute names",
+			productID:   12,
+			combination: map[string]string{"size": "M", "color": "black"},
+			want:        "P12-BLACK-M",
+		},
+		{
+			name:        "cyrillic values are transliterated",
+			productID:   7,
+			combination: map[string]string{"цвет": "Тёмно-синий"},
+			want:        "P7-TYOMNO-SINIJ",
+		},
+		{
+			name:        "values without letters or digits are skipped",
+			productID:   3,
+			combination: map[string]string{"color": "black", "mark": "***"},
+			want:        "P3-BLACK",
+		},
+		{
+			name:        "taken sku is numbered",
+			productID:   12,
+			combination: map[string]string{"color": "black"},
+			taken:       map[string]bool{"P12-BLACK": true, "P12-BLACK-2": true},
+			want:        "P12-BLACK-3",
+		},
+		{
+			name:        "long sku is cut to 56 characters",
+			productID:   1,
+			combination: map[string]string{"a": strings.Repeat("x", 49), "b": "yyyy"},
+			want:        "P1-" + strings.Repeat("X", 49) + "-YYY",
+		},
+		{
+			name:        "long sku is cut without a trailing dash",
+			productID:   1,
+			combination: map[string]string{"a": strings.Repeat("x", 52), "b": "yyyy"},
+			want:        "P1-" + strings.Repeat("X", 52),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generateSKU(tt.productID, tt.combination, tt.taken); got != tt.want {
+				t.Errorf("generateSKU(%d, %v) = %q, want %q", tt.productID, tt.combination, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSKUWithSuffix(t *testing.T) {
+	if got := skuWithSuffix("P12-BLACK", 4); got != "P12-BLACK-4" {
+		t.Errorf("skuWithSuffix() = %q, want %q", got, "P12-BLACK-4")
+	}
+}
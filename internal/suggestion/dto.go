@@ -0,0 +1,8 @@
+package suggestion
+
+// suggestRequest represents a query for getting autocomplete suggestions
+type suggestRequest struct {
+	Query    string `form:"q"`
+	Language string `form:"language"`
+	Limit    int    `form:"limit"`
+}
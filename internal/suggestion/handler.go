@@ -0,0 +1,68 @@
+package suggestion
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"ngMarketplace/internal/apperror"
+	"ngMarketplace/internal/transport/http/router"
+	"ngMarketplace/pkg/logger"
+)
+
+const (
+	suggestURL = "/suggest"
+)
+
+type UseCase interface {
+	Suggest(ctx context.Context, request suggestRequest) ([]*Suggestion, error)
+}
+
+type Handler struct {
+	useCase UseCase
+	logger  logger.Logger
+}
+
+func NewHandler(useCase UseCase, logger logger.Logger) *Handler {
+	return &Handler{
+		useCase: useCase,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	router.GET(suggestURL, h.suggestHandler)
+}
+
+// suggestHandler returns autocomplete suggestions, the response may be cached by clients for a minute
+// since it is requested on every keystroke
+func (h *Handler) suggestHandler(ctx *gin.Context) {
+	const op = "suggestHandler"
+
+	var req suggestRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("%s: ctx.ShouldBindQuery: %v", op, err)
+		apperror.WriteBadRequestResponse(ctx, err, "some parameter was sent with incorrect type")
+		return
+	}
+
+	suggestions, err := h.useCase.Suggest(ctx, req)
+	if err != nil {
+		h.logger.Error("%s: h.useCase.Suggest: %v", op, err)
+		switch {
+		case errors.Is(err, ErrSuggestValidationFailed):
+			apperror.WriteBadRequestResponse(ctx, err, err.Error())
+		default:
+			apperror.WriteInternalErrResponse(ctx, err, "Unexpected error occurred")
+		}
+		return
+	}
+
+	headers := http.Header{"Cache-Control": []string{"public, max-age=60"}}
+
+	if err = router.WriteJSON(ctx, http.StatusOK, gin.H{"suggestions": suggestions}, headers); err != nil {
+		h.logger.Warn("%s: router.WriteJSON: %v", op, err)
+		ctx.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+		return
+	}
+}
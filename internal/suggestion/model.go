@@ -0,0 +1,22 @@
+package suggestion
+
+import "errors"
+
+// Types of suggestions
+const (
+	TypeCategory = "category"
+	TypeProduct  = "product"
+)
+
+// Suggestion represents one autocomplete entry, categories always go before products
+type Suggestion struct {
+	Type  string  `json:"type"`
+	ID    int     `json:"id"`
+	Text  string  `json:"text"`
+	Score float32 `json:"score"`
+}
+
+// Service Errors
+var (
+	ErrSuggestValidationFailed = errors.New("suggest validation failed")
+)
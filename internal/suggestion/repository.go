@@ -0,0 +1,115 @@
+package suggestion
+
+import (
+	"context"
+	"ngMarketplace/pkg/postgres"
+	"strings"
+)
+
+type Repository struct {
+	client *postgres.Postgres
+}
+
+func NewRepository(client *postgres.Postgres) *Repository {
+	return &Repository{client: client}
+}
+
+// likeEscaper escapes LIKE wildcards, so a client can't turn a prefix search into a full scan
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Suggest method returns up to limit categories and up to limit product names that start with
+// one of the texts or are similar to them by trigrams. Prefix matches go before fuzzy ones
+// and categories go before products
+func (r *Repository) Suggest(ctx context.Context, text string, alternative string, language string, limit int) ([]*Suggestion, error) {
+	const op = "Suggest"
+
+	query := `
+		(
+		    SELECT
+		        1 AS priority, 'category' AS type, category_id AS id, category_name::text AS text,
+		        (category_name::text ILIKE $3 OR category_name::text ILIKE $4) AS prefix,
+		        greatest(similarity(category_name::text, $1), similarity(category_name::text, $2)) AS score
+		    FROM
+		        categories
+		    WHERE
+		        deleted_at IS NULL
+		    AND
+		        active = true
+		    AND
+		        language = $5
+		    AND
+		        (category_name::text ILIKE $3 OR category_name::text ILIKE $4
+		            OR category_name::text % $1 OR category_name::text % $2)
+		    ORDER BY
+		        prefix DESC, score DESC
+		    LIMIT $6
+		)
+		UNION ALL
+		(
+		    SELECT
+		        2 AS priority, 'product' AS type, t.product_id AS id, t.product_name::text AS text,
+		        (t.product_name::text ILIKE $3 OR t.product_name::text ILIKE $4) AS prefix,
+		        greatest(similarity(t.product_name::text, $1), similarity(t.product_name::text, $2)) AS score
+		    FROM
+		        product_translations t
+		    JOIN
+		        products p ON p.product_id = t.product_id AND p.active = true AND p.status = 'published'
+		    WHERE
+		        t.deleted_at IS NULL
+		    AND
+		        t.language = $5
+		    AND
+		        (t.product_name::text ILIKE $3 OR t.product_name::text ILIKE $4
+		            OR t.product_name::text % $1 OR t.product_name::text % $2)
+		    ORDER BY
+		        prefix DESC, score DESC
+		    LIMIT $6
+		)
+		ORDER BY
+		    priority, prefix DESC, score DESC, text`
+
+	args := []interface{}{
+		text,
+		alternative,
+		likeEscaper.Replace(text) + "%",
+		likeEscaper.Replace(alternative) + "%",
+		language,
+		limit,
+	}
+
+	rows, err := r.client.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, postgres.ErrDoQuery(op, err)
+	}
+	defer rows.Close()
+
+	suggestions := []*Suggestion{}
+
+	for rows.Next() {
+		var (
+			suggestion Suggestion
+			priority   int
+			prefix     bool
+		)
+
+		err = rows.Scan(
+			&priority,
+			&suggestion.Type,
+			&suggestion.ID,
+			&suggestion.Text,
+			&prefix,
+			&suggestion.Score,
+		)
+		if err != nil {
+			return nil, postgres.ErrScan(op, err)
+		}
+
+		suggestions = append(suggestions, &suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, postgres.ErrReadRows(op, err)
+	}
+
+	return suggestions, nil
+}
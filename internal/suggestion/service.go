@@ -0,0 +1,54 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+	"ngMarketplace/internal/common/attribute_schema/translit"
+	"ngMarketplace/pkg/validator"
+	"strings"
+	"unicode/utf8"
+)
+
+type Storage interface {
+	Suggest(ctx context.Context, text string, alternative string, language string, limit int) ([]*Suggestion, error)
+}
+
+type Service struct {
+	Repository Storage
+}
+
+func NewUseCase(repository Storage) *Service {
+	return &Service{Repository: repository}
+}
+
+// Suggest returns categories and product names for autocomplete. The query is also matched
+// in the other script, so "telefon" suggests "телефон"
+func (s *Service) Suggest(ctx context.Context, request suggestRequest) ([]*Suggestion, error) {
+	if request.Language == "" {
+		request.Language = "ru"
+	}
+
+	if request.Limit == 0 {
+		request.Limit = 5
+	}
+
+	request.Query = strings.TrimSpace(request.Query)
+
+	v := validator.New()
+
+	v.Check(utf8.RuneCountInString(request.Query) >= 2, "q", "must be at least 2 characters long")
+	v.Check(utf8.RuneCountInString(request.Query) <= 100, "q", "must not be more than 100 characters long")
+	v.Check(validator.In(request.Language, "ru", "tj", "en"), "language", "language must be one of [tj ru en]")
+	v.Check(request.Limit > 0 && request.Limit <= 10, "limit", "must be between 1 and 10")
+
+	if !v.Valid() {
+		return nil, fmt.Errorf("%w: %w", ErrSuggestValidationFailed, v.Errors)
+	}
+
+	alternative := request.Query
+	if variants := translit.Variants(request.Query, request.Language); len(variants) > 1 {
+		alternative = variants[1]
+	}
+
+	return s.Repository.Suggest(ctx, request.Query, alternative, request.Language, request.Limit)
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+// adminKey is the key of the context value marking requests of admins
+const adminKey = "admin"
+
+// Admin marks requests carrying the admin token as "Authorization: Bearer <token>", requests without it
+// are passed on as the ones of the public. An empty token disables admin access, so no request is marked
+func Admin(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		bearer, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if ok && token != "" && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(token)) == 1 {
+			ctx.Set(adminKey, true)
+		}
+
+		ctx.Next()
+	}
+}
+
+// IsAdmin reports whether the request was marked by Admin
+func IsAdmin(ctx *gin.Context) bool {
+	return ctx.GetBool(adminKey)
+}
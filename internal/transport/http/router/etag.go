@@ -0,0 +1,95 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+	ErrInvalidETag          = errors.New("If-Match header must hold an entity tag given by the server")
+)
+
+// ETag returns the entity tag of a record with the row version
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ETagHeader returns the header carrying the entity tag of a record with the row version
+func ETagHeader(version int) http.Header {
+	return http.Header{"ETag": []string{ETag(version)}}
+}
+
+// RepresentationETag returns the entity tag of a representation of a record: the row version followed by a hash
+// of the body. The body depends on more than the row, e.g. on ?language, ?include, discounts and rates,
+// so the tag changes with all of them, while If-Match still reads the version from it
+func RepresentationETag(version int, body any) (string, error) {
+	js, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(js)
+
+	return fmt.Sprintf(`"%d-%s"`, version, hex.EncodeToString(sum[:8])), nil
+}
+
+// IfMatchVersion returns the row version required by the If-Match header, 0 is returned for "*" matching
+// any version. A single strong entity tag is accepted, since weak ones never match for writes, the tag
+// may be either the one of the record or the one of its representation
+func IfMatchVersion(ctx *gin.Context) (int, error) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		return 0, ErrPreconditionRequired
+	}
+
+	if header == "*" {
+		return 0, nil
+	}
+
+	tag, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return 0, ErrInvalidETag
+	}
+	tag, ok = strings.CutSuffix(tag, `"`)
+	if !ok {
+		return 0, ErrInvalidETag
+	}
+
+	tag, _, _ = strings.Cut(tag, "-")
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, ErrInvalidETag
+	}
+
+	return version, nil
+}
+
+// NotModified answers with not modified status (304) when the If-None-Match header matches the entity tag,
+// tags are compared weakly as the header may list several of them
+func NotModified(ctx *gin.Context, etag string) bool {
+	header := ctx.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			ctx.Header("ETag", etag)
+			ctx.Status(http.StatusNotModified)
+			ctx.Writer.WriteHeaderNow()
+			return true
+		}
+	}
+
+	return false
+}
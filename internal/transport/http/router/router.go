@@ -7,8 +7,10 @@ import (
 	"strings"
 )
 
-func NewRouter() *gin.Engine {
+// NewRouter returns the engine with admin requests marked by the admin token, see Admin
+func NewRouter(adminToken string) *gin.Engine {
 	r := gin.Default()
+	r.Use(Admin(adminToken))
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
@@ -0,0 +1,3 @@
+-- Drop language specific full-text search indexes
+DROP INDEX IF EXISTS idx_product_translations_search_en;
+DROP INDEX IF EXISTS idx_product_translations_search_ru;
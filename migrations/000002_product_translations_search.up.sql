@@ -0,0 +1,8 @@
+-- Language specific full-text search indexes for product_translations.
+-- Tajik has no built-in text search configuration, so it keeps using idx_product_translations_search ('simple')
+CREATE INDEX idx_product_translations_search_ru ON product_translations
+    USING GIN (to_tsvector('russian', product_name || ' ' || coalesce(product_description, '')))
+    WHERE language = 'ru';
+CREATE INDEX idx_product_translations_search_en ON product_translations
+    USING GIN (to_tsvector('english', product_name || ' ' || coalesce(product_description, '')))
+    WHERE language = 'en';
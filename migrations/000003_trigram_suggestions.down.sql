@@ -0,0 +1,6 @@
+-- Drop trigram indexes
+DROP INDEX IF EXISTS idx_product_translations_name_trgm;
+DROP INDEX IF EXISTS idx_categories_name_trgm;
+
+-- Drop extension pg_trgm
+DROP EXTENSION IF EXISTS pg_trgm;
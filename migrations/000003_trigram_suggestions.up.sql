@@ -0,0 +1,6 @@
+-- Enable pg_trgm extension for fuzzy search and autocomplete
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes used by similarity (%) and prefix (ILIKE) matching of suggestions
+CREATE INDEX idx_categories_name_trgm ON categories USING GIN ((category_name::text) gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX idx_product_translations_name_trgm ON product_translations USING GIN ((product_name::text) gin_trgm_ops) WHERE deleted_at IS NULL;
@@ -0,0 +1,10 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_product_variants_timestamp ON product_variants;
+
+-- Drop indexes of product_variants
+DROP INDEX IF EXISTS idx_product_variants_attributes;
+DROP INDEX IF EXISTS idx_product_variants_sku;
+DROP INDEX IF EXISTS idx_product_variants_product;
+
+-- Drop table product_variants
+DROP TABLE IF EXISTS product_variants;
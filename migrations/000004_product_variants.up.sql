@@ -0,0 +1,32 @@
+-- Create product_variants table
+CREATE TABLE "product_variants"
+(
+    "variant_id"     INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "product_id"     INTEGER     NOT NULL,
+    "sku"            VARCHAR(64) NOT NULL,
+    "price"          DECIMAL(12, 2) CHECK (price >= 0),
+    "stock_quantity" INTEGER     NOT NULL DEFAULT 0 CHECK (stock_quantity >= 0),
+    "attributes"     jsonb       NOT NULL DEFAULT '{}',
+    "created_at"     TIMESTAMP DEFAULT now(),
+    "active"         BOOLEAN   DEFAULT true,
+    "updated_at"     TIMESTAMP DEFAULT now(),
+    "deleted_at"     TIMESTAMP
+);
+
+-- Adding foreign key for product_variants
+ALTER TABLE "product_variants"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Creating indexes for product_variants
+CREATE INDEX idx_product_variants_product ON product_variants (product_id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (product_id, sku) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_product_variants_attributes ON product_variants (product_id, attributes) WHERE deleted_at IS NULL;
+
+-- Trigger for updating updated_at field in product_variants
+CREATE TRIGGER update_product_variants_timestamp
+    BEFORE UPDATE ON product_variants
+    FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+COMMENT ON TABLE product_variants IS 'Варианты продукта (размер, цвет и т.д.) со своим артикулом и остатком';
+COMMENT ON COLUMN product_variants.price IS 'Цена варианта, NULL - используется цена продукта';
+COMMENT ON COLUMN product_variants.attributes IS 'Атрибуты, определяющие вариант, проверяются по attribute_schema категории';
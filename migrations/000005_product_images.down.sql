@@ -0,0 +1,9 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_product_images_timestamp ON product_images;
+
+-- Drop indexes of product_images
+DROP INDEX IF EXISTS idx_product_images_main;
+DROP INDEX IF EXISTS idx_product_images_product_position;
+
+-- Drop table product_images
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,34 @@
+-- Create product_images table. Rows are deleted together with files, so there is no soft delete here
+CREATE TABLE "product_images"
+(
+    "image_id"     INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "product_id"   INTEGER      NOT NULL,
+    "storage_key"  VARCHAR(255) NOT NULL,
+    "content_type" VARCHAR(50)  NOT NULL,
+    "size_bytes"   INTEGER      NOT NULL,
+    "width"        INTEGER      NOT NULL,
+    "height"       INTEGER      NOT NULL,
+    "thumbnails"   jsonb        NOT NULL DEFAULT '{}',
+    "position"     INTEGER      NOT NULL DEFAULT 0,
+    "is_main"      BOOLEAN      NOT NULL DEFAULT false,
+    "created_at"   TIMESTAMP DEFAULT now(),
+    "updated_at"   TIMESTAMP DEFAULT now()
+);
+
+-- Adding foreign key for product_images
+ALTER TABLE "product_images"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Creating indexes for product_images
+CREATE INDEX idx_product_images_product_position ON product_images (product_id, position);
+CREATE UNIQUE INDEX idx_product_images_main ON product_images (product_id) WHERE is_main = true;
+
+-- Trigger for updating updated_at field in product_images
+CREATE TRIGGER update_product_images_timestamp
+    BEFORE UPDATE ON product_images
+    FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+COMMENT ON TABLE product_images IS 'Фотографии продуктов, файлы хранятся во внешнем хранилище';
+COMMENT ON COLUMN product_images.storage_key IS 'Ключ оригинала в хранилище';
+COMMENT ON COLUMN product_images.thumbnails IS 'Ключи миниатюр в хранилище по названию размера';
+COMMENT ON COLUMN product_images.is_main IS 'Главное фото продукта, не более одного на продукт';
@@ -0,0 +1,5 @@
+-- Drop index of price_history
+DROP INDEX IF EXISTS idx_price_history_product_changed;
+
+-- Drop table price_history
+DROP TABLE IF EXISTS price_history;
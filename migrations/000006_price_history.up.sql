@@ -0,0 +1,25 @@
+-- Create price_history table
+CREATE TABLE "price_history"
+(
+    "history_id" INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "product_id" INTEGER        NOT NULL,
+    "price"      DECIMAL(12, 2) NOT NULL CHECK (price >= 0),
+    "currency"   VARCHAR(3)     NOT NULL,
+    "changed_at" TIMESTAMP      NOT NULL DEFAULT now()
+);
+
+-- Adding foreign key for price_history
+ALTER TABLE "price_history"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Creating index for price_history
+CREATE INDEX idx_price_history_product_changed ON price_history (product_id, changed_at);
+
+-- Recording current prices of existing products as their initial history
+INSERT INTO price_history (product_id, price, currency, changed_at)
+SELECT product_id, price, currency, coalesce(created_at, now())
+FROM products
+WHERE price IS NOT NULL;
+
+COMMENT ON TABLE price_history IS 'История изменения цены и валюты продукта';
+COMMENT ON COLUMN price_history.changed_at IS 'Момент, с которого действует цена';
@@ -0,0 +1,12 @@
+-- Drop functions
+DROP FUNCTION IF EXISTS effective_price(INTEGER, INTEGER, DECIMAL);
+DROP FUNCTION IF EXISTS applied_discounts(INTEGER, INTEGER);
+
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_discounts_timestamp ON discounts;
+
+-- Drop index of discounts
+DROP INDEX IF EXISTS idx_discounts_scope_target;
+
+-- Drop table discounts
+DROP TABLE IF EXISTS discounts;
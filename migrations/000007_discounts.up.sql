@@ -0,0 +1,102 @@
+-- Create discounts table
+CREATE TABLE "discounts"
+(
+    "discount_id" INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "name"        VARCHAR(255)   NOT NULL,
+    "kind"        VARCHAR(10)    NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    "value"       DECIMAL(12, 2) NOT NULL CHECK (value > 0),
+    "currency"    VARCHAR(3),
+    "scope"       VARCHAR(10)    NOT NULL CHECK (scope IN ('product', 'variant', 'category', 'seller')),
+    "target_id"   INTEGER        NOT NULL,
+    "priority"    INTEGER        NOT NULL DEFAULT 0,
+    "stackable"   BOOLEAN        NOT NULL DEFAULT false,
+    "starts_at"   TIMESTAMP      NOT NULL DEFAULT now(),
+    "ends_at"     TIMESTAMP,
+    "user_id"     INTEGER        NOT NULL,
+    "created_at"  TIMESTAMP DEFAULT now(),
+    "active"      BOOLEAN   DEFAULT true,
+    "updated_at"  TIMESTAMP DEFAULT now(),
+    "deleted_at"  TIMESTAMP,
+    CHECK (kind = 'percent' AND value < 100 OR kind = 'fixed' AND currency IS NOT NULL),
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- Creating index for discounts
+CREATE INDEX idx_discounts_scope_target ON discounts (scope, target_id) WHERE active = true;
+
+-- Trigger for updating updated_at field in discounts
+CREATE TRIGGER update_discounts_timestamp
+    BEFORE UPDATE ON discounts
+    FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+-- applied_discounts returns discounts applied to the price of the product (or its variant) right now, in order of
+-- application. Discounts are ordered by priority: when the first one is not stackable it is the only one applied,
+-- otherwise every stackable discount is applied. Fixed discounts only apply to prices in their currency
+CREATE OR REPLACE FUNCTION applied_discounts(p_product_id INTEGER, p_variant_id INTEGER)
+RETURNS SETOF discounts AS $$
+    WITH RECURSIVE product AS (
+        SELECT product_id, category_id, user_id, currency FROM products WHERE product_id = p_product_id
+    ), ancestors AS (
+        SELECT c.category_id, c.parent_id FROM categories c JOIN product ON c.category_id = product.category_id
+        UNION
+        SELECT c.category_id, c.parent_id FROM categories c JOIN ancestors a ON c.category_id = a.parent_id
+    ), candidates AS (
+        SELECT
+            d.*,
+            row_number() OVER w AS seq,
+            first_value(d.stackable) OVER w AS first_stackable
+        FROM
+            discounts d, product
+        WHERE
+            d.active = true
+        AND
+            d.starts_at <= now() AND (d.ends_at IS NULL OR d.ends_at > now())
+        AND
+            (d.kind = 'percent' OR d.currency = product.currency)
+        AND (
+            d.scope = 'product' AND d.target_id = product.product_id
+            OR d.scope = 'variant' AND d.target_id = p_variant_id
+            OR d.scope = 'seller' AND d.target_id = product.user_id
+            OR d.scope = 'category' AND d.target_id IN (SELECT category_id FROM ancestors)
+        )
+        WINDOW w AS (ORDER BY d.priority DESC, d.discount_id)
+    )
+    SELECT
+        discount_id, name, kind, value, currency, scope, target_id, priority, stackable, starts_at, ends_at,
+        user_id, created_at, active, updated_at, deleted_at
+    FROM
+        candidates
+    WHERE
+        seq = 1 OR (first_stackable AND stackable)
+    ORDER BY
+        seq
+$$ LANGUAGE sql STABLE;
+
+-- effective_price applies discounts of the product (or its variant) to p_price: percentages one after another,
+-- then fixed amounts, the result is never negative
+CREATE OR REPLACE FUNCTION effective_price(p_product_id INTEGER, p_variant_id INTEGER, p_price DECIMAL)
+RETURNS DECIMAL AS $$
+DECLARE
+    d discounts;
+    result DECIMAL := p_price;
+BEGIN
+    IF p_price IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    FOR d IN SELECT * FROM applied_discounts(p_product_id, p_variant_id) ORDER BY kind = 'fixed' LOOP
+        IF d.kind = 'percent' THEN
+            result := result * (100 - d.value) / 100;
+        ELSE
+            result := result - d.value;
+        END IF;
+    END LOOP;
+
+    RETURN round(greatest(result, 0), 2);
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+COMMENT ON TABLE discounts IS 'Скидки на продукт, вариант, дерево категорий или все продукты продавца';
+COMMENT ON COLUMN discounts.value IS 'Процент (меньше 100) или сумма скидки в валюте currency';
+COMMENT ON COLUMN discounts.priority IS 'Скидка с большим приоритетом применяется первой';
+COMMENT ON COLUMN discounts.stackable IS 'Суммируется ли скидка с другими суммируемыми скидками';
@@ -0,0 +1,12 @@
+-- Drop functions
+DROP FUNCTION IF EXISTS convert_price(DECIMAL, VARCHAR, VARCHAR);
+DROP FUNCTION IF EXISTS exchange_rate(VARCHAR);
+
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_exchange_rates_timestamp ON exchange_rates;
+
+-- Drop index of exchange_rates
+DROP INDEX IF EXISTS idx_exchange_rates_currency_effective;
+
+-- Drop table exchange_rates
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,47 @@
+-- Create exchange_rates table
+CREATE TABLE "exchange_rates"
+(
+    "rate_id"      INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "currency"     VARCHAR(3)     NOT NULL CHECK (currency <> 'TJS'),
+    "rate"         DECIMAL(18, 8) NOT NULL CHECK (rate > 0),
+    "effective_at" TIMESTAMP      NOT NULL DEFAULT now(),
+    "source"       VARCHAR(20)    NOT NULL DEFAULT 'manual',
+    "created_at"   TIMESTAMP DEFAULT now(),
+    "updated_at"   TIMESTAMP DEFAULT now()
+);
+
+-- Creating index for exchange_rates
+CREATE UNIQUE INDEX idx_exchange_rates_currency_effective ON exchange_rates (currency, effective_at);
+
+-- Trigger for updating updated_at field in exchange_rates
+CREATE TRIGGER update_exchange_rates_timestamp
+    BEFORE UPDATE ON exchange_rates
+    FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+-- exchange_rate returns the current price of one unit of the currency in the base currency (TJS),
+-- NULL when no rate has been loaded for the currency yet
+CREATE OR REPLACE FUNCTION exchange_rate(p_currency VARCHAR)
+RETURNS DECIMAL AS $$
+    SELECT CASE
+        WHEN p_currency = 'TJS' THEN 1
+        ELSE (
+            SELECT rate FROM exchange_rates
+            WHERE currency = p_currency AND effective_at <= now()
+            ORDER BY effective_at DESC
+            LIMIT 1
+        )
+    END
+$$ LANGUAGE sql STABLE;
+
+-- convert_price converts the amount between currencies through the base currency with the current rates
+CREATE OR REPLACE FUNCTION convert_price(p_amount DECIMAL, p_from VARCHAR, p_to VARCHAR)
+RETURNS DECIMAL AS $$
+    SELECT CASE
+        WHEN p_from = p_to THEN p_amount
+        ELSE round(p_amount * exchange_rate(p_from) / exchange_rate(p_to), 2)
+    END
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON TABLE exchange_rates IS 'История курсов валют к базовой валюте (TJS)';
+COMMENT ON COLUMN exchange_rates.rate IS 'Стоимость одной единицы валюты в TJS';
+COMMENT ON COLUMN exchange_rates.effective_at IS 'Момент, с которого действует курс';
@@ -0,0 +1,11 @@
+-- Drop index of product_status_history
+DROP INDEX IF EXISTS idx_product_status_history_product;
+
+-- Drop table product_status_history
+DROP TABLE IF EXISTS product_status_history;
+
+-- Drop index of products status
+DROP INDEX IF EXISTS idx_products_status;
+
+-- Drop status of products
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,40 @@
+-- Adding lifecycle status to products
+ALTER TABLE "products"
+    ADD COLUMN "status" VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'pending_review', 'published', 'archived', 'sold'));
+
+-- Existing products were shown in listings, so they stay visible
+UPDATE products SET status = 'published';
+
+-- Creating index for public listings
+CREATE INDEX idx_products_status ON products (status) WHERE active = true;
+
+-- Create product_status_history table
+CREATE TABLE "product_status_history"
+(
+    "history_id"  INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    "product_id"  INTEGER     NOT NULL,
+    "from_status" VARCHAR(20),
+    "to_status"   VARCHAR(20) NOT NULL,
+    "reason"      TEXT,
+    "user_id"     INTEGER     NOT NULL,
+    "changed_at"  TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+-- Adding foreign key for product_status_history
+ALTER TABLE "product_status_history"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Creating index for product_status_history
+CREATE INDEX idx_product_status_history_product ON product_status_history (product_id, changed_at);
+
+-- Recording the status of existing products as their initial history
+INSERT INTO product_status_history (product_id, to_status, user_id, changed_at)
+SELECT product_id, status, user_id, coalesce(created_at, now())
+FROM products;
+
+COMMENT ON COLUMN products.status IS 'Статус жизненного цикла: draft, pending_review, published, archived, sold';
+COMMENT ON TABLE product_status_history IS 'История изменения статуса продукта';
+COMMENT ON COLUMN product_status_history.from_status IS 'Предыдущий статус, NULL для созданного продукта';
+COMMENT ON COLUMN product_status_history.reason IS 'Причина отклонения модератором';
+COMMENT ON COLUMN product_status_history.user_id IS 'Продавец или модератор, изменивший статус';
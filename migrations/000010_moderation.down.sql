@@ -0,0 +1,11 @@
+-- Drop reason code of status history
+ALTER TABLE "product_status_history" DROP COLUMN IF EXISTS "reason_code";
+
+-- Drop table moderation_claims
+DROP TABLE IF EXISTS moderation_claims;
+
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_rejection_reasons_timestamp ON rejection_reasons;
+
+-- Drop table rejection_reasons
+DROP TABLE IF EXISTS rejection_reasons;
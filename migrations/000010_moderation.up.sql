@@ -0,0 +1,46 @@
+-- Create rejection_reasons table
+CREATE TABLE "rejection_reasons"
+(
+    "code"       VARCHAR(50) PRIMARY KEY,
+    "text_tj"    TEXT      NOT NULL,
+    "text_ru"    TEXT      NOT NULL,
+    "text_en"    TEXT      NOT NULL,
+    "active"     BOOLEAN   NOT NULL DEFAULT true,
+    "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Trigger for updating updated_at field in rejection_reasons
+CREATE TRIGGER update_rejection_reasons_timestamp
+    BEFORE UPDATE ON rejection_reasons
+FOR EACH ROW EXECUTE FUNCTION update_timestamp();
+
+INSERT INTO rejection_reasons (code, text_tj, text_ru, text_en)
+VALUES
+    ('prohibited_item', 'Фурӯши ин мол манъ аст', 'Продажа этого товара запрещена', 'This item is prohibited from sale'),
+    ('wrong_category', 'Категорияи нодуруст интихоб шудааст', 'Выбрана неверная категория', 'The category is wrong'),
+    ('poor_description', 'Тавсиф нопурра ё нофаҳмо аст', 'Описание неполное или непонятное', 'The description is incomplete or unclear'),
+    ('misleading_price', 'Нарх гумроҳкунанда аст', 'Цена вводит в заблуждение', 'The price is misleading'),
+    ('bad_images', 'Суратҳо ба мол мувофиқ нестанд ё сифаташон паст аст', 'Фотографии не соответствуют товару или низкого качества', 'Images do not match the item or are of poor quality'),
+    ('duplicate', 'Ин эълон такрор аст', 'Это объявление является дубликатом', 'This listing is a duplicate');
+
+-- Create moderation_claims table
+CREATE TABLE "moderation_claims"
+(
+    "product_id"   INTEGER PRIMARY KEY,
+    "moderator_id" INTEGER   NOT NULL,
+    "claimed_at"   TIMESTAMP NOT NULL DEFAULT now(),
+    "expires_at"   TIMESTAMP NOT NULL
+);
+
+-- Adding foreign key for moderation_claims
+ALTER TABLE "moderation_claims"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Adding predefined reason to status history
+ALTER TABLE "product_status_history"
+    ADD COLUMN "reason_code" VARCHAR(50) REFERENCES "rejection_reasons" ("code") ON DELETE RESTRICT;
+
+COMMENT ON TABLE rejection_reasons IS 'Предопределённые причины отклонения продукта модератором';
+COMMENT ON TABLE moderation_claims IS 'Продукты, взятые модератором на проверку, до expires_at их не может взять другой модератор';
+COMMENT ON COLUMN product_status_history.reason_code IS 'Предопределённая причина отклонения';
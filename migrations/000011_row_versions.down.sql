@@ -0,0 +1,10 @@
+-- Drop triggers incrementing versions
+DROP TRIGGER IF EXISTS increment_categories_version ON categories;
+DROP TRIGGER IF EXISTS increment_products_version ON products;
+
+-- Drop function incrementing versions
+DROP FUNCTION IF EXISTS increment_version();
+
+-- Drop versions of categories and products
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "products" DROP COLUMN IF EXISTS "version";
@@ -0,0 +1,28 @@
+-- Adding row versions to products and categories
+ALTER TABLE "products"
+    ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE "categories"
+    ADD COLUMN "version" INTEGER NOT NULL DEFAULT 1;
+
+-- Function for incrementing version field for triggers
+CREATE OR REPLACE FUNCTION increment_version()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.version = OLD.version + 1;
+RETURN NEW;
+END;
+$$
+language 'plpgsql';
+
+-- Triggers for incrementing version field on every change
+CREATE TRIGGER increment_products_version
+BEFORE UPDATE ON products
+FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+CREATE TRIGGER increment_categories_version
+BEFORE UPDATE ON categories
+FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+COMMENT ON COLUMN products.version IS 'Версия строки, увеличивается при каждом изменении, отдаётся клиентам как ETag';
+COMMENT ON COLUMN categories.version IS 'Версия строки, увеличивается при каждом изменении, отдаётся клиентам как ETag';
@@ -0,0 +1,18 @@
+-- Drop indexes for sorting by time
+DROP INDEX IF EXISTS idx_products_updated_at;
+DROP INDEX IF EXISTS idx_products_created_at;
+
+-- Return timestamps without time zone
+ALTER TABLE "categories"
+    ALTER COLUMN "created_at" DROP NOT NULL,
+    ALTER COLUMN "updated_at" DROP NOT NULL,
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMP;
+
+ALTER TABLE "products"
+    ALTER COLUMN "created_at" DROP NOT NULL,
+    ALTER COLUMN "updated_at" DROP NOT NULL,
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMP;
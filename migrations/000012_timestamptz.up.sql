@@ -0,0 +1,36 @@
+-- Switching timestamps of products and categories to timestamptz, existing values were written by now()
+-- in the time zone of the session, so they are converted in it as well
+ALTER TABLE "products"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "categories"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMPTZ;
+
+-- Creation and update times are always set, listings are sorted and filtered by them
+UPDATE "products" SET "created_at" = now() WHERE "created_at" IS NULL;
+UPDATE "products" SET "updated_at" = "created_at" WHERE "updated_at" IS NULL;
+UPDATE "categories" SET "created_at" = now() WHERE "created_at" IS NULL;
+UPDATE "categories" SET "updated_at" = "created_at" WHERE "updated_at" IS NULL;
+
+ALTER TABLE "products"
+    ALTER COLUMN "created_at" SET NOT NULL,
+    ALTER COLUMN "updated_at" SET NOT NULL;
+
+ALTER TABLE "categories"
+    ALTER COLUMN "created_at" SET NOT NULL,
+    ALTER COLUMN "updated_at" SET NOT NULL;
+
+-- Indexes for sorting listings by creation and update time
+CREATE INDEX idx_products_created_at ON products (created_at, product_id);
+CREATE INDEX idx_products_updated_at ON products (updated_at, product_id);
+
+COMMENT ON COLUMN products.created_at IS 'Время создания продукта с часовым поясом';
+COMMENT ON COLUMN products.updated_at IS 'Время последнего изменения продукта с часовым поясом';
+COMMENT ON COLUMN products.deleted_at IS 'Время мягкого удаления продукта с часовым поясом, NULL у неудалённых';
+COMMENT ON COLUMN categories.created_at IS 'Время создания категории с часовым поясом';
+COMMENT ON COLUMN categories.updated_at IS 'Время последнего изменения категории с часовым поясом';
+COMMENT ON COLUMN categories.deleted_at IS 'Время мягкого удаления категории с часовым поясом, NULL у неудалённых';
@@ -0,0 +1,9 @@
+-- Drop table product_import_rows
+DROP TABLE IF EXISTS product_import_rows;
+
+-- Drop table product_imports
+DROP TABLE IF EXISTS product_imports;
+
+-- Drop external sku of products
+DROP INDEX IF EXISTS idx_products_user_external_sku;
+ALTER TABLE "products" DROP COLUMN IF EXISTS "external_sku";
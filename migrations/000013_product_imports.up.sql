@@ -0,0 +1,53 @@
+-- Adding external sku of sellers to products, imports upsert products by it
+ALTER TABLE "products"
+    ADD COLUMN "external_sku" VARCHAR(100);
+
+CREATE UNIQUE INDEX idx_products_user_external_sku ON products (user_id, external_sku) WHERE external_sku IS NOT NULL;
+
+-- Create product_imports table
+CREATE TABLE "product_imports"
+(
+    "import_id"      SERIAL PRIMARY KEY,
+    "user_id"        INTEGER     NOT NULL,
+    "format"         VARCHAR(10) NOT NULL CHECK ("format" IN ('csv', 'jsonl')),
+    "status"         VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'processing', 'completed', 'failed')),
+    "total_rows"     INTEGER     NOT NULL DEFAULT 0,
+    "processed_rows" INTEGER     NOT NULL DEFAULT 0,
+    "created_rows"   INTEGER     NOT NULL DEFAULT 0,
+    "updated_rows"   INTEGER     NOT NULL DEFAULT 0,
+    "failed_rows"    INTEGER     NOT NULL DEFAULT 0,
+    "error"          TEXT,
+    "created_at"     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "started_at"     TIMESTAMPTZ,
+    "finished_at"    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_product_imports_pending ON product_imports (import_id) WHERE status IN ('pending', 'processing');
+
+-- Create product_import_rows table
+CREATE TABLE "product_import_rows"
+(
+    "import_id"    INTEGER     NOT NULL,
+    "row_number"   INTEGER     NOT NULL,
+    "external_sku" VARCHAR(100),
+    "payload"      JSONB,
+    "status"       VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'created', 'updated', 'failed')),
+    "product_id"   INTEGER,
+    "errors"       JSONB,
+    PRIMARY KEY ("import_id", "row_number")
+);
+
+-- Adding foreign keys for product_import_rows
+ALTER TABLE "product_import_rows"
+    ADD FOREIGN KEY ("import_id") REFERENCES "product_imports" ("import_id") ON DELETE CASCADE;
+
+ALTER TABLE "product_import_rows"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE SET NULL;
+
+CREATE INDEX idx_product_import_rows_status ON product_import_rows (import_id, status, row_number);
+
+COMMENT ON COLUMN products.external_sku IS 'Артикул продавца, по которому импорт обновляет уже загруженный продукт';
+COMMENT ON TABLE product_imports IS 'Задания массового импорта продуктов из CSV или JSONL, обрабатываются в фоне';
+COMMENT ON COLUMN product_imports.error IS 'Причина, по которой задание прервано, ошибки отдельных строк хранятся в product_import_rows';
+COMMENT ON TABLE product_import_rows IS 'Строки файла импорта, payload - разобранный продукт, NULL у строк, которые не удалось разобрать';
+COMMENT ON COLUMN product_import_rows.errors IS 'Ошибки строки по полям';
@@ -0,0 +1,46 @@
+-- Return timestamps without time zone
+ALTER TABLE "moderation_claims"
+    ALTER COLUMN "claimed_at" TYPE TIMESTAMP,
+    ALTER COLUMN "expires_at" TYPE TIMESTAMP;
+
+ALTER TABLE "rejection_reasons"
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP;
+
+ALTER TABLE "product_status_history"
+    ALTER COLUMN "changed_at" TYPE TIMESTAMP;
+
+ALTER TABLE "exchange_rates"
+    ALTER COLUMN "effective_at" TYPE TIMESTAMP,
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP;
+
+ALTER TABLE "discounts"
+    ALTER COLUMN "starts_at" TYPE TIMESTAMP,
+    ALTER COLUMN "ends_at" TYPE TIMESTAMP,
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMP;
+
+ALTER TABLE "price_history"
+    ALTER COLUMN "changed_at" TYPE TIMESTAMP;
+
+ALTER TABLE "product_images"
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP;
+
+ALTER TABLE "product_variants"
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMP;
+
+ALTER TABLE "product_translations"
+    ALTER COLUMN "created_at" TYPE TIMESTAMP,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMP,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMP;
+
+COMMENT ON COLUMN discounts.starts_at IS NULL;
+COMMENT ON COLUMN discounts.ends_at IS NULL;
+COMMENT ON COLUMN price_history.changed_at IS 'Момент, с которого действует цена';
+COMMENT ON COLUMN exchange_rates.effective_at IS 'Момент, с которого действует курс';
+COMMENT ON COLUMN moderation_claims.expires_at IS NULL;
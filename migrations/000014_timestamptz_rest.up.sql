@@ -0,0 +1,47 @@
+-- Switching the remaining timestamps to timestamptz, existing values were written by now()
+-- in the time zone of the session, so they are converted in it as well
+ALTER TABLE "product_translations"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "product_variants"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "product_images"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "price_history"
+    ALTER COLUMN "changed_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "discounts"
+    ALTER COLUMN "starts_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "ends_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "deleted_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "exchange_rates"
+    ALTER COLUMN "effective_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "product_status_history"
+    ALTER COLUMN "changed_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "rejection_reasons"
+    ALTER COLUMN "created_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "updated_at" TYPE TIMESTAMPTZ;
+
+ALTER TABLE "moderation_claims"
+    ALTER COLUMN "claimed_at" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "expires_at" TYPE TIMESTAMPTZ;
+
+COMMENT ON COLUMN discounts.starts_at IS 'Момент начала действия скидки с часовым поясом';
+COMMENT ON COLUMN discounts.ends_at IS 'Момент окончания действия скидки с часовым поясом, NULL у бессрочных';
+COMMENT ON COLUMN price_history.changed_at IS 'Момент с часовым поясом, с которого действует цена';
+COMMENT ON COLUMN exchange_rates.effective_at IS 'Момент с часовым поясом, с которого действует курс';
+COMMENT ON COLUMN moderation_claims.expires_at IS 'Момент с часовым поясом, после которого продукт свободен для других модераторов';
@@ -0,0 +1,26 @@
+-- Drop functions refreshing prices by time
+DROP FUNCTION IF EXISTS refresh_due_product_prices();
+
+-- Drop table product_price_refreshes
+DROP TABLE IF EXISTS product_price_refreshes;
+
+-- Drop triggers
+DROP TRIGGER IF EXISTS refresh_categories_prices ON categories;
+DROP TRIGGER IF EXISTS refresh_exchange_rates_prices ON exchange_rates;
+DROP TRIGGER IF EXISTS refresh_discounts_prices ON discounts;
+DROP TRIGGER IF EXISTS refresh_products_prices ON products;
+
+-- Drop functions
+DROP FUNCTION IF EXISTS refresh_prices_of_category();
+DROP FUNCTION IF EXISTS refresh_prices_of_rate();
+DROP FUNCTION IF EXISTS refresh_prices_of_discount();
+DROP FUNCTION IF EXISTS refresh_prices_of_product();
+DROP FUNCTION IF EXISTS discount_products(VARCHAR, INTEGER);
+DROP FUNCTION IF EXISTS refresh_product_prices(INTEGER[]);
+
+-- Drop indexes of product_prices
+DROP INDEX IF EXISTS idx_product_prices_base_effective_price;
+DROP INDEX IF EXISTS idx_product_prices_base_price;
+
+-- Drop table product_prices
+DROP TABLE IF EXISTS product_prices;
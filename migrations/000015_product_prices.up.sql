@@ -0,0 +1,169 @@
+-- Create product_prices table, discounted and base currency prices of products are stored instead of being computed
+-- on every read, so listings filter and sort by them with indexes
+CREATE TABLE "product_prices"
+(
+    "product_id"           INTEGER PRIMARY KEY,
+    "effective_price"      DECIMAL,
+    "base_price"           DECIMAL,
+    "base_effective_price" DECIMAL
+);
+
+-- Adding foreign key for product_prices
+ALTER TABLE "product_prices"
+    ADD FOREIGN KEY ("product_id") REFERENCES "products" ("product_id") ON DELETE CASCADE;
+
+-- Indexes for filtering and sorting listings by prices
+CREATE INDEX idx_product_prices_base_price ON product_prices (base_price, product_id);
+CREATE INDEX idx_product_prices_base_effective_price ON product_prices (base_effective_price, product_id);
+
+-- refresh_product_prices computes stored prices of the products, NULL refreshes every product
+CREATE OR REPLACE FUNCTION refresh_product_prices(p_product_ids INTEGER[])
+RETURNS VOID AS $$
+    INSERT INTO product_prices (product_id, effective_price, base_price, base_effective_price)
+    SELECT
+        p.product_id, e.effective_price, p.price * exchange_rate(p.currency), e.effective_price * exchange_rate(p.currency)
+    FROM
+        products p
+    CROSS JOIN LATERAL
+        (SELECT effective_price(p.product_id, NULL, p.price)) e
+    WHERE
+        p_product_ids IS NULL OR p.product_id = ANY(p_product_ids)
+    ON CONFLICT (product_id) DO UPDATE SET
+        effective_price = EXCLUDED.effective_price,
+        base_price = EXCLUDED.base_price,
+        base_effective_price = EXCLUDED.base_effective_price
+$$ LANGUAGE sql;
+
+-- discount_products returns products whose own price the discount may apply to, variant discounts
+-- only change prices of variants, which are not stored
+CREATE OR REPLACE FUNCTION discount_products(p_scope VARCHAR, p_target_id INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE subtree AS (
+        SELECT category_id FROM categories WHERE p_scope = 'category' AND category_id = p_target_id
+        UNION
+        SELECT c.category_id FROM categories c JOIN subtree s ON c.parent_id = s.category_id
+    )
+    SELECT
+        product_id
+    FROM
+        products
+    WHERE
+        p_scope = 'product' AND product_id = p_target_id
+        OR p_scope = 'seller' AND user_id = p_target_id
+        OR p_scope = 'category' AND category_id IN (SELECT category_id FROM subtree)
+$$ LANGUAGE sql STABLE;
+
+-- Functions refreshing stored prices of products affected by a change of their row, discounts, rates or categories
+CREATE OR REPLACE FUNCTION refresh_prices_of_product()
+RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM refresh_product_prices(ARRAY[NEW.product_id]);
+RETURN NULL;
+END;
+$$
+language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION refresh_prices_of_discount()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    PERFORM refresh_product_prices(ARRAY(SELECT discount_products(OLD.scope, OLD.target_id)));
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    PERFORM refresh_product_prices(ARRAY(SELECT discount_products(NEW.scope, NEW.target_id)));
+  END IF;
+RETURN NULL;
+END;
+$$
+language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION refresh_prices_of_rate()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    PERFORM refresh_product_prices(ARRAY(SELECT product_id FROM products WHERE currency = OLD.currency));
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    PERFORM refresh_product_prices(ARRAY(SELECT product_id FROM products WHERE currency = NEW.currency));
+  END IF;
+RETURN NULL;
+END;
+$$
+language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION refresh_prices_of_category()
+RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM refresh_product_prices(ARRAY(SELECT discount_products('category', NEW.category_id)));
+RETURN NULL;
+END;
+$$
+language 'plpgsql';
+
+-- Triggers refreshing stored prices, columns of products are listed so refreshing does not trigger itself
+CREATE TRIGGER refresh_products_prices
+    AFTER INSERT OR UPDATE OF price, currency, category_id, user_id ON products
+    FOR EACH ROW EXECUTE FUNCTION refresh_prices_of_product();
+
+CREATE TRIGGER refresh_discounts_prices
+    AFTER INSERT OR UPDATE OR DELETE ON discounts
+    FOR EACH ROW EXECUTE FUNCTION refresh_prices_of_discount();
+
+CREATE TRIGGER refresh_exchange_rates_prices
+    AFTER INSERT OR UPDATE OR DELETE ON exchange_rates
+    FOR EACH ROW EXECUTE FUNCTION refresh_prices_of_rate();
+
+CREATE TRIGGER refresh_categories_prices
+    AFTER UPDATE OF parent_id ON categories
+    FOR EACH ROW EXECUTE FUNCTION refresh_prices_of_category();
+
+-- Create product_price_refreshes table, it holds the moment discount windows and rates were refreshed up to
+CREATE TABLE "product_price_refreshes"
+(
+    "refreshed_until" TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO product_price_refreshes (refreshed_until) VALUES (now());
+
+-- refresh_due_product_prices refreshes products whose discounts started or ended or whose rates took effect
+-- since the previous call, no row changes at these moments, so it is called periodically
+CREATE OR REPLACE FUNCTION refresh_due_product_prices()
+RETURNS VOID AS $$
+DECLARE
+    since TIMESTAMPTZ;
+BEGIN
+    SELECT refreshed_until INTO since FROM product_price_refreshes FOR UPDATE;
+
+    PERFORM refresh_product_prices(ARRAY(
+        SELECT
+            dp.product_id
+        FROM
+            discounts d
+        CROSS JOIN LATERAL
+            discount_products(d.scope, d.target_id) AS dp(product_id)
+        WHERE
+            d.active = true
+        AND
+            (d.starts_at > since AND d.starts_at <= now() OR d.ends_at > since AND d.ends_at <= now())
+        UNION
+        SELECT
+            p.product_id
+        FROM
+            products p
+        JOIN
+            exchange_rates r ON r.currency = p.currency
+        WHERE
+            r.effective_at > since AND r.effective_at <= now()
+    ));
+
+    UPDATE product_price_refreshes SET refreshed_until = now();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Storing prices of existing products
+SELECT refresh_product_prices(NULL);
+
+COMMENT ON TABLE product_prices IS 'Цены продуктов со скидками и в базовой валюте (TJS), обновляются триггерами и refresh_due_product_prices';
+COMMENT ON COLUMN product_prices.base_price IS 'Цена в TJS, NULL если курс валюты продукта ещё не загружен';
+COMMENT ON COLUMN product_prices.base_effective_price IS 'Цена со скидками в TJS, NULL если курс валюты продукта ещё не загружен';
+COMMENT ON TABLE product_price_refreshes IS 'Момент, до которого учтены начала и окончания скидок и вступления курсов в силу';
@@ -0,0 +1,2 @@
+-- Drop heartbeat of product imports
+ALTER TABLE "product_imports" DROP COLUMN IF EXISTS "heartbeat_at";
@@ -0,0 +1,5 @@
+-- Adding heartbeat of the worker processing the import, jobs without a recent heartbeat are abandoned
+ALTER TABLE "product_imports"
+    ADD COLUMN "heartbeat_at" TIMESTAMPTZ;
+
+COMMENT ON COLUMN product_imports.heartbeat_at IS 'Время последнего обработанного ряда, задание без свежего времени возвращается в очередь';
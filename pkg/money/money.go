@@ -0,0 +1,266 @@
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount of money")
+	ErrTooManyDecimals = errors.New("too many decimal places")
+)
+
+// exponents - number of minor units digits of currencies (dirams, kopecks, cents)
+var exponents = map[string]int{
+	"TJS": 2,
+	"RUB": 2,
+	"USD": 2,
+}
+
+// unknownExponent is used while the currency is unknown, e.g. right after decoding a request or scanning
+// a numeric column, it keeps enough decimal places to tell whether the amount fits its currency later
+const unknownExponent = 8
+
+// maxDigits keeps amounts within int64
+const maxDigits = 18
+
+// Money is an exact amount of money in minor units of its currency, e.g. 1999 of TJS is 19.99 somoni.
+// In JSON it is an exact decimal number, in Postgres it is numeric
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// Exponent returns the number of decimal places the currency allows
+func Exponent(currency string) int {
+	if exponent, ok := exponents[currency]; ok {
+		return exponent
+	}
+	return unknownExponent
+}
+
+// FromMinor returns the money of amount minor units
+func FromMinor(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromMajor returns the money of amount whole units
+func FromMajor(amount int64, currency string) Money {
+	return Money{Amount: amount * pow10(Exponent(currency)), Currency: currency}
+}
+
+// Parse reads a decimal like "19.99" exactly, more decimal places than the currency allows is an error
+func Parse(s string, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" || !isDigits(whole) || (strings.Contains(digits, ".") && (fraction == "" || !isDigits(fraction))) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	exponent := Exponent(currency)
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%w: at most %d allowed", ErrTooManyDecimals, exponent)
+	}
+
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole)+exponent > maxDigits {
+		return Money{}, fmt.Errorf("%w: %q is too large", ErrInvalidAmount, s)
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// In returns the same amount in minor units of the currency, it fails when the amount has more decimal places
+// than the currency allows
+func (m Money) In(currency string) (Money, error) {
+	from, to := Exponent(m.Currency), Exponent(currency)
+	if to >= from {
+		return Money{Amount: m.Amount * pow10(to-from), Currency: currency}, nil
+	}
+
+	if m.Amount%pow10(from-to) != 0 {
+		return Money{}, fmt.Errorf("%w: at most %d allowed", ErrTooManyDecimals, to)
+	}
+
+	return Money{Amount: m.Amount / pow10(from-to), Currency: currency}, nil
+}
+
+// Decimals returns the number of decimal places the amount actually has, e.g. 1 for 19.90
+func (m Money) Decimals() int {
+	exponent := Exponent(m.Currency)
+
+	amount := m.Amount
+	for exponent > 0 && amount%10 == 0 {
+		amount /= 10
+		exponent--
+	}
+
+	return exponent
+}
+
+// FitsIn reports whether the amount has no more decimal places than the currency allows
+func (m Money) FitsIn(currency string) bool {
+	return m.Decimals() <= Exponent(currency)
+}
+
+// RoundTo returns the amount in minor units of the currency rounded half away from zero
+func (m Money) RoundTo(currency string) Money {
+	from, to := Exponent(m.Currency), Exponent(currency)
+	if to >= from {
+		return Money{Amount: m.Amount * pow10(to-from), Currency: currency}
+	}
+
+	return Money{Amount: divRound(m.Amount, pow10(from-to)), Currency: currency}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Cmp compares amounts regardless of the exponents of their currencies: -1 if m < other, 0 if equal, +1 if m > other
+func (m Money) Cmp(other Money) int {
+	exponent := max(Exponent(m.Currency), Exponent(other.Currency))
+
+	a := m.Amount * pow10(exponent-Exponent(m.Currency))
+	b := other.Amount * pow10(exponent-Exponent(other.Currency))
+
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the amount as a decimal with all decimal places of the currency, e.g. "19.90",
+// trailing zeros are dropped while the currency is unknown
+func (m Money) String() string {
+	exponent := Exponent(m.Currency)
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	fraction := fmt.Sprintf("%0*d", exponent, amount%pow10(exponent))
+	if _, known := exponents[m.Currency]; !known {
+		if fraction = strings.TrimRight(fraction, "0"); fraction == "" {
+			return fmt.Sprintf("%s%d", sign, amount/pow10(exponent))
+		}
+	}
+
+	return fmt.Sprintf("%s%d.%s", sign, amount/pow10(exponent), fraction)
+}
+
+// MarshalJSON writes the amount as an exact decimal number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a number or a string, keeping the currency the value already has
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s, m.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+// UnmarshalParam reads the amount from a query parameter when binding with gin
+func (m *Money) UnmarshalParam(param string) error {
+	if param == "" {
+		return nil
+	}
+
+	parsed, err := Parse(param, m.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) int64 {
+	result := int64(1)
+	for range n {
+		result *= 10
+	}
+	return result
+}
+
+// divRound divides rounding half away from zero
+func divRound(a, b int64) int64 {
+	quotient, remainder := a/b, a%b
+	if 2*abs(remainder) >= b {
+		if a < 0 {
+			return quotient - 1
+		}
+		return quotient + 1
+	}
+	return quotient
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// bigPow10 is pow10 for exponents of numeric values read from Postgres
+func bigPow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
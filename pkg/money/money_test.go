@@ -0,0 +1,167 @@
+package money
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{"whole", "19", "TJS", Money{Amount: 1900, Currency: "TJS"}, nil},
+		{"minor units", "19.99", "TJS", Money{Amount: 1999, Currency: "TJS"}, nil},
+		{"one decimal", "19.9", "USD", Money{Amount: 1990, Currency: "USD"}, nil},
+		{"trailing zeros beyond exponent", "19.9900", "RUB", Money{Amount: 1999, Currency: "RUB"}, nil},
+		{"leading zeros", "007.50", "TJS", Money{Amount: 750, Currency: "TJS"}, nil},
+		{"negative", "-0.01", "TJS", Money{Amount: -1, Currency: "TJS"}, nil},
+		{"spaces", " 5 ", "TJS", Money{Amount: 500, Currency: "TJS"}, nil},
+		{"unknown currency", "1.12345678", "", Money{Amount: 112345678}, nil},
+		{"too many decimals", "19.999", "TJS", Money{}, ErrTooManyDecimals},
+		{"too many decimals of unknown currency", "0.123456789", "", Money{}, ErrTooManyDecimals},
+		{"empty", "", "TJS", Money{}, ErrInvalidAmount},
+		{"no whole part", ".5", "TJS", Money{}, ErrInvalidAmount},
+		{"no fraction", "5.", "TJS", Money{}, ErrInvalidAmount},
+		{"letters", "5a", "TJS", Money{}, ErrInvalidAmount},
+		{"exponent notation", "1e3", "TJS", Money{}, ErrInvalidAmount},
+		{"too large", "10000000000000000", "TJS", Money{}, ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q, %q) error = %v, want %v", tt.s, tt.currency, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.s, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		currency string
+		want     Money
+	}{
+		{"down", Money{Amount: 123449999}, "TJS", Money{Amount: 123, Currency: "TJS"}},
+		{"half up", Money{Amount: 123500000}, "TJS", Money{Amount: 124, Currency: "TJS"}},
+		{"half away from zero", Money{Amount: -123500000}, "TJS", Money{Amount: -124, Currency: "TJS"}},
+		{"negative down", Money{Amount: -123400000}, "TJS", Money{Amount: -123, Currency: "TJS"}},
+		{"same exponent", Money{Amount: 1999, Currency: "USD"}, "TJS", Money{Amount: 1999, Currency: "TJS"}},
+		{"more decimal places", Money{Amount: 1999, Currency: "TJS"}, "", Money{Amount: 1999000000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.RoundTo(tt.currency); got != tt.want {
+				t.Errorf("%+v.RoundTo(%q) = %+v, want %+v", tt.money, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1990, Currency: "TJS"}, "19.90"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 1090000000}, "10.9"},
+		{Money{Amount: 300000000}, "3"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestScanNumeric(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    pgtype.Numeric
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"exact", pgtype.Numeric{Int: big.NewInt(1999), Exp: -2, Valid: true}, "TJS", 1999, false},
+		{"scaled up", pgtype.Numeric{Int: big.NewInt(5), Exp: 0, Valid: true}, "TJS", 500, false},
+		{"positive exponent", pgtype.Numeric{Int: big.NewInt(12), Exp: 2, Valid: true}, "TJS", 120000, false},
+		{"rounded half up", pgtype.Numeric{Int: big.NewInt(19995), Exp: -3, Valid: true}, "TJS", 2000, false},
+		{"rounded down", pgtype.Numeric{Int: big.NewInt(19994), Exp: -3, Valid: true}, "TJS", 1999, false},
+		{"rounded half away from zero", pgtype.Numeric{Int: big.NewInt(-19995), Exp: -3, Valid: true}, "TJS", -2000, false},
+		{"unknown currency", pgtype.Numeric{Int: big.NewInt(109253), Exp: -4, Valid: true}, "", 1092530000, false},
+		{"null", pgtype.Numeric{}, "TJS", 0, true},
+		{"nan", pgtype.Numeric{NaN: true, Valid: true}, "TJS", 0, true},
+		{"out of range", pgtype.Numeric{Int: big.NewInt(1), Exp: 30, Valid: true}, "TJS", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Money{Currency: tt.currency}
+			err := m.ScanNumeric(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanNumeric(%v) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && m.Amount != tt.want {
+				t.Errorf("ScanNumeric(%v) amount = %d, want %d", tt.value, m.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericValueRoundTrip(t *testing.T) {
+	for _, m := range []Money{{Amount: 1999, Currency: "TJS"}, {Amount: -1, Currency: "USD"}, {Amount: 112345678}} {
+		value, err := m.NumericValue()
+		if err != nil {
+			t.Fatalf("%+v.NumericValue() error = %v", m, err)
+		}
+
+		scanned := Money{Currency: m.Currency}
+		if err = scanned.ScanNumeric(value); err != nil {
+			t.Fatalf("ScanNumeric(%v) error = %v", value, err)
+		}
+		if scanned != m {
+			t.Errorf("round trip of %+v = %+v", m, scanned)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{"number", `19.99`, Money{Amount: 1999, Currency: "TJS"}, false},
+		{"string", `"19.99"`, Money{Amount: 1999, Currency: "TJS"}, false},
+		{"null keeps the value", `null`, Money{Currency: "TJS"}, false},
+		{"too many decimals", `19.999`, Money{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Money{Currency: "TJS"}
+			err := m.UnmarshalJSON([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON(%s) error = %v, want error %v", tt.data, err, tt.wantErr)
+			}
+			if !tt.wantErr && m != tt.want {
+				t.Errorf("UnmarshalJSON(%s) = %+v, want %+v", tt.data, m, tt.want)
+			}
+		})
+	}
+}
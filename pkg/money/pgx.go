@@ -0,0 +1,52 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math/big"
+)
+
+// ScanNumeric implements pgtype.NumericScanner. The database keeps amounts without currency,
+// so the amount is read with the exponent of the currency m already has (the one of unknown currency for a new value)
+// and rounded half away from zero
+func (m *Money) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		return errors.New("cannot scan NULL into Money")
+	}
+
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: %v is not a finite number", ErrInvalidAmount, v)
+	}
+
+	amount := new(big.Int).Set(v.Int)
+	shift := v.Exp + int32(Exponent(m.Currency))
+
+	if shift >= 0 {
+		amount.Mul(amount, bigPow10(shift))
+	} else {
+		divisor := bigPow10(-shift)
+		quotient, remainder := new(big.Int).QuoRem(amount, divisor, new(big.Int))
+		if remainder.Abs(remainder).Mul(remainder, big.NewInt(2)).Cmp(divisor) >= 0 {
+			quotient.Add(quotient, big.NewInt(int64(amount.Sign())))
+		}
+		amount = quotient
+	}
+
+	if !amount.IsInt64() {
+		return fmt.Errorf("%w: %v is out of range", ErrInvalidAmount, amount)
+	}
+
+	m.Amount = amount.Int64()
+
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer, so Money can be passed as a numeric query argument
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{
+		Int:   big.NewInt(m.Amount),
+		Exp:   -int32(Exponent(m.Currency)),
+		Valid: true,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package money
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math/big"
+)
+
+// rateExponent is the number of decimal places of exchange rates, the scale of their numeric columns
+const rateExponent = unknownExponent
+
+// Rate is an exact exchange rate with 8 decimal places, the price of one unit of a currency in another one.
+// Units holds the rate multiplied by 10^8. In JSON it is an exact decimal number, in Postgres it is numeric
+type Rate struct {
+	Units int64
+}
+
+// ParseRate reads a decimal like "10.9253" exactly, more than 8 decimal places is an error
+func ParseRate(s string) (Rate, error) {
+	m, err := Parse(s, "")
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{Units: m.Amount}, nil
+}
+
+// RateFromRat rounds the exact quotient to 8 decimal places half away from zero, rates computed from feeds,
+// e.g. a cross rate through rubles, are divisions that rarely fit 8 places
+func RateFromRat(x *big.Rat) (Rate, error) {
+	scaled := new(big.Rat).Mul(x, new(big.Rat).SetInt(bigPow10(rateExponent)))
+
+	denominator := scaled.Denom()
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), denominator, new(big.Int))
+	if remainder.Abs(remainder).Mul(remainder, big.NewInt(2)).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+	}
+
+	if !quotient.IsInt64() {
+		return Rate{}, fmt.Errorf("%w: rate %v is out of range", ErrInvalidAmount, x.FloatString(rateExponent))
+	}
+
+	return Rate{Units: quotient.Int64()}, nil
+}
+
+// Rat returns the exact value of the rate
+func (r Rate) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(r.Units), bigPow10(rateExponent))
+}
+
+func (r Rate) IsPositive() bool {
+	return r.Units > 0
+}
+
+// String formats the rate as a decimal without trailing zeros, e.g. "10.9253"
+func (r Rate) String() string {
+	return r.money().String()
+}
+
+// MarshalJSON writes the rate as an exact decimal number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return r.money().MarshalJSON()
+}
+
+// UnmarshalJSON reads the rate from a number or a string
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	m := r.money()
+	if err := m.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	r.Units = m.Amount
+
+	return nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner, the value is rounded to 8 decimal places
+func (r *Rate) ScanNumeric(v pgtype.Numeric) error {
+	m := r.money()
+	if err := m.ScanNumeric(v); err != nil {
+		return err
+	}
+
+	r.Units = m.Amount
+
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer, so Rate can be passed as a numeric query argument
+func (r Rate) NumericValue() (pgtype.Numeric, error) {
+	return r.money().NumericValue()
+}
+
+// money returns the rate as an amount of unknown currency, its exponent is the one of rates
+func (r Rate) money() Money {
+	return Money{Amount: r.Units}
+}
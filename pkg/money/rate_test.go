@@ -0,0 +1,82 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{"10.9253", 1092530000, false},
+		{"0.00000001", 1, false},
+		{"1", 100000000, false},
+		{"0.123456789", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseRate(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		}
+		if got.Units != tt.want {
+			t.Errorf("ParseRate(%q) = %d, want %d", tt.s, got.Units, tt.want)
+		}
+	}
+}
+
+func TestRateFromRat(t *testing.T) {
+	tests := []struct {
+		name    string
+		x       *big.Rat
+		want    int64
+		wantErr bool
+	}{
+		{"exact", big.NewRat(109253, 10000), 1092530000, false},
+		{"rounded down", big.NewRat(1, 3), 33333333, false},
+		{"rounded up", big.NewRat(2, 3), 66666667, false},
+		{"half away from zero", big.NewRat(1, 200000000), 1, false},
+		{"negative half away from zero", big.NewRat(-1, 200000000), -1, false},
+		{"cross rate", new(big.Rat).Quo(big.NewRat(1, 1), big.NewRat(1433, 10000)), 697836706, false},
+		{"out of range", new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 64)), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RateFromRat(tt.x)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RateFromRat(%v) error = %v, want error %v", tt.x, err, tt.wantErr)
+			}
+			if got.Units != tt.want {
+				t.Errorf("RateFromRat(%v) = %d, want %d", tt.x, got.Units, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateJSON(t *testing.T) {
+	rate, err := ParseRate("10.9253")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := rate.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "10.9253" {
+		t.Errorf("MarshalJSON() = %s, want 10.9253", data)
+	}
+
+	var decoded Rate
+	if err = decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != rate {
+		t.Errorf("UnmarshalJSON(%s) = %+v, want %+v", data, decoded, rate)
+	}
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Tx - alias of pgx transaction, so repositories don't have to import pgx directly
+type Tx = pgx.Tx
+
+// Executor is implemented by both the pool and a transaction, so the same query code
+// can be run either standalone or as a part of a bigger transaction
+type Executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// WithinTx runs fn inside a transaction. The transaction is committed when fn returns nil
+// and rolled back otherwise, the error of fn is returned as is
+func (p *Postgres) WithinTx(ctx context.Context, op string, fn func(tx Tx) error) error {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return ErrCreateTx(op, err)
+	}
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return fmt.Errorf("%w (%w)", err, ErrRollback(op, rbErr))
+		}
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return ErrCommit(op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// FileStorage - interface of a place where uploaded files are kept. Keys are slash separated
+// relative paths like "products/1/abc/original.jpg", so the same key works for local disk and S3-compatible buckets
+type FileStorage interface {
+	// Save writes the content of r under key, overwriting existing file
+	Save(ctx context.Context, key string, contentType string, r io.Reader) error
+	// Delete removes the file, missing files are not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients can download the file from
+	URL(key string) string
+}